@@ -7,12 +7,15 @@ import (
 	"kasir-api/docs"
 	_ "kasir-api/docs"
 	"kasir-api/internal/handlers"
+	"kasir-api/internal/models"
 	"kasir-api/internal/repositories"
 	"kasir-api/internal/services"
+	"kasir-api/internal/storage"
 	"log"
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 	httpSwagger "github.com/swaggo/http-swagger"
@@ -21,6 +24,13 @@ import (
 type Config struct {
 	Port   string `mapstructure:"PORT"`
 	DBConn string `mapstructure:"DB_CONN"`
+	// BlockExpiredLots - tolak checkout dari lot yang sudah kedaluwarsa
+	BlockExpiredLots bool `mapstructure:"BLOCK_EXPIRED_LOTS"`
+	// LowStockThreshold - stok outlet di bawah angka ini memicu webhook product.stock_low, 0 = nonaktif
+	LowStockThreshold float64 `mapstructure:"LOW_STOCK_THRESHOLD"`
+	// MediaDir - folder penyimpanan gambar produk, MediaBaseURL - prefix URL publiknya
+	MediaDir     string `mapstructure:"MEDIA_DIR"`
+	MediaBaseURL string `mapstructure:"MEDIA_BASE_URL"`
 }
 
 // @title           Kasir API
@@ -47,8 +57,18 @@ func main() {
 	}
 
 	config := Config{
-		Port:   viper.GetString("PORT"),
-		DBConn: viper.GetString("DB_CONN"),
+		Port:              viper.GetString("PORT"),
+		DBConn:            viper.GetString("DB_CONN"),
+		BlockExpiredLots:  viper.GetBool("BLOCK_EXPIRED_LOTS"),
+		LowStockThreshold: viper.GetFloat64("LOW_STOCK_THRESHOLD"),
+		MediaDir:          viper.GetString("MEDIA_DIR"),
+		MediaBaseURL:      viper.GetString("MEDIA_BASE_URL"),
+	}
+	if config.MediaDir == "" {
+		config.MediaDir = "uploads"
+	}
+	if config.MediaBaseURL == "" {
+		config.MediaBaseURL = "/media"
 	}
 
 	//setup database
@@ -58,6 +78,10 @@ func main() {
 	}
 	defer db.Close()
 
+	if err := database.Migrate(db); err != nil {
+		log.Fatal("Failed migration ", err)
+	}
+
 	// ... pring-print version ...
 
 	// Cek PORT untuk menentukan environment
@@ -79,10 +103,32 @@ func main() {
 	// Jalur untuk membuka UI Swagger
 	http.HandleFunc("/swagger/", httpSwagger.WrapHandler)
 
+	// Penyimpanan gambar produk di filesystem lokal, disajikan di /media/
+	mediaStorage, err := storage.NewLocal(config.MediaDir, config.MediaBaseURL)
+	if err != nil {
+		log.Fatal("Failed media storage ", err)
+	}
+	mediaFiles := http.StripPrefix("/media/", http.FileServer(http.Dir(mediaStorage.Dir())))
+	http.HandleFunc("/media/", func(w http.ResponseWriter, r *http.Request) {
+		// Nama file acak, jangan bocorkan isi folder lewat directory listing
+		if strings.HasSuffix(r.URL.Path, "/") {
+			http.NotFound(w, r)
+			return
+		}
+		mediaFiles.ServeHTTP(w, r)
+	})
+
 	productRepo := repositories.NewProductRepository(db)
-	productService := services.NewProductService(productRepo)
+	productRepo.ImageURL = mediaStorage.URL
+	productService := services.NewProductService(productRepo, mediaStorage)
 	productHandler := handlers.NewProductHandler(productService)
 
+	// Terapkan harga terjadwal yang sudah jatuh tempo
+	runEvery(time.Minute, "apply scheduled prices", func() error {
+		_, err := productService.ApplyDuePrices()
+		return err
+	})
+
 	categoryRepo := repositories.NewCategoryRepository(db)
 	categoryService := services.NewCategoryService(categoryRepo)
 	categoryHandler := handlers.NewCategoryHandler(categoryService)
@@ -90,17 +136,143 @@ func main() {
 	// Setup routes
 	http.HandleFunc("/api/products", productHandler.HandleProducts)
 	http.HandleFunc("/api/products/", productHandler.HandleProductByID)
+	http.HandleFunc("/api/products/import", productHandler.Import)
+	http.HandleFunc("/api/products/export", productHandler.Export)
+	http.HandleFunc("/api/products/batch", productHandler.Batch)
 	http.HandleFunc("/api/categories", categoryHandler.HandleCategories)
 	http.HandleFunc("/api/categories/", categoryHandler.HandleCategoryByID)
 
 	// Transaction
 	transactionRepo := repositories.NewTransactionRepository(db)
+	transactionRepo.BlockExpiredLots = config.BlockExpiredLots
+	transactionRepo.LowStockThreshold = models.QuantityFromFloat(config.LowStockThreshold)
 	transactionService := services.NewTransactionService(transactionRepo)
 	transactionHandler := handlers.NewTransactionHandler(transactionService)
 
 	http.HandleFunc("/api/checkout", transactionHandler.HandleCheckout) // POST
+	http.HandleFunc("/api/transactions/", transactionHandler.HandleTransactionByID)
 	http.HandleFunc("/api/report/hari-ini", transactionHandler.HandleDailyReport)
 	http.HandleFunc("/api/report", transactionHandler.HandleReportByDate)
+	http.HandleFunc("/api/report/stock-movements", transactionHandler.HandleStockMovementReport)
+	http.HandleFunc("/api/report/outlets", transactionHandler.HandleOutletReport)
+
+	// Penerimaan barang
+	purchaseRepo := repositories.NewPurchaseRepository(db)
+	purchaseService := services.NewPurchaseService(purchaseRepo)
+	purchaseHandler := handlers.NewPurchaseHandler(purchaseService)
+
+	http.HandleFunc("/api/purchases", purchaseHandler.HandlePurchases)
+	http.HandleFunc("/api/purchases/", purchaseHandler.HandlePurchaseByID)
+
+	// Lot & kedaluwarsa
+	inventoryRepo := repositories.NewInventoryRepository(db)
+	inventoryService := services.NewInventoryService(inventoryRepo)
+	inventoryHandler := handlers.NewInventoryHandler(inventoryService)
+
+	http.HandleFunc("/api/inventory/expiring", inventoryHandler.HandleExpiring)
+
+	// Pelanggan
+	customerRepo := repositories.NewCustomerRepository(db)
+	customerService := services.NewCustomerService(customerRepo)
+	customerHandler := handlers.NewCustomerHandler(customerService)
+
+	http.HandleFunc("/api/customers", customerHandler.HandleCustomers)
+	http.HandleFunc("/api/customers/", customerHandler.HandleCustomerByID)
+	http.HandleFunc("/api/customers/lookup", customerHandler.Lookup)
+
+	// Program poin
+	loyaltyRepo := repositories.NewLoyaltyRepository(db)
+	loyaltyService := services.NewLoyaltyService(loyaltyRepo)
+	loyaltyHandler := handlers.NewLoyaltyHandler(loyaltyService)
+
+	http.HandleFunc("/api/loyalty/settings", loyaltyHandler.HandleSettings)
+	http.HandleFunc("/api/loyalty/category-rates", loyaltyHandler.HandleCategoryRates)
+	http.HandleFunc("/api/loyalty/category-rates/", loyaltyHandler.HandleCategoryRate)
+
+	// Hanguskan poin yang sudah lewat masa berlaku
+	runEvery(time.Hour, "expire loyalty points", func() error {
+		_, err := loyaltyService.ExpirePoints()
+		return err
+	})
+
+	// Kartu hadiah & store credit
+	storedValueRepo := repositories.NewStoredValueRepository(db)
+	storedValueService := services.NewStoredValueService(storedValueRepo)
+	storedValueHandler := handlers.NewStoredValueHandler(storedValueService)
+
+	http.HandleFunc("/api/stored-value", storedValueHandler.HandleAccounts)
+	http.HandleFunc("/api/stored-value/", storedValueHandler.HandleAccountByCode)
+
+	// Keranjang parkir & open order
+	cartRepo := repositories.NewCartRepository(db, transactionRepo)
+	cartService := services.NewCartService(cartRepo)
+	cartHandler := handlers.NewCartHandler(cartService)
+
+	http.HandleFunc("/api/carts", cartHandler.HandleCarts)
+	http.HandleFunc("/api/carts/", cartHandler.HandleCartByID)
+
+	// Mode restoran: meja & layar dapur
+	tableRepo := repositories.NewTableRepository(db)
+	tableService := services.NewTableService(tableRepo)
+	tableHandler := handlers.NewTableHandler(tableService)
+
+	http.HandleFunc("/api/tables", tableHandler.HandleTables)
+	http.HandleFunc("/api/tables/", tableHandler.HandleTableByID)
+
+	kitchenRepo := repositories.NewKitchenRepository(db)
+	kitchenService := services.NewKitchenService(kitchenRepo)
+	kitchenHandler := handlers.NewKitchenHandler(kitchenService)
+
+	http.HandleFunc("/api/kitchen/tickets", kitchenHandler.HandleTickets)
+	http.HandleFunc("/api/kitchen/tickets/", kitchenHandler.HandleTicketStatus)
+
+	// Reservasi stok pesanan web shop & kiosk
+	reservationRepo := repositories.NewReservationRepository(db)
+	reservationService := services.NewReservationService(reservationRepo)
+	reservationHandler := handlers.NewReservationHandler(reservationService)
+
+	http.HandleFunc("/api/reservations", reservationHandler.HandleReservations)
+	http.HandleFunc("/api/reservations/", reservationHandler.HandleReservationByID)
+
+	// Tandai reservasi yang lewat waktu sebagai expired
+	runEvery(time.Minute, "expire stock reservations", func() error {
+		_, err := reservationService.ExpireDue()
+		return err
+	})
+
+	// Multi-outlet: katalog bersama, stok per outlet
+	outletRepo := repositories.NewOutletRepository(db)
+	outletService := services.NewOutletService(outletRepo)
+	outletHandler := handlers.NewOutletHandler(outletService)
+
+	http.HandleFunc("/api/outlets", outletHandler.HandleOutlets)
+	http.HandleFunc("/api/outlets/", outletHandler.HandleOutletByID)
+
+	// Transfer stok antar outlet/gudang: draft -> dikirim -> diterima
+	transferRepo := repositories.NewTransferRepository(db)
+	transferService := services.NewTransferService(transferRepo)
+	transferHandler := handlers.NewTransferHandler(transferService)
+
+	http.HandleFunc("/api/transfers", transferHandler.HandleTransfers)
+	http.HandleFunc("/api/transfers/", transferHandler.HandleTransferByID)
+
+	// Jejak audit, ditulis oleh trigger database di transaksi yang sama dengan perubahannya
+	auditRepo := repositories.NewAuditRepository(db)
+	auditService := services.NewAuditService(auditRepo)
+	auditHandler := handlers.NewAuditHandler(auditService)
+
+	http.HandleFunc("/api/audit", auditHandler.HandleAudit)
+
+	// Webhook: event ditulis ke outbox di transaksi DB yang sama, lalu dikirim dispatcher setelah commit
+	webhookRepo := repositories.NewWebhookRepository(db)
+	webhookService := services.NewWebhookService(webhookRepo)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+
+	http.HandleFunc("/api/webhooks", webhookHandler.HandleWebhooks)
+	http.HandleFunc("/api/webhooks/", webhookHandler.HandleWebhookByID)
+
+	runEvery(5*time.Second, "deliver webhooks", webhookService.Dispatch)
+	runEvery(time.Hour, "purge webhook history", webhookService.PurgeHistory)
 
 	// Health check endpoint
 	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -118,3 +290,16 @@ func main() {
 		fmt.Println("Gagal Running Server")
 	}
 }
+
+// runEvery menjalankan job latar belakang secara berkala selama server hidup
+func runEvery(interval time.Duration, name string, job func() error) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := job(); err != nil {
+				log.Printf("Job %s gagal: %v", name, err)
+			}
+		}
+	}()
+}
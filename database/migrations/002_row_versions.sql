@@ -0,0 +1,3 @@
+-- Versi baris untuk optimistic concurrency (ETag / If-Match)
+ALTER TABLE products ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
@@ -0,0 +1,5 @@
+-- Soft delete: produk/kategori yang dihapus hanya diarsipkan supaya riwayat transaksi tetap utuh
+ALTER TABLE products ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_products_active ON products (id) WHERE deleted_at IS NULL;
@@ -0,0 +1,21 @@
+-- Simpan nama produk dan harga satuan saat checkout supaya riwayat tidak ikut berubah
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS product_name TEXT;
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS unit_price INT;
+
+-- Backfill baris lama: nama dari produk saat ini, harga satuan dihitung dari subtotal
+-- (lebih akurat daripada harga produk sekarang yang mungkin sudah berubah)
+UPDATE transaction_details td
+SET product_name = p.name
+FROM products p
+WHERE td.product_id = p.id AND td.product_name IS NULL;
+
+UPDATE transaction_details
+SET product_name = '(produk dihapus)'
+WHERE product_name IS NULL;
+
+UPDATE transaction_details
+SET unit_price = CASE WHEN quantity > 0 THEN subtotal / quantity ELSE 0 END
+WHERE unit_price IS NULL;
+
+ALTER TABLE transaction_details ALTER COLUMN product_name SET NOT NULL;
+ALTER TABLE transaction_details ALTER COLUMN unit_price SET NOT NULL;
@@ -0,0 +1,26 @@
+-- Riwayat perubahan harga produk
+CREATE TABLE IF NOT EXISTS product_price_history (
+    id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(id),
+    old_price INT NOT NULL,
+    new_price INT NOT NULL,
+    source TEXT NOT NULL DEFAULT 'manual', -- manual | schedule
+    schedule_id INT,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_history_product ON product_price_history (product_id, changed_at DESC);
+
+-- Harga terjadwal, diterapkan otomatis saat effective_from sudah lewat
+CREATE TABLE IF NOT EXISTS product_price_schedules (
+    id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(id),
+    price INT NOT NULL CHECK (price >= 0),
+    effective_from TIMESTAMPTZ NOT NULL,
+    applied_at TIMESTAMPTZ,
+    cancelled_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_schedules_pending ON product_price_schedules (product_id, effective_from)
+    WHERE applied_at IS NULL AND cancelled_at IS NULL;
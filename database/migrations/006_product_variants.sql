@@ -0,0 +1,23 @@
+-- Sumbu opsi varian per produk, mis. [{"name": "Ukuran", "values": ["S", "M", "L"]}]
+ALTER TABLE products ADD COLUMN IF NOT EXISTS options JSONB NOT NULL DEFAULT '[]';
+
+-- Varian dengan SKU, harga dan stok sendiri
+CREATE TABLE IF NOT EXISTS product_variants (
+    id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(id),
+    sku TEXT,
+    name TEXT NOT NULL,
+    options JSONB NOT NULL DEFAULT '{}',
+    price INT NOT NULL CHECK (price >= 0),
+    stock INT NOT NULL DEFAULT 0,
+    version INT NOT NULL DEFAULT 1,
+    deleted_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_variants_product ON product_variants (product_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_variants_sku ON product_variants (sku) WHERE sku IS NOT NULL AND deleted_at IS NULL;
+
+-- Baris transaksi mencatat varian yang terjual, product_id tetap produk induk
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS variant_id INT;
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS variant_name TEXT;
@@ -0,0 +1,40 @@
+-- Satuan dasar produk (stok selalu disimpan dalam satuan dasar) dan harga pokok per satuan dasar
+ALTER TABLE products ADD COLUMN IF NOT EXISTS base_unit TEXT NOT NULL DEFAULT 'pcs';
+ALTER TABLE products ADD COLUMN IF NOT EXISTS cost INT NOT NULL DEFAULT 0;
+
+-- Satuan jual/beli lain dengan faktor konversi ke satuan dasar, mis. 1 dus = 40 pcs
+CREATE TABLE IF NOT EXISTS product_units (
+    id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(id),
+    name TEXT NOT NULL,
+    factor INT NOT NULL CHECK (factor > 0),
+    price INT CHECK (price >= 0), -- NULL = harga satuan dasar x faktor
+    sellable BOOLEAN NOT NULL DEFAULT TRUE,
+    purchasable BOOLEAN NOT NULL DEFAULT TRUE,
+    UNIQUE (product_id, name)
+);
+
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS unit TEXT;
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS unit_factor INT NOT NULL DEFAULT 1;
+
+-- Penerimaan barang dari supplier
+CREATE TABLE IF NOT EXISTS purchase_receipts (
+    id SERIAL PRIMARY KEY,
+    supplier TEXT NOT NULL DEFAULT '',
+    note TEXT NOT NULL DEFAULT '',
+    total_cost INT NOT NULL DEFAULT 0,
+    received_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS purchase_receipt_lines (
+    id SERIAL PRIMARY KEY,
+    receipt_id INT NOT NULL REFERENCES purchase_receipts(id) ON DELETE CASCADE,
+    product_id INT NOT NULL REFERENCES products(id),
+    variant_id INT REFERENCES product_variants(id),
+    unit TEXT NOT NULL,
+    unit_factor INT NOT NULL,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    base_quantity INT NOT NULL,
+    unit_cost INT NOT NULL DEFAULT 0,
+    subtotal INT NOT NULL DEFAULT 0
+);
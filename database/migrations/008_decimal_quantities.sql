@@ -0,0 +1,17 @@
+-- Jumlah desimal untuk barang timbangan (kg, liter), maksimal 3 angka di belakang koma
+ALTER TABLE products ALTER COLUMN stock TYPE NUMERIC(14, 3);
+ALTER TABLE product_variants ALTER COLUMN stock TYPE NUMERIC(14, 3);
+ALTER TABLE product_units ALTER COLUMN factor TYPE NUMERIC(14, 3);
+ALTER TABLE transaction_details ALTER COLUMN quantity TYPE NUMERIC(14, 3);
+ALTER TABLE transaction_details ALTER COLUMN unit_factor TYPE NUMERIC(14, 3);
+ALTER TABLE purchase_receipt_lines ALTER COLUMN quantity TYPE NUMERIC(14, 3);
+ALTER TABLE purchase_receipt_lines ALTER COLUMN unit_factor TYPE NUMERIC(14, 3);
+ALTER TABLE purchase_receipt_lines ALTER COLUMN base_quantity TYPE NUMERIC(14, 3);
+
+-- Jumlah angka desimal yang boleh dipakai saat menjual produk (0 = hanya bilangan bulat)
+ALTER TABLE products ADD COLUMN IF NOT EXISTS quantity_precision SMALLINT NOT NULL DEFAULT 0
+    CHECK (quantity_precision BETWEEN 0 AND 3);
+
+-- Kode PLU 5 digit untuk barcode timbangan (EAN-13 berawalan 2x)
+ALTER TABLE products ADD COLUMN IF NOT EXISTS plu TEXT;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_plu ON products (plu) WHERE plu IS NOT NULL;
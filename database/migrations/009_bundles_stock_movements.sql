@@ -0,0 +1,28 @@
+-- Produk paket (bundle): tidak punya stok sendiri, stok diambil dari komponennya
+ALTER TABLE products ADD COLUMN IF NOT EXISTS is_bundle BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS product_bundle_items (
+    id SERIAL PRIMARY KEY,
+    bundle_id INT NOT NULL REFERENCES products(id),
+    component_id INT NOT NULL REFERENCES products(id),
+    component_variant_id INT REFERENCES product_variants(id),
+    quantity NUMERIC(14, 3) NOT NULL CHECK (quantity > 0) -- dalam satuan dasar komponen
+);
+
+CREATE INDEX IF NOT EXISTS idx_bundle_items_bundle ON product_bundle_items (bundle_id);
+
+-- Riwayat pergerakan stok, quantity bertanda (negatif = keluar)
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(id),
+    variant_id INT REFERENCES product_variants(id),
+    quantity NUMERIC(14, 3) NOT NULL,
+    reason TEXT NOT NULL, -- sale | bundle_component | purchase
+    reference_type TEXT NOT NULL DEFAULT '',
+    reference_id INT,
+    bundle_product_id INT REFERENCES products(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_movements_product ON stock_movements (product_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_stock_movements_created ON stock_movements (created_at);
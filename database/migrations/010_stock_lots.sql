@@ -0,0 +1,19 @@
+-- Lot/batch stok dengan tanggal kedaluwarsa, dibuat saat penerimaan barang.
+-- quantity = sisa lot dalam satuan dasar, stok di luar lot dianggap stok tanpa lot.
+CREATE TABLE IF NOT EXISTS stock_lots (
+    id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(id),
+    variant_id INT REFERENCES product_variants(id),
+    lot_number TEXT NOT NULL DEFAULT '',
+    expires_at DATE,
+    quantity NUMERIC(14, 3) NOT NULL CHECK (quantity >= 0),
+    received_quantity NUMERIC(14, 3) NOT NULL,
+    receipt_id INT REFERENCES purchase_receipts(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_lots_fefo ON stock_lots (product_id, variant_id, expires_at) WHERE quantity > 0;
+CREATE INDEX IF NOT EXISTS idx_stock_lots_expiry ON stock_lots (expires_at) WHERE quantity > 0;
+
+ALTER TABLE purchase_receipt_lines ADD COLUMN IF NOT EXISTS lot_id INT REFERENCES stock_lots(id);
+ALTER TABLE stock_movements ADD COLUMN IF NOT EXISTS lot_id INT REFERENCES stock_lots(id);
@@ -0,0 +1,8 @@
+-- Kategori bertingkat (departemen -> kategori -> subkategori) dan kategori produk
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS parent_id INT REFERENCES categories(id);
+ALTER TABLE categories DROP CONSTRAINT IF EXISTS categories_parent_not_self;
+ALTER TABLE categories ADD CONSTRAINT categories_parent_not_self CHECK (parent_id <> id);
+CREATE INDEX IF NOT EXISTS idx_categories_parent ON categories (parent_id);
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS category_id INT REFERENCES categories(id);
+CREATE INDEX IF NOT EXISTS idx_products_category ON products (category_id);
@@ -0,0 +1,10 @@
+-- SKU produk (kunci upsert untuk impor katalog) dan snapshot SKU di baris transaksi
+ALTER TABLE products ADD COLUMN IF NOT EXISTS sku TEXT;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_sku ON products (sku) WHERE sku IS NOT NULL;
+
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS sku TEXT;
+
+-- Sebelumnya hanya varian yang punya SKU
+UPDATE transaction_details td SET sku = v.sku
+FROM product_variants v
+WHERE td.variant_id = v.id AND td.sku IS NULL AND v.sku IS NOT NULL;
@@ -0,0 +1,17 @@
+-- Data pelanggan dan transaksi yang terhubung ke pelanggan
+CREATE TABLE IF NOT EXISTS customers (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    -- phone disimpan dalam bentuk ternormalisasi (hanya angka, awalan 0) untuk pencarian di kasir
+    phone TEXT,
+    email TEXT,
+    notes TEXT NOT NULL DEFAULT '',
+    version INT NOT NULL DEFAULT 1,
+    deleted_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customers_phone ON customers (phone) WHERE deleted_at IS NULL AND phone IS NOT NULL;
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS customer_id INT REFERENCES customers(id);
+CREATE INDEX IF NOT EXISTS idx_transactions_customer ON transactions (customer_id, created_at) WHERE customer_id IS NOT NULL;
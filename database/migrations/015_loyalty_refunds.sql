@@ -0,0 +1,63 @@
+-- Program poin pelanggan dan retur (refund) transaksi
+
+-- Satu baris pengaturan. rupiah_per_point 0 berarti poin tidak dikumpulkan.
+CREATE TABLE IF NOT EXISTS loyalty_settings (
+    id INT PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+    rupiah_per_point INT NOT NULL DEFAULT 0 CHECK (rupiah_per_point >= 0),
+    point_value INT NOT NULL DEFAULT 1 CHECK (point_value > 0), -- rupiah per poin saat ditukar
+    min_redeem_points INT NOT NULL DEFAULT 0 CHECK (min_redeem_points >= 0),
+    expiry_days INT NOT NULL DEFAULT 0 CHECK (expiry_days >= 0), -- 0 = poin tidak kedaluwarsa
+    version INT NOT NULL DEFAULT 1
+);
+INSERT INTO loyalty_settings (id) VALUES (1) ON CONFLICT (id) DO NOTHING;
+
+-- Tarif khusus per kategori, berlaku juga untuk subkategorinya (tarif terdekat yang dipakai)
+CREATE TABLE IF NOT EXISTS loyalty_category_rates (
+    category_id INT PRIMARY KEY REFERENCES categories(id),
+    rupiah_per_point INT NOT NULL CHECK (rupiah_per_point >= 0)
+);
+
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS points_balance INT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS refunds (
+    id SERIAL PRIMARY KEY,
+    transaction_id INT NOT NULL REFERENCES transactions(id),
+    amount INT NOT NULL, -- uang yang dikembalikan ke pelanggan
+    points_discount INT NOT NULL DEFAULT 0, -- bagian potongan poin dari barang yang diretur
+    points_returned INT NOT NULL DEFAULT 0,
+    points_reversed INT NOT NULL DEFAULT 0,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_refunds_transaction ON refunds (transaction_id);
+
+CREATE TABLE IF NOT EXISTS refund_lines (
+    id SERIAL PRIMARY KEY,
+    refund_id INT NOT NULL REFERENCES refunds(id) ON DELETE CASCADE,
+    detail_id INT NOT NULL REFERENCES transaction_details(id),
+    quantity NUMERIC(14, 3) NOT NULL CHECK (quantity > 0),
+    amount INT NOT NULL
+);
+
+-- Buku besar poin. Entri bertambah (earn/return) menyimpan sisa yang belum terpakai di remaining,
+-- penukaran dan kedaluwarsa memakai sisa itu urut dari yang paling cepat kedaluwarsa.
+CREATE TABLE IF NOT EXISTS loyalty_ledger (
+    id SERIAL PRIMARY KEY,
+    customer_id INT NOT NULL REFERENCES customers(id),
+    points INT NOT NULL,
+    type TEXT NOT NULL, -- earn | redeem | reverse | return | expire | adjust
+    remaining INT NOT NULL DEFAULT 0,
+    expires_at TIMESTAMPTZ,
+    transaction_id INT REFERENCES transactions(id),
+    refund_id INT REFERENCES refunds(id),
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_loyalty_ledger_customer ON loyalty_ledger (customer_id, id);
+CREATE INDEX IF NOT EXISTS idx_loyalty_ledger_open ON loyalty_ledger (expires_at) WHERE remaining > 0;
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS points_redeemed INT NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS points_discount INT NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS points_earned INT NOT NULL DEFAULT 0;
+
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS refunded_quantity NUMERIC(14, 3) NOT NULL DEFAULT 0;
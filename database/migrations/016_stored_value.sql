@@ -0,0 +1,48 @@
+-- Saldo tersimpan: kartu hadiah (gift card) dan store credit dari retur
+CREATE TABLE IF NOT EXISTS stored_value_accounts (
+    id SERIAL PRIMARY KEY,
+    code TEXT NOT NULL UNIQUE,
+    type TEXT NOT NULL CHECK (type IN ('gift_card', 'store_credit')),
+    customer_id INT REFERENCES customers(id),
+    balance INT NOT NULL DEFAULT 0 CHECK (balance >= 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_stored_value_customer ON stored_value_accounts (customer_id) WHERE customer_id IS NOT NULL;
+
+-- Buku besar saldo, amount bertanda dan balance_after adalah saldo setelah entri ini
+CREATE TABLE IF NOT EXISTS stored_value_ledger (
+    id SERIAL PRIMARY KEY,
+    account_id INT NOT NULL REFERENCES stored_value_accounts(id),
+    amount INT NOT NULL CHECK (amount <> 0),
+    type TEXT NOT NULL, -- issue | top_up | redeem
+    balance_after INT NOT NULL CHECK (balance_after >= 0),
+    transaction_id INT REFERENCES transactions(id),
+    refund_id INT REFERENCES refunds(id),
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_stored_value_ledger_account ON stored_value_ledger (account_id, id);
+
+-- Entri buku besar tidak boleh diubah atau dihapus, koreksi dilakukan dengan entri baru
+CREATE OR REPLACE FUNCTION stored_value_ledger_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'stored_value_ledger is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS stored_value_ledger_no_change ON stored_value_ledger;
+CREATE TRIGGER stored_value_ledger_no_change
+    BEFORE UPDATE OR DELETE ON stored_value_ledger
+    FOR EACH ROW EXECUTE FUNCTION stored_value_ledger_immutable();
+
+-- Rincian pembayaran transaksi, total amount semua baris = transactions.total_amount
+CREATE TABLE IF NOT EXISTS transaction_payments (
+    id SERIAL PRIMARY KEY,
+    transaction_id INT NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    method TEXT NOT NULL, -- cash | card | stored_value
+    account_id INT REFERENCES stored_value_accounts(id),
+    amount INT NOT NULL CHECK (amount > 0)
+);
+CREATE INDEX IF NOT EXISTS idx_transaction_payments_transaction ON transaction_payments (transaction_id);
+
+ALTER TABLE refunds ADD COLUMN IF NOT EXISTS store_credit_account_id INT REFERENCES stored_value_accounts(id);
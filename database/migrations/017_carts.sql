@@ -0,0 +1,39 @@
+-- Keranjang yang diparkir dan pesanan terbuka (mis. pesanan meja di kafe)
+CREATE TABLE IF NOT EXISTS carts (
+    id SERIAL PRIMARY KEY,
+    status TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'held', 'converted', 'cancelled')),
+    label TEXT NOT NULL DEFAULT '', -- mis. nama pelanggan atau "Meja 5"
+    customer_id INT REFERENCES customers(id),
+    note TEXT NOT NULL DEFAULT '',
+    reserve_stock BOOLEAN NOT NULL DEFAULT FALSE,
+    transaction_id INT REFERENCES transactions(id),
+    version INT NOT NULL DEFAULT 1,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_carts_active ON carts (status, updated_at) WHERE status IN ('open', 'held');
+
+CREATE TABLE IF NOT EXISTS cart_lines (
+    id SERIAL PRIMARY KEY,
+    cart_id INT NOT NULL REFERENCES carts(id) ON DELETE CASCADE,
+    product_id INT NOT NULL REFERENCES products(id),
+    variant_id INT REFERENCES product_variants(id),
+    unit TEXT NOT NULL DEFAULT '',
+    quantity NUMERIC(14, 3) NOT NULL CHECK (quantity > 0),
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_cart_lines_cart ON cart_lines (cart_id, id);
+
+-- Stok yang ditahan untuk baris keranjang, dalam satuan dasar (paket menahan stok komponennya)
+CREATE TABLE IF NOT EXISTS stock_reservations (
+    id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(id),
+    variant_id INT REFERENCES product_variants(id),
+    quantity NUMERIC(14, 3) NOT NULL CHECK (quantity > 0),
+    cart_id INT REFERENCES carts(id) ON DELETE CASCADE,
+    cart_line_id INT REFERENCES cart_lines(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_product ON stock_reservations (product_id, variant_id);
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_cart ON stock_reservations (cart_id);
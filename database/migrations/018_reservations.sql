@@ -0,0 +1,22 @@
+-- Reservasi stok berbatas waktu untuk pesanan web shop & kiosk yang belum dibayar
+CREATE TABLE IF NOT EXISTS reservations (
+    id SERIAL PRIMARY KEY,
+    reference TEXT NOT NULL DEFAULT '', -- nomor pesanan di sistem asal
+    status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'consumed', 'expired', 'released')),
+    expires_at TIMESTAMPTZ NOT NULL,
+    transaction_id INT REFERENCES transactions(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_reservations_active ON reservations (expires_at) WHERE status = 'active';
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reservations_reference ON reservations (reference) WHERE status = 'active' AND reference <> '';
+
+-- Baris stok yang ditahan dimiliki keranjang atau reservasi. Baris reservasi tidak dihapus
+-- saat selesai (released_at diisi) supaya isinya tetap bisa dilihat.
+ALTER TABLE stock_reservations ADD COLUMN IF NOT EXISTS reservation_id INT REFERENCES reservations(id);
+ALTER TABLE stock_reservations ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+ALTER TABLE stock_reservations ADD COLUMN IF NOT EXISTS released_at TIMESTAMPTZ;
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_reservation ON stock_reservations (reservation_id);
+
+DROP INDEX IF EXISTS idx_stock_reservations_product;
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_product ON stock_reservations (product_id, variant_id) WHERE released_at IS NULL;
@@ -0,0 +1,43 @@
+-- Mode restoran: meja, modifier pesanan dan tiket dapur
+
+CREATE TABLE IF NOT EXISTS dining_tables (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    seats INT NOT NULL DEFAULT 0 CHECK (seats >= 0),
+    version INT NOT NULL DEFAULT 1,
+    deleted_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_dining_tables_name ON dining_tables (LOWER(name)) WHERE deleted_at IS NULL;
+
+-- Modifier produk, mis. "less sugar" (0) atau "extra shot" (+5000 per porsi)
+CREATE TABLE IF NOT EXISTS product_modifiers (
+    id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(id),
+    name TEXT NOT NULL,
+    price_delta INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_modifiers_name ON product_modifiers (product_id, LOWER(name));
+
+-- Stasiun dapur yang menyiapkan produk (mis. "bar", "kitchen"), kosong berarti tidak lewat dapur
+ALTER TABLE products ADD COLUMN IF NOT EXISTS kitchen_station TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE carts ADD COLUMN IF NOT EXISTS table_id INT REFERENCES dining_tables(id);
+
+-- Modifier dihargai saat checkout, jadi yang disimpan di keranjang hanya pilihannya
+CREATE TABLE IF NOT EXISTS cart_line_modifiers (
+    cart_line_id INT NOT NULL REFERENCES cart_lines(id) ON DELETE CASCADE,
+    modifier_id INT NOT NULL REFERENCES product_modifiers(id) ON DELETE CASCADE,
+    PRIMARY KEY (cart_line_id, modifier_id)
+);
+
+-- Tiket dapur: satu per baris keranjang yang produknya punya stasiun
+ALTER TABLE cart_lines ADD COLUMN IF NOT EXISTS kitchen_station TEXT NOT NULL DEFAULT '';
+ALTER TABLE cart_lines ADD COLUMN IF NOT EXISTS kitchen_status TEXT CHECK (kitchen_status IN ('queued', 'cooking', 'ready', 'served'));
+ALTER TABLE cart_lines ADD COLUMN IF NOT EXISTS kitchen_updated_at TIMESTAMPTZ;
+CREATE INDEX IF NOT EXISTS idx_cart_lines_kitchen ON cart_lines (kitchen_station, created_at) WHERE kitchen_status IN ('queued', 'cooking', 'ready');
+
+-- Snapshot modifier di detail transaksi: [{"modifier_id": 1, "name": "extra shot", "price_delta": 5000}]
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS modifiers JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS table_id INT REFERENCES dining_tables(id);
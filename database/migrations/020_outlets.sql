@@ -0,0 +1,72 @@
+-- Multi-outlet: katalog produk & kategori tetap bersama, stok dicatat per outlet
+CREATE TABLE IF NOT EXISTS outlets (
+    id SERIAL PRIMARY KEY,
+    code TEXT NOT NULL,
+    name TEXT NOT NULL,
+    address TEXT NOT NULL DEFAULT '',
+    -- is_default - outlet untuk request yang tidak menyebut outlet dan untuk perubahan stok dari katalog
+    is_default BOOLEAN NOT NULL DEFAULT FALSE,
+    version INT NOT NULL DEFAULT 1,
+    deleted_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_outlets_code ON outlets (LOWER(code)) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_outlets_default ON outlets (is_default) WHERE is_default;
+
+INSERT INTO outlets (code, name, is_default)
+SELECT 'MAIN', 'Outlet Utama', TRUE
+WHERE NOT EXISTS (SELECT 1 FROM outlets);
+
+-- Stok per outlet dalam satuan dasar. products.stock dan product_variants.stock sekarang
+-- adalah total semua outlet, diperbarui bersamaan dengan tabel ini.
+CREATE TABLE IF NOT EXISTS outlet_stock (
+    outlet_id INT NOT NULL REFERENCES outlets(id),
+    product_id INT NOT NULL REFERENCES products(id),
+    variant_id INT REFERENCES product_variants(id),
+    quantity NUMERIC(14, 3) NOT NULL DEFAULT 0
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_outlet_stock_key ON outlet_stock (outlet_id, product_id, COALESCE(variant_id, 0));
+
+-- Stok yang sudah ada masuk ke outlet utama
+INSERT INTO outlet_stock (outlet_id, product_id, variant_id, quantity)
+SELECT o.id, p.id, NULL, p.stock
+FROM products p CROSS JOIN outlets o
+WHERE o.is_default
+ON CONFLICT DO NOTHING;
+
+INSERT INTO outlet_stock (outlet_id, product_id, variant_id, quantity)
+SELECT o.id, v.product_id, v.id, v.stock
+FROM product_variants v CROSS JOIN outlets o
+WHERE o.is_default
+ON CONFLICT DO NOTHING;
+
+-- Dokumen & pergerakan stok yang sudah ada dianggap milik outlet utama
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS outlet_id INT REFERENCES outlets(id);
+ALTER TABLE stock_movements ADD COLUMN IF NOT EXISTS outlet_id INT REFERENCES outlets(id);
+ALTER TABLE stock_lots ADD COLUMN IF NOT EXISTS outlet_id INT REFERENCES outlets(id);
+ALTER TABLE purchase_receipts ADD COLUMN IF NOT EXISTS outlet_id INT REFERENCES outlets(id);
+ALTER TABLE stock_reservations ADD COLUMN IF NOT EXISTS outlet_id INT REFERENCES outlets(id);
+ALTER TABLE carts ADD COLUMN IF NOT EXISTS outlet_id INT REFERENCES outlets(id);
+ALTER TABLE reservations ADD COLUMN IF NOT EXISTS outlet_id INT REFERENCES outlets(id);
+
+UPDATE transactions SET outlet_id = (SELECT id FROM outlets WHERE is_default) WHERE outlet_id IS NULL;
+UPDATE stock_movements SET outlet_id = (SELECT id FROM outlets WHERE is_default) WHERE outlet_id IS NULL;
+UPDATE stock_lots SET outlet_id = (SELECT id FROM outlets WHERE is_default) WHERE outlet_id IS NULL;
+UPDATE purchase_receipts SET outlet_id = (SELECT id FROM outlets WHERE is_default) WHERE outlet_id IS NULL;
+UPDATE stock_reservations SET outlet_id = (SELECT id FROM outlets WHERE is_default) WHERE outlet_id IS NULL;
+UPDATE carts SET outlet_id = (SELECT id FROM outlets WHERE is_default) WHERE outlet_id IS NULL;
+UPDATE reservations SET outlet_id = (SELECT id FROM outlets WHERE is_default) WHERE outlet_id IS NULL;
+
+ALTER TABLE transactions ALTER COLUMN outlet_id SET NOT NULL;
+ALTER TABLE stock_movements ALTER COLUMN outlet_id SET NOT NULL;
+ALTER TABLE stock_lots ALTER COLUMN outlet_id SET NOT NULL;
+ALTER TABLE purchase_receipts ALTER COLUMN outlet_id SET NOT NULL;
+ALTER TABLE stock_reservations ALTER COLUMN outlet_id SET NOT NULL;
+ALTER TABLE carts ALTER COLUMN outlet_id SET NOT NULL;
+ALTER TABLE reservations ALTER COLUMN outlet_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_outlet ON transactions (outlet_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_stock_movements_outlet ON stock_movements (outlet_id, created_at);
+
+DROP INDEX IF EXISTS idx_stock_reservations_product;
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_product ON stock_reservations (outlet_id, product_id, variant_id) WHERE released_at IS NULL;
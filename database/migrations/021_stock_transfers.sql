@@ -0,0 +1,30 @@
+-- Gudang dicatat sebagai outlet jenis warehouse: bisa menerima barang dan mengirim transfer, tidak untuk penjualan
+ALTER TABLE outlets ADD COLUMN IF NOT EXISTS kind TEXT NOT NULL DEFAULT 'store' CHECK (kind IN ('store', 'warehouse'));
+
+-- Transfer stok antar outlet/gudang: draft -> in_transit (stok asal berkurang) -> received (stok tujuan bertambah)
+CREATE TABLE IF NOT EXISTS stock_transfers (
+    id SERIAL PRIMARY KEY,
+    source_outlet_id INT NOT NULL REFERENCES outlets(id),
+    destination_outlet_id INT NOT NULL REFERENCES outlets(id),
+    status TEXT NOT NULL DEFAULT 'draft' CHECK (status IN ('draft', 'in_transit', 'received', 'cancelled')),
+    note TEXT NOT NULL DEFAULT '',
+    version INT NOT NULL DEFAULT 1,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    dispatched_at TIMESTAMPTZ,
+    received_at TIMESTAMPTZ,
+    CHECK (source_outlet_id <> destination_outlet_id)
+);
+CREATE INDEX IF NOT EXISTS idx_stock_transfers_source ON stock_transfers (source_outlet_id, status);
+CREATE INDEX IF NOT EXISTS idx_stock_transfers_destination ON stock_transfers (destination_outlet_id, status);
+
+-- Jumlah dalam satuan dasar. received_quantity diisi saat diterima, selisihnya dengan quantity adalah selisih kirim.
+CREATE TABLE IF NOT EXISTS stock_transfer_lines (
+    id SERIAL PRIMARY KEY,
+    transfer_id INT NOT NULL REFERENCES stock_transfers(id) ON DELETE CASCADE,
+    product_id INT NOT NULL REFERENCES products(id),
+    variant_id INT REFERENCES product_variants(id),
+    quantity NUMERIC(14, 3) NOT NULL CHECK (quantity > 0),
+    received_quantity NUMERIC(14, 3) CHECK (received_quantity >= 0),
+    note TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS idx_stock_transfer_lines_transfer ON stock_transfer_lines (transfer_id);
@@ -0,0 +1,94 @@
+-- Jejak audit: setiap insert/update/delete di tabel master & dokumen dicatat oleh trigger,
+-- jadi selalu ikut transaksi DB yang sama dengan perubahannya. Actor dibaca dari setting
+-- transaksi kasir.actor (diisi aplikasi lewat set_config), kosong berarti job sistem.
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    actor TEXT NOT NULL,
+    entity TEXT NOT NULL,
+    entity_id TEXT,
+    action TEXT NOT NULL,
+    before_data JSONB,
+    after_data JSONB,
+    -- tx_id mengelompokkan baris dari satu transaksi DB, mis. semua perubahan stok satu checkout
+    tx_id BIGINT NOT NULL DEFAULT txid_current(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_audit_log_entity ON audit_log (entity, entity_id, id DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log (actor, id DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_created ON audit_log (created_at);
+
+-- audit_mask menyamarkan kolom rahasia sebelum baris dicatat. Di sini belum ada yang disamarkan,
+-- migrasi tabel yang menyimpan rahasia mengganti fungsi ini dengan CREATE OR REPLACE.
+CREATE OR REPLACE FUNCTION audit_mask(row_data JSONB) RETURNS JSONB AS $$
+    SELECT row_data;
+$$ LANGUAGE sql IMMUTABLE;
+
+-- audit_row(key_column, insert_action): key_column default 'id', insert_action mengganti 'create'
+-- (transaksi dicatat sebagai 'checkout'). Soft delete / restore lewat deleted_at dicatat sebagai delete / restore.
+CREATE OR REPLACE FUNCTION audit_row() RETURNS trigger AS $$
+DECLARE
+    key_column TEXT := COALESCE(TG_ARGV[0], 'id');
+    before_row JSONB;
+    after_row JSONB;
+    audit_action TEXT;
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        before_row := audit_mask(to_jsonb(OLD));
+    END IF;
+    IF TG_OP <> 'DELETE' THEN
+        after_row := audit_mask(to_jsonb(NEW));
+    END IF;
+    -- Update tanpa perubahan apa pun (mis. upsert dengan nilai yang sama) tidak dicatat
+    IF TG_OP = 'UPDATE' AND before_row = after_row THEN
+        RETURN NULL;
+    END IF;
+
+    audit_action := CASE TG_OP
+        WHEN 'INSERT' THEN COALESCE(TG_ARGV[1], 'create')
+        WHEN 'DELETE' THEN 'delete'
+        ELSE 'update'
+    END;
+    IF TG_OP = 'UPDATE' AND after_row ? 'deleted_at' THEN
+        IF before_row->'deleted_at' = 'null' AND after_row->'deleted_at' <> 'null' THEN
+            audit_action := 'delete';
+        ELSIF before_row->'deleted_at' <> 'null' AND after_row->'deleted_at' = 'null' THEN
+            audit_action := 'restore';
+        END IF;
+    END IF;
+
+    INSERT INTO audit_log (actor, entity, entity_id, action, before_data, after_data)
+    VALUES (
+        COALESCE(NULLIF(current_setting('kasir.actor', true), ''), 'system'),
+        TG_TABLE_NAME,
+        COALESCE(after_row, before_row)->>key_column,
+        audit_action,
+        before_row,
+        after_row
+    );
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DO $$
+DECLARE
+    t TEXT;
+BEGIN
+    FOREACH t IN ARRAY ARRAY[
+        'categories', 'products', 'product_variants', 'product_units', 'product_modifiers', 'product_images',
+        'product_bundle_items', 'product_price_schedules', 'customers', 'loyalty_settings', 'stored_value_accounts',
+        'refunds', 'purchase_receipts', 'carts', 'reservations', 'dining_tables', 'outlets', 'stock_transfers'
+    ] LOOP
+        EXECUTE format('DROP TRIGGER IF EXISTS audit_%1$s ON %1$I', t);
+        EXECUTE format('CREATE TRIGGER audit_%1$s AFTER INSERT OR UPDATE OR DELETE ON %1$I
+            FOR EACH ROW EXECUTE FUNCTION audit_row()', t);
+    END LOOP;
+END $$;
+
+-- Tabel dengan kunci selain id, dan checkout yang dicatat dengan aksi sendiri
+DROP TRIGGER IF EXISTS audit_loyalty_category_rates ON loyalty_category_rates;
+CREATE TRIGGER audit_loyalty_category_rates AFTER INSERT OR UPDATE OR DELETE ON loyalty_category_rates
+    FOR EACH ROW EXECUTE FUNCTION audit_row('category_id');
+
+DROP TRIGGER IF EXISTS audit_transactions ON transactions;
+CREATE TRIGGER audit_transactions AFTER INSERT OR UPDATE OR DELETE ON transactions
+    FOR EACH ROW EXECUTE FUNCTION audit_row('id', 'checkout');
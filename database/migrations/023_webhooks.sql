@@ -0,0 +1,71 @@
+-- Langganan webhook: sistem luar (sinkronisasi akuntansi, bot notifikasi) menerima event lewat POST
+-- yang ditandatangani HMAC-SHA256 dengan secret langganan
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id SERIAL PRIMARY KEY,
+    url TEXT NOT NULL,
+    events TEXT[] NOT NULL,
+    secret TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    version INT NOT NULL DEFAULT 1,
+    deleted_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Transactional outbox: event ditulis di transaksi DB yang sama dengan perubahannya,
+-- jadi hanya terlihat oleh dispatcher setelah commit dan ikut hilang kalau rollback
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id BIGSERIAL PRIMARY KEY,
+    event_type TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    -- dispatched_at diisi setelah event disebar ke webhook_deliveries
+    dispatched_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_outbox_events_pending ON outbox_events (id) WHERE dispatched_at IS NULL;
+
+-- Satu pengiriman per event per langganan, dicoba ulang dengan backoff eksponensial sampai berhasil
+-- atau batas percobaan habis
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id INT NOT NULL REFERENCES webhook_subscriptions(id),
+    event_id BIGINT NOT NULL REFERENCES outbox_events(id),
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'succeeded', 'failed')),
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_status_code INT,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    delivered_at TIMESTAMPTZ,
+    UNIQUE (subscription_id, event_id)
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription ON webhook_deliveries (subscription_id, id DESC);
+-- Dipakai pembersihan event outbox lama
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_event ON webhook_deliveries (event_id);
+
+-- Log tiap percobaan kirim. Isi respons penerima tidak disimpan supaya URL webhook tidak bisa
+-- dipakai membaca layanan lain lewat log pengiriman.
+CREATE TABLE IF NOT EXISTS webhook_delivery_attempts (
+    id BIGSERIAL PRIMARY KEY,
+    delivery_id BIGINT NOT NULL REFERENCES webhook_deliveries(id) ON DELETE CASCADE,
+    attempt INT NOT NULL,
+    status_code INT,
+    error TEXT NOT NULL DEFAULT '',
+    duration_ms INT NOT NULL,
+    attempted_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_delivery_attempts_delivery ON webhook_delivery_attempts (delivery_id, attempt);
+
+-- Kolom secret hanya dicatat sidiknya (md5 terpotong) di jejak audit: rotasi secret tetap terlihat
+-- tanpa membocorkan isinya
+CREATE OR REPLACE FUNCTION audit_mask(row_data JSONB) RETURNS JSONB AS $$
+    SELECT CASE WHEN row_data ? 'secret'
+        THEN jsonb_set(row_data, '{secret}', to_jsonb('md5:' || left(md5(row_data->>'secret'), 12)))
+        ELSE row_data
+    END;
+$$ LANGUAGE sql IMMUTABLE;
+
+DROP TRIGGER IF EXISTS audit_webhook_subscriptions ON webhook_subscriptions;
+CREATE TRIGGER audit_webhook_subscriptions AFTER INSERT OR UPDATE OR DELETE ON webhook_subscriptions
+    FOR EACH ROW EXECUTE FUNCTION audit_row();
@@ -0,0 +1,4 @@
+-- SKU hanya unik di antara produk aktif: produk yang diarsipkan melepas SKU-nya supaya bisa
+-- dipakai produk baru (mis. lewat impor katalog), sama seperti SKU varian
+DROP INDEX IF EXISTS idx_products_sku;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_sku ON products (sku) WHERE sku IS NOT NULL AND deleted_at IS NULL;
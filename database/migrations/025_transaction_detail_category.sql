@@ -0,0 +1,10 @@
+-- Snapshot kategori produk saat checkout: laporan penjualan per kategori tidak ikut berpindah
+-- kalau produk kemudian dipindah ke kategori lain
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS category_id INT REFERENCES categories(id);
+CREATE INDEX IF NOT EXISTS idx_transaction_details_category ON transaction_details (category_id);
+
+-- Baris lama memakai kategori produk saat ini, riwayat kategorinya tidak tersimpan
+UPDATE transaction_details td
+SET category_id = p.category_id
+FROM products p
+WHERE td.product_id = p.id AND td.category_id IS NULL;
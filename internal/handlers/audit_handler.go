@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"kasir-api/internal/models"
+	"kasir-api/internal/services"
+	"net/http"
+	"strconv"
+)
+
+type AuditHandler struct {
+	service *services.AuditService
+}
+
+func NewAuditHandler(service *services.AuditService) *AuditHandler {
+	return &AuditHandler{service: service}
+}
+
+// HandleAudit - GET /api/audit?entity=products&entity_id=5&action=update&actor=budi
+// &start_date=YYYY-MM-DD&end_date=YYYY-MM-DD&tx_id=123&limit=50&offset=0, terbaru dulu
+func (h *AuditHandler) HandleAudit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	filter := models.AuditFilter{
+		Entity:   q.Get("entity"),
+		EntityID: q.Get("entity_id"),
+		Action:   q.Get("action"),
+		Actor:    q.Get("actor"),
+	}
+	var err error
+	if filter.Start, err = queryOptionalDate(r, "start_date"); err != nil {
+		http.Error(w, "Invalid start_date format (YYYY-MM-DD)", http.StatusBadRequest)
+		return
+	}
+	if filter.End, err = queryOptionalDate(r, "end_date"); err != nil {
+		http.Error(w, "Invalid end_date format (YYYY-MM-DD)", http.StatusBadRequest)
+		return
+	}
+	if raw := q.Get("tx_id"); raw != "" {
+		txID, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid tx_id", http.StatusBadRequest)
+			return
+		}
+		filter.TxID = &txID
+	}
+	if filter.Limit, err = queryInt(r, "limit", 0); err != nil {
+		http.Error(w, "Invalid limit", http.StatusBadRequest)
+		return
+	}
+	if filter.Offset, err = queryInt(r, "offset", 0); err != nil {
+		http.Error(w, "Invalid offset", http.StatusBadRequest)
+		return
+	}
+
+	entries, err := h.service.GetAll(filter)
+	if errors.Is(err, services.ErrValidation) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
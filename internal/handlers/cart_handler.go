@@ -0,0 +1,340 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"kasir-api/internal/models"
+	"kasir-api/internal/repositories"
+	"kasir-api/internal/services"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type CartHandler struct {
+	service *services.CartService
+}
+
+func NewCartHandler(service *services.CartService) *CartHandler {
+	return &CartHandler{service: service}
+}
+
+// HandleCarts - GET/POST /api/carts
+func (h *CartHandler) HandleCarts(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	case http.MethodPost:
+		h.Create(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// GetAll - GET /api/carts?status=open,held&outlet_id=2 (default keranjang aktif di semua outlet)
+func (h *CartHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	outletID, err := queryOptionalInt(r, "outlet_id")
+	if err != nil {
+		http.Error(w, "Invalid outlet_id", http.StatusBadRequest)
+		return
+	}
+
+	var statuses []string
+	if raw := r.URL.Query().Get("status"); raw != "" {
+		for _, s := range strings.Split(raw, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				statuses = append(statuses, s)
+			}
+		}
+	}
+
+	carts, err := h.service.GetAll(statuses, outletID)
+	if errors.Is(err, services.ErrValidation) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(carts)
+}
+
+// Create - POST /api/carts {"label": "Meja 5", "reserve_stock": true, "lines": [...]}
+func (h *CartHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var cart models.Cart
+	err := json.NewDecoder(r.Body).Decode(&cart)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	created, err := h.service.Create(actorFrom(r), &cart)
+	if err != nil {
+		writeCartError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", versionETag(created.Version))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// HandleCartByID - /api/carts/{id}, /lines, /lines/{lineID}, /hold, /resume, /checkout
+func (h *CartHandler) HandleCartByID(w http.ResponseWriter, r *http.Request) {
+	parts := pathParams(r, "/api/carts/")
+	if len(parts) == 0 {
+		http.NotFound(w, r)
+		return
+	}
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		http.Error(w, "Invalid cart ID", http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case len(parts) == 1:
+		switch r.Method {
+		case http.MethodGet:
+			h.GetByID(w, r, id)
+		case http.MethodPut:
+			h.Update(w, r, id)
+		case http.MethodDelete:
+			h.changeStatus(w, r, id, h.service.Cancel)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	case len(parts) == 2 && parts[1] == "lines":
+		switch r.Method {
+		case http.MethodPost:
+			h.AddLine(w, r, id)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	case len(parts) == 3 && parts[1] == "lines":
+		lineID, err := strconv.Atoi(parts[2])
+		if err != nil {
+			http.Error(w, "Invalid line ID", http.StatusBadRequest)
+			return
+		}
+		switch r.Method {
+		case http.MethodPut:
+			h.UpdateLine(w, r, id, lineID)
+		case http.MethodDelete:
+			h.DeleteLine(w, r, id, lineID)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	case len(parts) == 2 && (parts[1] == "hold" || parts[1] == "resume" || parts[1] == "checkout"):
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		switch parts[1] {
+		case "hold":
+			h.changeStatus(w, r, id, h.service.Hold)
+		case "resume":
+			h.changeStatus(w, r, id, h.service.Resume)
+		default:
+			h.Checkout(w, r, id)
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *CartHandler) GetByID(w http.ResponseWriter, r *http.Request, id int) {
+	cart, err := h.service.GetByID(id)
+	if err != nil {
+		writeCartError(w, err)
+		return
+	}
+	writeJSONWithETag(w, r, versionETag(cart.Version), cart)
+}
+
+// Update - PUT /api/carts/{id}, mengubah label, pelanggan, catatan dan reserve_stock
+func (h *CartHandler) Update(w http.ResponseWriter, r *http.Request, id int) {
+	version, ok := optionalIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	var cart models.Cart
+	err := json.NewDecoder(r.Body).Decode(&cart)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	cart.ID = id
+	cart.Version = version
+
+	updated, err := h.service.Update(actorFrom(r), &cart)
+	if err != nil {
+		writeCartError(w, err)
+		return
+	}
+	writeCart(w, updated)
+}
+
+// AddLine - POST /api/carts/{id}/lines {"product_id": 1, "quantity": 2}
+func (h *CartHandler) AddLine(w http.ResponseWriter, r *http.Request, id int) {
+	version, ok := optionalIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	var line models.CartLine
+	err := json.NewDecoder(r.Body).Decode(&line)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	cart, err := h.service.AddLine(actorFrom(r), id, version, &line)
+	if err != nil {
+		writeCartError(w, err)
+		return
+	}
+	writeCart(w, cart)
+}
+
+// UpdateLine - PUT /api/carts/{id}/lines/{lineID} {"quantity": 3, "note": "..."}
+func (h *CartHandler) UpdateLine(w http.ResponseWriter, r *http.Request, id, lineID int) {
+	version, ok := optionalIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	var line models.CartLine
+	err := json.NewDecoder(r.Body).Decode(&line)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	line.ID = lineID
+
+	cart, err := h.service.UpdateLine(actorFrom(r), id, version, &line)
+	if err != nil {
+		writeCartError(w, err)
+		return
+	}
+	writeCart(w, cart)
+}
+
+func (h *CartHandler) DeleteLine(w http.ResponseWriter, r *http.Request, id, lineID int) {
+	version, ok := optionalIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	cart, err := h.service.DeleteLine(actorFrom(r), id, version, lineID)
+	if err != nil {
+		writeCartError(w, err)
+		return
+	}
+	writeCart(w, cart)
+}
+
+// changeStatus - hold, resume dan cancel (DELETE /api/carts/{id})
+func (h *CartHandler) changeStatus(w http.ResponseWriter, r *http.Request, id int, change func(actor string, id, version int) (*models.Cart, error)) {
+	version, ok := optionalIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	cart, err := change(actorFrom(r), id, version)
+	if err != nil {
+		writeCartError(w, err)
+		return
+	}
+	writeCart(w, cart)
+}
+
+// Checkout - POST /api/carts/{id}/checkout {"payments": [...], "redeem_points": 0}
+func (h *CartHandler) Checkout(w http.ResponseWriter, r *http.Request, id int) {
+	version, ok := optionalIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.CartCheckoutRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	transaction, err := h.service.Checkout(actorFrom(r), id, version, req)
+	if errors.Is(err, services.ErrValidation) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, repositories.ErrCartNotFound) || errors.Is(err, repositories.ErrCustomerNotFound) || errors.Is(err, repositories.ErrStoredValueNotFound) ||
+		errors.Is(err, repositories.ErrTableNotFound) || errors.Is(err, repositories.ErrModifierNotFound) ||
+		errors.Is(err, repositories.ErrProductNotFound) || errors.Is(err, repositories.ErrVariantNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, repositories.ErrVersionMismatch) {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+	if errors.Is(err, repositories.ErrCartClosed) || errors.Is(err, repositories.ErrLotExpired) || errors.Is(err, repositories.ErrOutletInUse) ||
+		errors.Is(err, repositories.ErrPointsRedemption) || errors.Is(err, repositories.ErrInsufficientBalance) || errors.Is(err, repositories.ErrPaymentMismatch) ||
+		errors.Is(err, repositories.ErrProductArchived) || errors.Is(err, repositories.ErrVariantArchived) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to process checkout: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transaction)
+}
+
+// optionalIfMatch - keranjang boleh diubah tanpa If-Match (layar kasir tunggal),
+// tapi kalau dikirim versinya dicek. ok false berarti response error sudah ditulis.
+func optionalIfMatch(w http.ResponseWriter, r *http.Request) (version int, ok bool) {
+	if r.Header.Get("If-Match") == "" {
+		return 0, true
+	}
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeIfMatchError(w, err)
+		return 0, false
+	}
+	return version, true
+}
+
+func writeCart(w http.ResponseWriter, cart *models.Cart) {
+	w.Header().Set("ETag", versionETag(cart.Version))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cart)
+}
+
+func writeCartError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrValidation):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, repositories.ErrCartNotFound), errors.Is(err, repositories.ErrCartLineNotFound),
+		errors.Is(err, repositories.ErrCustomerNotFound), errors.Is(err, repositories.ErrTableNotFound),
+		errors.Is(err, repositories.ErrModifierNotFound), errors.Is(err, repositories.ErrOutletNotFound),
+		errors.Is(err, repositories.ErrProductNotFound), errors.Is(err, repositories.ErrVariantNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, repositories.ErrVersionMismatch):
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+	case errors.Is(err, repositories.ErrCartClosed), errors.Is(err, repositories.ErrStockUnavailable),
+		errors.Is(err, repositories.ErrKitchenStarted), errors.Is(err, repositories.ErrOutletInUse),
+		errors.Is(err, repositories.ErrProductArchived), errors.Is(err, repositories.ErrVariantArchived):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -2,11 +2,16 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"kasir-api/internal/models"
+	"kasir-api/internal/repositories"
 	"kasir-api/internal/services"
 	"net/http"
 	"path"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type CategoryHandler struct {
@@ -34,17 +39,62 @@ func (h *CategoryHandler) HandleCategories(w http.ResponseWriter, r *http.Reques
 // @Tags         Categories
 // @Accept       json
 // @Produce      json
-// @Success      200  {array}  object{id=int,name=string,description=string}
+// @Param        include_archived  query  bool    false  "Sertakan kategori yang diarsipkan"
+// @Param        view              query  string  false  "flat (default, dengan path/breadcrumb) atau tree"
+// @Param        include           query  string  false  "stats untuk jumlah produk, stok, nilai persediaan dan penjualan"
+// @Param        start_date        query  string  false  "Awal periode penjualan untuk stats (YYYY-MM-DD), default 30 hari terakhir"
+// @Param        end_date          query  string  false  "Akhir periode penjualan untuk stats (YYYY-MM-DD), default hari ini"
+// @Success      200  {array}  object{id=int,name=string,description=string,parent_id=int,depth=int}
 // @Router       /api/categories [get]
 func (h *CategoryHandler) GetAll(w http.ResponseWriter, r *http.Request) {
-	categories, err := h.service.GetAll()
+	view := r.URL.Query().Get("view")
+	if view != "" && view != "flat" && view != "tree" {
+		http.Error(w, "view must be flat or tree", http.StatusBadRequest)
+		return
+	}
+
+	filter := models.CategoryFilter{
+		IncludeArchived: queryBool(r, "include_archived"),
+		Tree:            view == "tree",
+	}
+	for _, include := range strings.Split(r.URL.Query().Get("include"), ",") {
+		if strings.TrimSpace(include) == "stats" {
+			filter.IncludeStats = true
+		}
+	}
+	if filter.IncludeStats {
+		now := time.Now()
+		filter.SalesEnd = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		filter.SalesStart = filter.SalesEnd.AddDate(0, 0, -29)
+
+		var err error
+		if raw := r.URL.Query().Get("start_date"); raw != "" {
+			filter.SalesStart, err = time.Parse("2006-01-02", raw)
+			if err != nil {
+				http.Error(w, "Invalid start_date format (YYYY-MM-DD)", http.StatusBadRequest)
+				return
+			}
+		}
+		if raw := r.URL.Query().Get("end_date"); raw != "" {
+			filter.SalesEnd, err = time.Parse("2006-01-02", raw)
+			if err != nil {
+				http.Error(w, "Invalid end_date format (YYYY-MM-DD)", http.StatusBadRequest)
+				return
+			}
+		}
+	}
+
+	categories, err := h.service.GetAll(filter)
+	if errors.Is(err, services.ErrValidation) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(categories)
+	writeJSONWithContentETag(w, r, categories)
 }
 
 // CategoryHandler godoc
@@ -57,11 +107,18 @@ func (h *CategoryHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 // @Success      2200  {array}   object{id=int,name=string,description=string}
 // @Router       /api/categories/{id} [get]
 func (h *CategoryHandler) HandleCategoryByID(w http.ResponseWriter, r *http.Request) {
+	if parts := pathParams(r, "/api/categories/"); len(parts) > 1 {
+		h.handleCategorySubresource(w, r, parts)
+		return
+	}
+
 	switch r.Method {
 	case http.MethodGet:
 		h.GetByID(w, r)
 	case http.MethodPut:
 		h.Update(w, r)
+	case http.MethodPatch:
+		h.Patch(w, r)
 	case http.MethodDelete:
 		h.Delete(w, r)
 	default:
@@ -69,6 +126,37 @@ func (h *CategoryHandler) HandleCategoryByID(w http.ResponseWriter, r *http.Requ
 	}
 }
 
+func (h *CategoryHandler) handleCategorySubresource(w http.ResponseWriter, r *http.Request, parts []string) {
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		http.Error(w, "Invalid Category id", http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case len(parts) == 2 && parts[1] == "restore" && r.Method == http.MethodPost:
+		h.Restore(w, r, id)
+	case len(parts) == 2 && parts[1] == "restore":
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	case len(parts) == 2 && parts[1] == "move":
+		switch r.Method {
+		case http.MethodPost:
+			h.Move(w, r, id)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	case len(parts) == 2 && parts[1] == "sales":
+		switch r.Method {
+		case http.MethodGet:
+			h.GetSales(w, r, id)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
 func (h *CategoryHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 	// idStr := strings.TrimPrefix(r.URL.Path, "/api/categories/")
 	// id, err := strconv.Atoi(idStr)
@@ -84,8 +172,7 @@ func (h *CategoryHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(category)
+	writeJSONWithETag(w, r, versionETag(category.Version), category)
 }
 
 // CreateCategory godoc
@@ -94,7 +181,7 @@ func (h *CategoryHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 // @Tags         Categories
 // @Accept       json
 // @Produce      json
-// @Param        category  body  object{name=string,description=string}  true  "Data Kategori"
+// @Param        category  body  object{name=string,description=string,parent_id=int}  true  "Data Kategori"
 // @Success      201       {object}  object{id=int,name=string,description=string}
 // @Router       /api/categories [post]
 func (h *CategoryHandler) Create(w http.ResponseWriter, r *http.Request) {
@@ -105,13 +192,18 @@ func (h *CategoryHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.service.Create(&category)
+	err = h.service.Create(actorFrom(r), &category)
+	if errors.Is(err, repositories.ErrParentNotFound) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", versionETag(category.Version))
 	w.WriteHeader(http.StatusCreated) // 201
 	json.NewEncoder(w).Encode(category)
 }
@@ -123,7 +215,8 @@ func (h *CategoryHandler) Create(w http.ResponseWriter, r *http.Request) {
 // @Accept       json
 // @Produce      json
 // @Param        id        path    int     true  "Category ID"
-// @Param        category  body  object{name=string,description=string}  true  "Data Kategori"
+// @Param        If-Match  header  string  true  "ETag dari GET terakhir"
+// @Param        category  body  object{name=string,description=string,parent_id=int}  true  "Data Kategori, parent_id null = kategori teratas"
 // @Success      200       {object}  object{id=int,name=string,description=string}
 // @Router       /api/categories/{id} [put]
 func (h *CategoryHandler) Update(w http.ResponseWriter, r *http.Request) {
@@ -133,6 +226,12 @@ func (h *CategoryHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeIfMatchError(w, err)
+		return
+	}
+
 	var category models.Category
 	err = json.NewDecoder(r.Body).Decode(&category)
 	if err != nil {
@@ -141,23 +240,107 @@ func (h *CategoryHandler) Update(w http.ResponseWriter, r *http.Request) {
 	}
 
 	category.ID = id
-	err = h.service.Update(&category)
+	category.Version = version
+	err = h.service.Update(actorFrom(r), &category)
+	if errors.Is(err, repositories.ErrCategoryNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, repositories.ErrVersionMismatch) {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+	if errors.Is(err, repositories.ErrCategoryArchived) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if errors.Is(err, repositories.ErrParentNotFound) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, repositories.ErrCategoryCycle) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", versionETag(category.Version))
+	json.NewEncoder(w).Encode(category)
+}
+
+// PatchCategory godoc
+// @Summary      Update Sebagian Kategori
+// @Description  Memperbarui sebagian field kategori (JSON Merge Patch, RFC 7396)
+// @Tags         Categories
+// @Accept       json
+// @Produce      json
+// @Param        id        path    int     true  "Category ID"
+// @Param        If-Match  header  string  true  "ETag dari GET terakhir"
+// @Param        category  body  object{name=string,description=string}  true  "Field yang diubah"
+// @Success      200       {object}  object{id=int,name=string,description=string}
+// @Router       /api/categories/{id} [patch]
+func (h *CategoryHandler) Patch(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(path.Base(r.URL.Path))
+	if err != nil {
+		http.Error(w, "Invalid Category id", http.StatusBadRequest)
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeIfMatchError(w, err)
+		return
+	}
+
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	category, err := h.service.Patch(actorFrom(r), id, version, patch)
+	if errors.Is(err, repositories.ErrCategoryNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, repositories.ErrVersionMismatch) {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+	if errors.Is(err, repositories.ErrCategoryArchived) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if errors.Is(err, services.ErrInvalidPatch) || errors.Is(err, repositories.ErrParentNotFound) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, repositories.ErrCategoryCycle) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", versionETag(category.Version))
 	json.NewEncoder(w).Encode(category)
 }
 
 // DeleteCategory godoc
-// @Summary      Hapus Kategori
-// @Description  Menghapus kategori yang ada
+// @Summary      Arsipkan Kategori
+// @Description  Mengarsipkan kategori (soft delete), bisa dipulihkan lewat /restore
 // @Tags         Categories
 // @Accept       json
 // @Produce      json
 // @Param id path int true "Category ID"
+// @Param        If-Match  header  string  true  "ETag dari GET terakhir"
 // @Success      200       {object}  object{message=string}
 // @Router       /api/categories/{id} [delete]
 func (h *CategoryHandler) Delete(w http.ResponseWriter, r *http.Request) {
@@ -167,7 +350,25 @@ func (h *CategoryHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.service.Delete(id)
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeIfMatchError(w, err)
+		return
+	}
+
+	err = h.service.Delete(actorFrom(r), id, version)
+	if errors.Is(err, repositories.ErrCategoryNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, repositories.ErrCategoryHasChildren) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if errors.Is(err, repositories.ErrVersionMismatch) {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -175,6 +376,147 @@ func (h *CategoryHandler) Delete(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{
-		"message": "Category deleted successfully",
+		"message": "Category archived successfully",
 	})
 }
+
+// RestoreCategory godoc
+// @Summary      Pulihkan Kategori
+// @Description  Mengembalikan kategori yang diarsipkan
+// @Tags         Categories
+// @Produce      json
+// @Param        id        path    int     true   "Category ID"
+// @Param        If-Match  header  string  false  "ETag dari GET terakhir"
+// @Success      200       {object}  object{id=int,name=string,description=string}
+// @Router       /api/categories/{id}/restore [post]
+func (h *CategoryHandler) Restore(w http.ResponseWriter, r *http.Request, id int) {
+	version := 0
+	if r.Header.Get("If-Match") != "" {
+		v, err := ifMatchVersion(r)
+		if err != nil {
+			writeIfMatchError(w, err)
+			return
+		}
+		version = v
+	}
+
+	category, err := h.service.Restore(actorFrom(r), id, version)
+	if errors.Is(err, repositories.ErrCategoryNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, repositories.ErrParentNotFound) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if errors.Is(err, repositories.ErrVersionMismatch) {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+	if errors.Is(err, repositories.ErrCategoryNotArchived) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", versionETag(category.Version))
+	json.NewEncoder(w).Encode(category)
+}
+
+// MoveCategory godoc
+// @Summary      Pindahkan Kategori
+// @Description  Memindahkan kategori beserta semua subkategorinya ke parent lain, parent_id null menjadikannya kategori teratas
+// @Tags         Categories
+// @Accept       json
+// @Produce      json
+// @Param        id        path    int     true  "Category ID"
+// @Param        If-Match  header  string  true  "ETag dari GET terakhir"
+// @Param        body      body    object{parent_id=int}  true  "Parent baru"
+// @Success      200       {object}  object{id=int,name=string,parent_id=int}
+// @Router       /api/categories/{id}/move [post]
+func (h *CategoryHandler) Move(w http.ResponseWriter, r *http.Request, id int) {
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeIfMatchError(w, err)
+		return
+	}
+
+	var req struct {
+		ParentID *int `json:"parent_id"`
+	}
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	category, err := h.service.Move(actorFrom(r), id, version, req.ParentID)
+	if errors.Is(err, repositories.ErrCategoryNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, repositories.ErrVersionMismatch) {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+	if errors.Is(err, repositories.ErrCategoryArchived) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if errors.Is(err, repositories.ErrParentNotFound) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, repositories.ErrCategoryCycle) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", versionETag(category.Version))
+	json.NewEncoder(w).Encode(category)
+}
+
+// CategorySales godoc
+// @Summary      Penjualan Kategori
+// @Description  Total penjualan produk di kategori ini dan semua subkategorinya
+// @Tags         Categories
+// @Produce      json
+// @Param        id          path   int     true  "Category ID"
+// @Param        start_date  query  string  true  "YYYY-MM-DD"
+// @Param        end_date    query  string  true  "YYYY-MM-DD"
+// @Success      200  {object}  object{category_id=int,total_revenue=int,total_transaksi=int}
+// @Router       /api/categories/{id}/sales [get]
+func (h *CategoryHandler) GetSales(w http.ResponseWriter, r *http.Request, id int) {
+	startDate, err := time.Parse("2006-01-02", r.URL.Query().Get("start_date"))
+	if err != nil {
+		http.Error(w, "Invalid start_date format (YYYY-MM-DD)", http.StatusBadRequest)
+		return
+	}
+	endDate, err := time.Parse("2006-01-02", r.URL.Query().Get("end_date"))
+	if err != nil {
+		http.Error(w, "Invalid end_date format (YYYY-MM-DD)", http.StatusBadRequest)
+		return
+	}
+
+	sales, err := h.service.GetSales(id, startDate, endDate)
+	if errors.Is(err, repositories.ErrCategoryNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sales)
+}
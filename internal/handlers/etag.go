@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var (
+	errIfMatchRequired = errors.New("header If-Match wajib diisi, ambil ETag terbaru lewat GET")
+	errIfMatchInvalid  = errors.New("ETag pada If-Match tidak dikenali")
+)
+
+func versionETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// ifMatchVersion membaca versi dari header If-Match. "*" menghasilkan 0 (tanpa cek versi).
+// Hanya satu ETag kuat yang didukung, ETag lemah (W/) tidak pernah cocok.
+func ifMatchVersion(r *http.Request) (int, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" {
+		return 0, errIfMatchRequired
+	}
+	if header == "*" {
+		return 0, nil
+	}
+
+	tag := strings.Trim(header, `"`)
+	if strings.HasPrefix(header, "W/") || len(tag) != len(header)-2 {
+		return 0, errIfMatchInvalid
+	}
+	version, err := strconv.Atoi(tag)
+	if err != nil || version < 1 {
+		return 0, errIfMatchInvalid
+	}
+	return version, nil
+}
+
+// writeIfMatchError - 428 kalau header tidak dikirim, 412 kalau tidak cocok
+func writeIfMatchError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errIfMatchRequired) {
+		http.Error(w, err.Error(), http.StatusPreconditionRequired)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusPreconditionFailed)
+}
+
+// notModified mengecek If-None-Match (perbandingan lemah) terhadap ETag saat ini
+func notModified(r *http.Request, etag string) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+	current := strings.TrimPrefix(etag, "W/")
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == current {
+			return true
+		}
+	}
+	return false
+}
+
+// writeJSONWithETag menulis response JSON beserta ETag, atau 304 kalau client sudah punya versi terbaru
+func writeJSONWithETag(w http.ResponseWriter, r *http.Request, etag string, v interface{}) {
+	w.Header().Set("ETag", etag)
+	if notModified(r, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeJSONWithContentETag - untuk list, ETag diambil dari hash isi response
+func writeJSONWithContentETag(w http.ResponseWriter, r *http.Request, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	w.Header().Set("ETag", etag)
+	if notModified(r, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(append(body, '\n'))
+}
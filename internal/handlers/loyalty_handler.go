@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"kasir-api/internal/models"
+	"kasir-api/internal/repositories"
+	"kasir-api/internal/services"
+	"net/http"
+	"strconv"
+)
+
+type LoyaltyHandler struct {
+	service *services.LoyaltyService
+}
+
+func NewLoyaltyHandler(service *services.LoyaltyService) *LoyaltyHandler {
+	return &LoyaltyHandler{service: service}
+}
+
+// HandleSettings - GET/PUT /api/loyalty/settings
+func (h *LoyaltyHandler) HandleSettings(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		settings, err := h.service.GetSettings()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSONWithETag(w, r, versionETag(settings.Version), settings)
+	case http.MethodPut:
+		h.UpdateSettings(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// UpdateSettings - PUT /api/loyalty/settings {"rupiah_per_point": 10000, "point_value": 100, "expiry_days": 365}
+func (h *LoyaltyHandler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeIfMatchError(w, err)
+		return
+	}
+
+	var settings models.LoyaltySettings
+	err = json.NewDecoder(r.Body).Decode(&settings)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	settings.Version = version
+	err = h.service.UpdateSettings(actorFrom(r), &settings)
+	if errors.Is(err, services.ErrValidation) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, repositories.ErrVersionMismatch) {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", versionETag(settings.Version))
+	json.NewEncoder(w).Encode(settings)
+}
+
+// HandleCategoryRates - GET /api/loyalty/category-rates
+func (h *LoyaltyHandler) HandleCategoryRates(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	rates, err := h.service.GetCategoryRates()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rates)
+}
+
+// HandleCategoryRate - PUT/DELETE /api/loyalty/category-rates/{categoryID}
+func (h *LoyaltyHandler) HandleCategoryRate(w http.ResponseWriter, r *http.Request) {
+	parts := pathParams(r, "/api/loyalty/category-rates/")
+	if len(parts) != 1 {
+		http.NotFound(w, r)
+		return
+	}
+	categoryID, err := strconv.Atoi(parts[0])
+	if err != nil {
+		http.Error(w, "Invalid category ID", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		h.SetCategoryRate(w, r, categoryID)
+	case http.MethodDelete:
+		h.DeleteCategoryRate(w, r, categoryID)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// SetCategoryRate - PUT /api/loyalty/category-rates/{categoryID} {"rupiah_per_point": 5000}
+func (h *LoyaltyHandler) SetCategoryRate(w http.ResponseWriter, r *http.Request, categoryID int) {
+	var rate models.LoyaltyCategoryRate
+	err := json.NewDecoder(r.Body).Decode(&rate)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	rate.CategoryID = categoryID
+	err = h.service.SetCategoryRate(actorFrom(r), &rate)
+	if errors.Is(err, services.ErrValidation) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, repositories.ErrCategoryNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rate)
+}
+
+// DeleteCategoryRate - kategori kembali memakai tarif induknya atau tarif umum
+func (h *LoyaltyHandler) DeleteCategoryRate(w http.ResponseWriter, r *http.Request, categoryID int) {
+	err := h.service.DeleteCategoryRate(actorFrom(r), categoryID)
+	if errors.Is(err, repositories.ErrCategoryNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Category rate deleted successfully",
+	})
+}
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"kasir-api/internal/models"
+	"kasir-api/internal/repositories"
+	"kasir-api/internal/services"
+	"net/http"
+	"strconv"
+)
+
+type OutletHandler struct {
+	service *services.OutletService
+}
+
+func NewOutletHandler(service *services.OutletService) *OutletHandler {
+	return &OutletHandler{service: service}
+}
+
+// HandleOutlets - GET/POST /api/outlets
+func (h *OutletHandler) HandleOutlets(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	case http.MethodPost:
+		h.Create(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// GetAll - GET /api/outlets?include_archived=true, outlet default paling atas
+func (h *OutletHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	outlets, err := h.service.GetAll(queryBool(r, "include_archived"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(outlets)
+}
+
+// Create - POST /api/outlets {"code": "BDG", "name": "Cabang Bandung", "address": "..."}
+func (h *OutletHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var outlet models.Outlet
+	err := json.NewDecoder(r.Body).Decode(&outlet)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.Create(actorFrom(r), &outlet)
+	if err != nil {
+		writeOutletError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", versionETag(outlet.Version))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(outlet)
+}
+
+// HandleOutletByID - GET/PUT/DELETE /api/outlets/{id}, GET/POST /api/outlets/{id}/stock
+func (h *OutletHandler) HandleOutletByID(w http.ResponseWriter, r *http.Request) {
+	parts := pathParams(r, "/api/outlets/")
+	if len(parts) == 0 || len(parts) > 2 {
+		http.NotFound(w, r)
+		return
+	}
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		http.Error(w, "Invalid outlet ID", http.StatusBadRequest)
+		return
+	}
+
+	if len(parts) == 2 {
+		if parts[1] != "stock" {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			h.GetStock(w, r, id)
+		case http.MethodPost:
+			h.AdjustStock(w, r, id)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.GetByID(w, r, id)
+	case http.MethodPut:
+		h.Update(w, r, id)
+	case http.MethodDelete:
+		h.Delete(w, r, id)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *OutletHandler) GetByID(w http.ResponseWriter, r *http.Request, id int) {
+	outlet, err := h.service.GetByID(id)
+	if err != nil {
+		writeOutletError(w, err)
+		return
+	}
+
+	writeJSONWithETag(w, r, versionETag(outlet.Version), outlet)
+}
+
+// Update - PUT /api/outlets/{id}, "is_default": true memindahkan status default ke outlet ini
+func (h *OutletHandler) Update(w http.ResponseWriter, r *http.Request, id int) {
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeIfMatchError(w, err)
+		return
+	}
+
+	var outlet models.Outlet
+	err = json.NewDecoder(r.Body).Decode(&outlet)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	outlet.ID = id
+	outlet.Version = version
+	updated, err := h.service.Update(actorFrom(r), &outlet)
+	if err != nil {
+		writeOutletError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", versionETag(updated.Version))
+	json.NewEncoder(w).Encode(updated)
+}
+
+// Delete - mengarsipkan outlet, transaksi lama tetap terhubung
+func (h *OutletHandler) Delete(w http.ResponseWriter, r *http.Request, id int) {
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeIfMatchError(w, err)
+		return
+	}
+
+	err = h.service.Delete(actorFrom(r), id, version)
+	if err != nil {
+		writeOutletError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Outlet archived successfully",
+	})
+}
+
+// GetStock - GET /api/outlets/{id}/stock?product_id=5
+func (h *OutletHandler) GetStock(w http.ResponseWriter, r *http.Request, id int) {
+	productID, err := queryOptionalInt(r, "product_id")
+	if err != nil {
+		http.Error(w, "Invalid product_id", http.StatusBadRequest)
+		return
+	}
+
+	stock, err := h.service.GetStock(id, productID)
+	if err != nil {
+		writeOutletError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stock)
+}
+
+// AdjustStock - POST /api/outlets/{id}/stock {"product_id": 5, "variant_id": 2, "quantity": 12}
+// mengganti stok outlet dengan hasil hitung fisik
+func (h *OutletHandler) AdjustStock(w http.ResponseWriter, r *http.Request, id int) {
+	var adj models.StockAdjustment
+	err := json.NewDecoder(r.Body).Decode(&adj)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	stock, err := h.service.AdjustStock(actorFrom(r), id, adj)
+	if err != nil {
+		writeOutletError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stock)
+}
+
+func writeOutletError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrValidation):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, repositories.ErrOutletNotFound), errors.Is(err, repositories.ErrProductNotFound),
+		errors.Is(err, repositories.ErrVariantNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, repositories.ErrVersionMismatch):
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+	case errors.Is(err, repositories.ErrOutletExists), errors.Is(err, repositories.ErrOutletInUse),
+		errors.Is(err, repositories.ErrOutletStock):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pathParams memecah sisa path setelah prefix,
+// mis. "/api/products/5/restore" dengan prefix "/api/products/" -> ["5", "restore"]
+func pathParams(r *http.Request, prefix string) []string {
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, prefix), "/")
+	if rest == "" {
+		return nil
+	}
+	return strings.Split(rest, "/")
+}
+
+// queryBool - "true"/"1" dianggap true, selain itu false
+func queryBool(r *http.Request, key string) bool {
+	v, _ := strconv.ParseBool(r.URL.Query().Get(key))
+	return v
+}
+
+// queryInt - parameter kosong menghasilkan def
+func queryInt(r *http.Request, key string, def int) (int, error) {
+	raw := r.URL.Query().Get(key)
+	if raw == "" {
+		return def, nil
+	}
+	return strconv.Atoi(raw)
+}
+
+// queryOptionalInt - parameter kosong menghasilkan nil, mis. filter outlet_id
+func queryOptionalInt(r *http.Request, key string) (*int, error) {
+	raw := r.URL.Query().Get(key)
+	if raw == "" {
+		return nil, nil
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// queryOptionalDate - tanggal YYYY-MM-DD, parameter kosong menghasilkan nil
+func queryOptionalDate(r *http.Request, key string) (*time.Time, error) {
+	raw := r.URL.Query().Get(key)
+	if raw == "" {
+		return nil, nil
+	}
+	t, err := time.Parse("2006-01-02", raw)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// actorFrom - nama pengguna yang melakukan perubahan, dicatat di jejak audit.
+// API belum punya autentikasi, jadi client (aplikasi kasir) mengirimnya lewat header X-Actor.
+func actorFrom(r *http.Request) string {
+	// Byte yang bukan UTF-8 dibuang, Postgres menolak teks yang tidak valid
+	actor := strings.TrimSpace(strings.ToValidUTF8(r.Header.Get("X-Actor"), ""))
+	if actor == "" {
+		return "anonymous"
+	}
+	if runes := []rune(actor); len(runes) > 100 {
+		actor = string(runes[:100])
+	}
+	return actor
+}
@@ -1,12 +1,18 @@
 package handlers
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"io"
 	"kasir-api/internal/models"
+	"kasir-api/internal/repositories"
 	"kasir-api/internal/services"
+	"kasir-api/internal/spreadsheet"
 	"net/http"
 	"path"
 	"strconv"
+	"strings"
 )
 
 type ProductHandler struct {
@@ -30,16 +36,26 @@ func (h *ProductHandler) HandleProducts(w http.ResponseWriter, r *http.Request)
 }
 
 func (h *ProductHandler) GetAll(w http.ResponseWriter, r *http.Request) {
-	name := r.URL.Query().Get("name")
+	filter := models.ProductFilter{
+		Name:            r.URL.Query().Get("name"),
+		IncludeArchived: queryBool(r, "include_archived"),
+	}
+	if raw := r.URL.Query().Get("category_id"); raw != "" {
+		categoryID, err := strconv.Atoi(raw)
+		if err != nil {
+			http.Error(w, "Invalid category_id", http.StatusBadRequest)
+			return
+		}
+		filter.CategoryID = &categoryID
+	}
 
-	products, err := h.service.GetAll(name)
+	products, err := h.service.GetAll(filter)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(products)
+	writeJSONWithContentETag(w, r, products)
 }
 
 func (h *ProductHandler) Create(w http.ResponseWriter, r *http.Request) {
@@ -50,24 +66,32 @@ func (h *ProductHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.service.Create(&product)
+	err = h.service.Create(actorFrom(r), &product)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", versionETag(product.Version))
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(product)
 }
 
-// HandleProductByID - GET/PUT/DELETE /api/produk/{id}
+// HandleProductByID - GET/PUT/PATCH/DELETE /api/produk/{id}
 func (h *ProductHandler) HandleProductByID(w http.ResponseWriter, r *http.Request) {
+	if parts := pathParams(r, "/api/products/"); len(parts) > 1 {
+		h.handleProductSubresource(w, r, parts)
+		return
+	}
+
 	switch r.Method {
 	case http.MethodGet:
 		h.GetByID(w, r)
 	case http.MethodPut:
 		h.Update(w, r)
+	case http.MethodPatch:
+		h.Patch(w, r)
 	case http.MethodDelete:
 		h.Delete(w, r)
 	default:
@@ -75,6 +99,156 @@ func (h *ProductHandler) HandleProductByID(w http.ResponseWriter, r *http.Reques
 	}
 }
 
+// handleProductSubresource - /api/products/{id}/...
+func (h *ProductHandler) handleProductSubresource(w http.ResponseWriter, r *http.Request, parts []string) {
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case len(parts) == 2 && parts[1] == "restore":
+		switch r.Method {
+		case http.MethodPost:
+			h.Restore(w, r, id)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	case len(parts) == 2 && parts[1] == "price-history":
+		switch r.Method {
+		case http.MethodGet:
+			h.GetPriceHistory(w, r, id)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	case len(parts) == 2 && parts[1] == "price-schedules":
+		switch r.Method {
+		case http.MethodGet:
+			h.GetPriceSchedules(w, r, id)
+		case http.MethodPost:
+			h.SchedulePrice(w, r, id)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	case len(parts) == 3 && parts[1] == "price-schedules":
+		switch r.Method {
+		case http.MethodDelete:
+			h.CancelPriceSchedule(w, r, id, parts[2])
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	case len(parts) == 2 && parts[1] == "units":
+		switch r.Method {
+		case http.MethodGet:
+			h.GetUnits(w, r, id)
+		case http.MethodPost:
+			h.CreateUnit(w, r, id)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	case len(parts) == 3 && parts[1] == "units":
+		unitID, err := strconv.Atoi(parts[2])
+		if err != nil {
+			http.Error(w, "Invalid unit ID", http.StatusBadRequest)
+			return
+		}
+		switch r.Method {
+		case http.MethodPut:
+			h.UpdateUnit(w, r, id, unitID)
+		case http.MethodDelete:
+			h.DeleteUnit(w, r, id, unitID)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	case len(parts) == 2 && parts[1] == "modifiers":
+		switch r.Method {
+		case http.MethodGet:
+			h.GetModifiers(w, r, id)
+		case http.MethodPost:
+			h.CreateModifier(w, r, id)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	case len(parts) == 3 && parts[1] == "modifiers":
+		modifierID, err := strconv.Atoi(parts[2])
+		if err != nil {
+			http.Error(w, "Invalid modifier ID", http.StatusBadRequest)
+			return
+		}
+		switch r.Method {
+		case http.MethodPut:
+			h.UpdateModifier(w, r, id, modifierID)
+		case http.MethodDelete:
+			h.DeleteModifier(w, r, id, modifierID)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	case len(parts) == 2 && parts[1] == "images":
+		switch r.Method {
+		case http.MethodGet:
+			h.GetImages(w, r, id)
+		case http.MethodPost:
+			h.UploadImage(w, r, id)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	case len(parts) == 3 && parts[1] == "images":
+		imageID, err := strconv.Atoi(parts[2])
+		if err != nil {
+			http.Error(w, "Invalid image ID", http.StatusBadRequest)
+			return
+		}
+		switch r.Method {
+		case http.MethodDelete:
+			h.DeleteImage(w, r, id, imageID)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	case len(parts) == 2 && parts[1] == "bundle-items":
+		switch r.Method {
+		case http.MethodPut:
+			h.SetBundleItems(w, r, id)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	case len(parts) == 2 && parts[1] == "options":
+		switch r.Method {
+		case http.MethodPut:
+			h.SetOptions(w, r, id)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	case len(parts) == 2 && parts[1] == "variants":
+		switch r.Method {
+		case http.MethodGet:
+			h.GetVariants(w, r, id)
+		case http.MethodPost:
+			h.CreateVariant(w, r, id)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	case len(parts) == 3 && parts[1] == "variants":
+		variantID, err := strconv.Atoi(parts[2])
+		if err != nil {
+			http.Error(w, "Invalid variant ID", http.StatusBadRequest)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			h.GetVariant(w, r, id, variantID)
+		case http.MethodPut:
+			h.UpdateVariant(w, r, id, variantID)
+		case http.MethodDelete:
+			h.DeleteVariant(w, r, id, variantID)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
 // GetByID - GET /api/produk/{id}
 func (h *ProductHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(path.Base(r.URL.Path))
@@ -89,10 +263,10 @@ func (h *ProductHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(product)
+	writeJSONWithETag(w, r, versionETag(product.Version), product)
 }
 
+// Update - PUT /api/produk/{id}, field yang tidak dikirim tetap memakai nilai yang tersimpan
 func (h *ProductHandler) Update(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(path.Base(r.URL.Path))
 	if err != nil {
@@ -100,21 +274,89 @@ func (h *ProductHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var product models.Product
-	err = json.NewDecoder(r.Body).Decode(&product)
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeIfMatchError(w, err)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	product.ID = id
-	err = h.service.Update(&product)
+	product, err := h.service.Update(actorFrom(r), id, version, body)
+	if errors.Is(err, repositories.ErrProductNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, repositories.ErrVersionMismatch) {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+	if errors.Is(err, repositories.ErrProductArchived) || errors.Is(err, repositories.ErrOutletStock) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", versionETag(product.Version))
+	json.NewEncoder(w).Encode(product)
+}
+
+// Patch - PATCH /api/produk/{id} (JSON Merge Patch, RFC 7396)
+func (h *ProductHandler) Patch(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(path.Base(r.URL.Path))
+	if err != nil {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeIfMatchError(w, err)
+		return
+	}
+
+	patch, err := io.ReadAll(r.Body)
 	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	product, err := h.service.Patch(actorFrom(r), id, version, patch)
+	if errors.Is(err, repositories.ErrProductNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, repositories.ErrVersionMismatch) {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+	if errors.Is(err, repositories.ErrProductArchived) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if errors.Is(err, services.ErrInvalidPatch) || errors.Is(err, services.ErrValidation) || errors.Is(err, repositories.ErrCategoryNotFound) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if errors.Is(err, repositories.ErrOutletStock) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", versionETag(product.Version))
 	json.NewEncoder(w).Encode(product)
 }
 
@@ -128,7 +370,304 @@ func (h *ProductHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.service.Delete(id)
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeIfMatchError(w, err)
+		return
+	}
+
+	err = h.service.Delete(actorFrom(r), id, version)
+	if errors.Is(err, repositories.ErrProductNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, repositories.ErrVersionMismatch) {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Product archived successfully",
+	})
+}
+
+// Restore - POST /api/products/{id}/restore, If-Match opsional
+func (h *ProductHandler) Restore(w http.ResponseWriter, r *http.Request, id int) {
+	version := 0
+	if r.Header.Get("If-Match") != "" {
+		v, err := ifMatchVersion(r)
+		if err != nil {
+			writeIfMatchError(w, err)
+			return
+		}
+		version = v
+	}
+
+	product, err := h.service.Restore(actorFrom(r), id, version)
+	if errors.Is(err, repositories.ErrProductNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, repositories.ErrVersionMismatch) {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+	if errors.Is(err, repositories.ErrProductNotArchived) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if errors.Is(err, repositories.ErrSKUExists) || errors.Is(err, repositories.ErrPLUExists) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", versionETag(product.Version))
+	json.NewEncoder(w).Encode(product)
+}
+
+// GetPriceHistory - GET /api/products/{id}/price-history
+func (h *ProductHandler) GetPriceHistory(w http.ResponseWriter, r *http.Request, id int) {
+	history, err := h.service.GetPriceHistory(id)
+	if errors.Is(err, repositories.ErrProductNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(history)
+}
+
+// GetPriceSchedules - GET /api/products/{id}/price-schedules
+func (h *ProductHandler) GetPriceSchedules(w http.ResponseWriter, r *http.Request, id int) {
+	schedules, err := h.service.GetPriceSchedules(id)
+	if errors.Is(err, repositories.ErrProductNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schedules)
+}
+
+// SchedulePrice - POST /api/products/{id}/price-schedules {"price": 4000, "effective_from": "2026-02-01T00:00:00+07:00"}
+func (h *ProductHandler) SchedulePrice(w http.ResponseWriter, r *http.Request, id int) {
+	var schedule models.PriceSchedule
+	err := json.NewDecoder(r.Body).Decode(&schedule)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	schedule.ProductID = id
+	err = h.service.SchedulePrice(actorFrom(r), &schedule)
+	if errors.Is(err, repositories.ErrProductNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, services.ErrValidation) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(schedule)
+}
+
+// CancelPriceSchedule - DELETE /api/products/{id}/price-schedules/{scheduleID}
+func (h *ProductHandler) CancelPriceSchedule(w http.ResponseWriter, r *http.Request, id int, scheduleIDStr string) {
+	scheduleID, err := strconv.Atoi(scheduleIDStr)
+	if err != nil {
+		http.Error(w, "Invalid schedule ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.CancelPriceSchedule(actorFrom(r), id, scheduleID)
+	if errors.Is(err, repositories.ErrPriceScheduleNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Price schedule cancelled successfully",
+	})
+}
+
+// SetOptions - PUT /api/products/{id}/options [{"name": "Ukuran", "values": ["S", "M", "L"]}]
+func (h *ProductHandler) SetOptions(w http.ResponseWriter, r *http.Request, id int) {
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeIfMatchError(w, err)
+		return
+	}
+
+	var options []models.ProductOption
+	err = json.NewDecoder(r.Body).Decode(&options)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	product, err := h.service.SetOptions(actorFrom(r), id, version, options)
+	if errors.Is(err, repositories.ErrProductNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, repositories.ErrVersionMismatch) {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+	if errors.Is(err, services.ErrValidation) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", versionETag(product.Version))
+	json.NewEncoder(w).Encode(product)
+}
+
+// GetVariants - GET /api/products/{id}/variants
+func (h *ProductHandler) GetVariants(w http.ResponseWriter, r *http.Request, id int) {
+	variants, err := h.service.GetVariants(id, queryBool(r, "include_archived"))
+	if errors.Is(err, repositories.ErrProductNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSONWithContentETag(w, r, variants)
+}
+
+// GetVariant - GET /api/products/{id}/variants/{variantID}
+func (h *ProductHandler) GetVariant(w http.ResponseWriter, r *http.Request, id, variantID int) {
+	variant, err := h.service.GetVariant(id, variantID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	writeJSONWithETag(w, r, versionETag(variant.Version), variant)
+}
+
+// CreateVariant - POST /api/products/{id}/variants
+func (h *ProductHandler) CreateVariant(w http.ResponseWriter, r *http.Request, id int) {
+	var variant models.ProductVariant
+	err := json.NewDecoder(r.Body).Decode(&variant)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	variant.ProductID = id
+	err = h.service.CreateVariant(actorFrom(r), &variant)
+	if errors.Is(err, repositories.ErrProductNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", versionETag(variant.Version))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(variant)
+}
+
+// UpdateVariant - PUT /api/products/{id}/variants/{variantID}
+func (h *ProductHandler) UpdateVariant(w http.ResponseWriter, r *http.Request, id, variantID int) {
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeIfMatchError(w, err)
+		return
+	}
+
+	var variant models.ProductVariant
+	err = json.NewDecoder(r.Body).Decode(&variant)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	variant.ID = variantID
+	variant.ProductID = id
+	variant.Version = version
+	err = h.service.UpdateVariant(actorFrom(r), &variant)
+	if errors.Is(err, repositories.ErrProductNotFound) || errors.Is(err, repositories.ErrVariantNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, repositories.ErrVersionMismatch) {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+	if errors.Is(err, repositories.ErrOutletStock) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", versionETag(variant.Version))
+	json.NewEncoder(w).Encode(variant)
+}
+
+// DeleteVariant - DELETE /api/products/{id}/variants/{variantID}, varian diarsipkan
+func (h *ProductHandler) DeleteVariant(w http.ResponseWriter, r *http.Request, id, variantID int) {
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeIfMatchError(w, err)
+		return
+	}
+
+	err = h.service.DeleteVariant(actorFrom(r), id, variantID, version)
+	if errors.Is(err, repositories.ErrVariantNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, repositories.ErrVersionMismatch) {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -136,6 +675,481 @@ func (h *ProductHandler) Delete(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{
-		"message": "Product deleted successfully",
+		"message": "Variant archived successfully",
 	})
 }
+
+// GetUnits - GET /api/products/{id}/units
+func (h *ProductHandler) GetUnits(w http.ResponseWriter, r *http.Request, id int) {
+	units, err := h.service.GetUnits(id)
+	if errors.Is(err, repositories.ErrProductNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(units)
+}
+
+// CreateUnit - POST /api/products/{id}/units {"name": "dus", "factor": 40, "price": 115000}
+func (h *ProductHandler) CreateUnit(w http.ResponseWriter, r *http.Request, id int) {
+	unit := models.ProductUnit{Sellable: true, Purchasable: true}
+	err := json.NewDecoder(r.Body).Decode(&unit)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	unit.ProductID = id
+	err = h.service.CreateUnit(actorFrom(r), &unit)
+	if errors.Is(err, repositories.ErrProductNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, repositories.ErrUnitExists) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(unit)
+}
+
+// UpdateUnit - PUT /api/products/{id}/units/{unitID}
+func (h *ProductHandler) UpdateUnit(w http.ResponseWriter, r *http.Request, id, unitID int) {
+	var unit models.ProductUnit
+	err := json.NewDecoder(r.Body).Decode(&unit)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	unit.ID = unitID
+	unit.ProductID = id
+	err = h.service.UpdateUnit(actorFrom(r), &unit)
+	if errors.Is(err, repositories.ErrProductNotFound) || errors.Is(err, repositories.ErrUnitNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, repositories.ErrUnitExists) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(unit)
+}
+
+// DeleteUnit - DELETE /api/products/{id}/units/{unitID}
+func (h *ProductHandler) DeleteUnit(w http.ResponseWriter, r *http.Request, id, unitID int) {
+	err := h.service.DeleteUnit(actorFrom(r), id, unitID)
+	if errors.Is(err, repositories.ErrUnitNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Unit deleted successfully",
+	})
+}
+
+// GetModifiers - GET /api/products/{id}/modifiers
+func (h *ProductHandler) GetModifiers(w http.ResponseWriter, r *http.Request, id int) {
+	modifiers, err := h.service.GetModifiers(id)
+	if errors.Is(err, repositories.ErrProductNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(modifiers)
+}
+
+// CreateModifier - POST /api/products/{id}/modifiers {"name": "extra shot", "price_delta": 5000}
+func (h *ProductHandler) CreateModifier(w http.ResponseWriter, r *http.Request, id int) {
+	var modifier models.ProductModifier
+	err := json.NewDecoder(r.Body).Decode(&modifier)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	modifier.ProductID = id
+	err = h.service.CreateModifier(actorFrom(r), &modifier)
+	if errors.Is(err, services.ErrValidation) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, repositories.ErrProductNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, repositories.ErrModifierExists) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(modifier)
+}
+
+// UpdateModifier - PUT /api/products/{id}/modifiers/{modifierID}
+func (h *ProductHandler) UpdateModifier(w http.ResponseWriter, r *http.Request, id, modifierID int) {
+	var modifier models.ProductModifier
+	err := json.NewDecoder(r.Body).Decode(&modifier)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	modifier.ID = modifierID
+	modifier.ProductID = id
+	err = h.service.UpdateModifier(actorFrom(r), &modifier)
+	if errors.Is(err, services.ErrValidation) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, repositories.ErrModifierNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, repositories.ErrModifierExists) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(modifier)
+}
+
+// DeleteModifier - DELETE /api/products/{id}/modifiers/{modifierID}
+func (h *ProductHandler) DeleteModifier(w http.ResponseWriter, r *http.Request, id, modifierID int) {
+	err := h.service.DeleteModifier(actorFrom(r), id, modifierID)
+	if errors.Is(err, repositories.ErrModifierNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Modifier deleted successfully",
+	})
+}
+
+// GetImages - GET /api/products/{id}/images
+func (h *ProductHandler) GetImages(w http.ResponseWriter, r *http.Request, id int) {
+	images, err := h.service.GetImages(id)
+	if errors.Is(err, repositories.ErrProductNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(images)
+}
+
+// maxImageUploadSize - batas body upload, sedikit di atas batas file supaya header multipart muat
+const maxImageUploadSize = 6 << 20
+
+// UploadImage - POST /api/products/{id}/images (multipart, field "image")
+func (h *ProductHandler) UploadImage(w http.ResponseWriter, r *http.Request, id int) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImageUploadSize)
+	file, _, err := r.FormFile("image")
+	if err != nil {
+		http.Error(w, "File tidak ditemukan di field 'image': "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		http.Error(w, "Gagal membaca file: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	image, err := h.service.UploadImage(actorFrom(r), id, data)
+	if errors.Is(err, repositories.ErrProductNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, services.ErrValidation) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(image)
+}
+
+// DeleteImage - DELETE /api/products/{id}/images/{imageID}
+func (h *ProductHandler) DeleteImage(w http.ResponseWriter, r *http.Request, id, imageID int) {
+	err := h.service.DeleteImage(actorFrom(r), id, imageID)
+	if errors.Is(err, repositories.ErrImageNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Image deleted successfully",
+	})
+}
+
+// SetBundleItems - PUT /api/products/{id}/bundle-items [{"component_id": 3, "quantity": 1}]
+func (h *ProductHandler) SetBundleItems(w http.ResponseWriter, r *http.Request, id int) {
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeIfMatchError(w, err)
+		return
+	}
+
+	var items []models.BundleItem
+	err = json.NewDecoder(r.Body).Decode(&items)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	product, err := h.service.SetBundleItems(actorFrom(r), id, version, items)
+	if errors.Is(err, repositories.ErrProductNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, repositories.ErrVersionMismatch) {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+	if errors.Is(err, services.ErrValidation) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", versionETag(product.Version))
+	json.NewEncoder(w).Encode(product)
+}
+
+// maxImportSize - batas ukuran file impor katalog (10 MB)
+const maxImportSize = 10 << 20
+
+// Import - POST /api/products/import?mode=all_or_nothing|skip_invalid&dry_run=true
+// File dikirim sebagai multipart (field "file") atau langsung sebagai body dengan ?format=csv|xlsx.
+// Untuk SKU yang sudah ada, sel kosong tidak mengubah kolomnya; isi "-" untuk mengosongkan plu/category_id.
+func (h *ProductHandler) Import(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	formatName := r.URL.Query().Get("format")
+	var body io.Reader = r.Body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, "File tidak ditemukan di field 'file': "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		body = file
+		if formatName == "" {
+			formatName = header.Filename
+		}
+	} else if formatName == "" && strings.HasPrefix(r.Header.Get("Content-Type"), spreadsheet.ContentTypeXLSX) {
+		formatName = "xlsx"
+	} else if formatName == "" {
+		formatName = "csv"
+	}
+
+	format, err := spreadsheet.ParseFormat(formatName)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		http.Error(w, "Gagal membaca file: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.service.ImportCatalog(actorFrom(r), format, data, r.URL.Query().Get("mode"), queryBool(r, "dry_run"))
+	if errors.Is(err, services.ErrValidation) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Impor sungguhan yang dibatalkan karena ada baris gagal dijawab 422 beserta daftar error
+	w.Header().Set("Content-Type", "application/json")
+	if !result.DryRun && !result.Committed {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}
+	json.NewEncoder(w).Encode(result)
+}
+
+// Export - GET /api/products/export?format=csv|xlsx, kolom sama dengan file impor
+func (h *ProductHandler) Export(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	formatName := r.URL.Query().Get("format")
+	if formatName == "" {
+		formatName = "csv"
+	}
+	format, err := spreadsheet.ParseFormat(formatName)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter := models.ProductFilter{IncludeArchived: queryBool(r, "include_archived")}
+	if raw := r.URL.Query().Get("category_id"); raw != "" {
+		categoryID, err := strconv.Atoi(raw)
+		if err != nil {
+			http.Error(w, "Invalid category_id", http.StatusBadRequest)
+			return
+		}
+		filter.CategoryID = &categoryID
+	}
+
+	// Ditulis ke buffer dulu supaya error tidak muncul di tengah file yang sudah terkirim
+	var buf bytes.Buffer
+	if err := h.service.ExportCatalog(&buf, format, filter); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", `attachment; filename="products.`+string(format)+`"`)
+	w.Write(buf.Bytes())
+}
+
+// maxBatchSize - batas body batch produk (5 MB), jumlah operasinya dibatasi lagi di service
+const maxBatchSize = 5 << 20
+
+// Batch - POST /api/products/batch
+// {"operations": [{"op": "create", "product": {...}}, {"op": "update", "id": 5, "version": 3, "product": {...}}, {"op": "delete", "id": 7, "version": 2}]}
+// Semua operasi berhasil (200) atau semuanya dibatalkan (422) dengan status per operasi.
+func (h *ProductHandler) Batch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxBatchSize)
+	var req models.ProductBatchRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		http.Error(w, "Body batch maksimal "+strconv.FormatInt(tooLarge.Limit, 10)+" byte", http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.service.Batch(actorFrom(r), req.Operations)
+	if errors.Is(err, services.ErrValidation) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, repositories.ErrSKUExists) || errors.Is(err, repositories.ErrPLUExists) || errors.Is(err, repositories.ErrOutletStock) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	for i := range resp.Results {
+		result := &resp.Results[i]
+		result.Status = batchResultStatus(result.Op, result.Err)
+		if result.Err != nil {
+			result.Error = result.Err.Error()
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !resp.Committed {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// batchResultStatus - kode yang sama dengan endpoint tunggal untuk error yang sama
+func batchResultStatus(op string, err error) int {
+	switch {
+	case err == nil && op == models.BatchCreate:
+		return http.StatusCreated
+	case err == nil:
+		return http.StatusOK
+	case errors.Is(err, repositories.ErrProductNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repositories.ErrVersionMismatch):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, repositories.ErrSKUExists), errors.Is(err, repositories.ErrPLUExists), errors.Is(err, repositories.ErrProductArchived):
+		return http.StatusConflict
+	case errors.Is(err, repositories.ErrBatchCancelled):
+		return http.StatusFailedDependency
+	}
+	return http.StatusBadRequest
+}
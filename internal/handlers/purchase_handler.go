@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"encoding/json"
+	"kasir-api/internal/models"
+	"kasir-api/internal/services"
+	"net/http"
+	"path"
+	"strconv"
+)
+
+type PurchaseHandler struct {
+	service *services.PurchaseService
+}
+
+func NewPurchaseHandler(service *services.PurchaseService) *PurchaseHandler {
+	return &PurchaseHandler{service: service}
+}
+
+// HandlePurchases - GET/POST /api/purchases
+func (h *PurchaseHandler) HandlePurchases(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	case http.MethodPost:
+		h.Create(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *PurchaseHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	receipts, err := h.service.GetAll()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(receipts)
+}
+
+// Create - POST /api/purchases
+// {"supplier": "PT Indofood", "lines": [{"product_id": 1, "unit": "dus", "quantity": 2, "unit_cost": 110000}]}
+func (h *PurchaseHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var receipt models.PurchaseReceipt
+	err := json.NewDecoder(r.Body).Decode(&receipt)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.CreateReceipt(actorFrom(r), &receipt)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(receipt)
+}
+
+// HandlePurchaseByID - GET /api/purchases/{id}
+func (h *PurchaseHandler) HandlePurchaseByID(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(path.Base(r.URL.Path))
+	if err != nil {
+		http.Error(w, "Invalid purchase ID", http.StatusBadRequest)
+		return
+	}
+
+	receipt, err := h.service.GetByID(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(receipt)
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"kasir-api/internal/models"
+	"kasir-api/internal/repositories"
+	"kasir-api/internal/services"
+	"net/http"
+	"strconv"
+)
+
+type ReservationHandler struct {
+	service *services.ReservationService
+}
+
+func NewReservationHandler(service *services.ReservationService) *ReservationHandler {
+	return &ReservationHandler{service: service}
+}
+
+// HandleReservations - GET/POST /api/reservations
+func (h *ReservationHandler) HandleReservations(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	case http.MethodPost:
+		h.Create(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// GetAll - GET /api/reservations?status=active&reference=WEB-1001&outlet_id=2
+func (h *ReservationHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	outletID, err := queryOptionalInt(r, "outlet_id")
+	if err != nil {
+		http.Error(w, "Invalid outlet_id", http.StatusBadRequest)
+		return
+	}
+	filter := models.ReservationFilter{
+		Status:    r.URL.Query().Get("status"),
+		Reference: r.URL.Query().Get("reference"),
+		OutletID:  outletID,
+	}
+
+	reservations, err := h.service.GetAll(filter)
+	if errors.Is(err, services.ErrValidation) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reservations)
+}
+
+// Create - POST /api/reservations {"reference": "WEB-1001", "ttl_minutes": 15, "items": [...]}
+func (h *ReservationHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req models.ReservationRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	reservation, err := h.service.Create(actorFrom(r), req)
+	if err != nil {
+		writeReservationError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(reservation)
+}
+
+// HandleReservationByID - GET/DELETE /api/reservations/{id}, POST /api/reservations/{id}/extend
+func (h *ReservationHandler) HandleReservationByID(w http.ResponseWriter, r *http.Request) {
+	parts := pathParams(r, "/api/reservations/")
+	if len(parts) == 0 {
+		http.NotFound(w, r)
+		return
+	}
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		http.Error(w, "Invalid reservation ID", http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case len(parts) == 1:
+		switch r.Method {
+		case http.MethodGet:
+			h.GetByID(w, r, id)
+		case http.MethodDelete:
+			h.Release(w, r, id)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	case len(parts) == 2 && parts[1] == "extend":
+		switch r.Method {
+		case http.MethodPost:
+			h.Extend(w, r, id)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *ReservationHandler) GetByID(w http.ResponseWriter, r *http.Request, id int) {
+	reservation, err := h.service.GetByID(id)
+	if err != nil {
+		writeReservationError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reservation)
+}
+
+// Extend - POST /api/reservations/{id}/extend {"ttl_minutes": 10}
+func (h *ReservationHandler) Extend(w http.ResponseWriter, r *http.Request, id int) {
+	var req struct {
+		TTLMinutes int `json:"ttl_minutes"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	reservation, err := h.service.Extend(actorFrom(r), id, req.TTLMinutes)
+	if err != nil {
+		writeReservationError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reservation)
+}
+
+// Release - DELETE /api/reservations/{id}, pesanan batal dan stoknya dilepas
+func (h *ReservationHandler) Release(w http.ResponseWriter, r *http.Request, id int) {
+	reservation, err := h.service.Release(actorFrom(r), id)
+	if err != nil {
+		writeReservationError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reservation)
+}
+
+func writeReservationError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrValidation):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, repositories.ErrReservationNotFound), errors.Is(err, repositories.ErrOutletNotFound),
+		errors.Is(err, repositories.ErrProductNotFound), errors.Is(err, repositories.ErrVariantNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, repositories.ErrReservationClosed), errors.Is(err, repositories.ErrReferenceExists),
+		errors.Is(err, repositories.ErrStockUnavailable), errors.Is(err, repositories.ErrOutletInUse),
+		errors.Is(err, repositories.ErrProductArchived), errors.Is(err, repositories.ErrVariantArchived):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"kasir-api/internal/models"
+	"kasir-api/internal/repositories"
+	"kasir-api/internal/services"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type TableHandler struct {
+	service *services.TableService
+}
+
+func NewTableHandler(service *services.TableService) *TableHandler {
+	return &TableHandler{service: service}
+}
+
+// HandleTables - GET/POST /api/tables
+func (h *TableHandler) HandleTables(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	case http.MethodPost:
+		h.Create(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// GetAll - GET /api/tables?include_archived=true, open_cart_id menunjukkan meja yang terisi
+func (h *TableHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	tables, err := h.service.GetAll(queryBool(r, "include_archived"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tables)
+}
+
+// Create - POST /api/tables {"name": "Meja 5", "seats": 4}
+func (h *TableHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var table models.DiningTable
+	err := json.NewDecoder(r.Body).Decode(&table)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.Create(actorFrom(r), &table)
+	if errors.Is(err, services.ErrValidation) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, repositories.ErrTableExists) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", versionETag(table.Version))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(table)
+}
+
+// HandleTableByID - GET/PUT/DELETE /api/tables/{id}
+func (h *TableHandler) HandleTableByID(w http.ResponseWriter, r *http.Request) {
+	parts := pathParams(r, "/api/tables/")
+	if len(parts) != 1 {
+		http.NotFound(w, r)
+		return
+	}
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		http.Error(w, "Invalid table ID", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.GetByID(w, r, id)
+	case http.MethodPut:
+		h.Update(w, r, id)
+	case http.MethodDelete:
+		h.Delete(w, r, id)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *TableHandler) GetByID(w http.ResponseWriter, r *http.Request, id int) {
+	table, err := h.service.GetByID(id)
+	if errors.Is(err, repositories.ErrTableNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSONWithETag(w, r, versionETag(table.Version), table)
+}
+
+func (h *TableHandler) Update(w http.ResponseWriter, r *http.Request, id int) {
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeIfMatchError(w, err)
+		return
+	}
+
+	var table models.DiningTable
+	err = json.NewDecoder(r.Body).Decode(&table)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	table.ID = id
+	table.Version = version
+	updated, err := h.service.Update(actorFrom(r), &table)
+	if errors.Is(err, repositories.ErrTableNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, repositories.ErrVersionMismatch) {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+	if errors.Is(err, repositories.ErrTableExists) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if errors.Is(err, services.ErrValidation) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", versionETag(updated.Version))
+	json.NewEncoder(w).Encode(updated)
+}
+
+// Delete - mengarsipkan meja, transaksi lama tetap terhubung
+func (h *TableHandler) Delete(w http.ResponseWriter, r *http.Request, id int) {
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeIfMatchError(w, err)
+		return
+	}
+
+	err = h.service.Delete(actorFrom(r), id, version)
+	if errors.Is(err, repositories.ErrTableNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, repositories.ErrVersionMismatch) {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Table archived successfully",
+	})
+}
+
+type KitchenHandler struct {
+	service *services.KitchenService
+}
+
+func NewKitchenHandler(service *services.KitchenService) *KitchenHandler {
+	return &KitchenHandler{service: service}
+}
+
+// HandleTickets - GET /api/kitchen/tickets?station=bar&status=queued,cooking
+func (h *KitchenHandler) HandleTickets(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	filter := models.KitchenFilter{Station: r.URL.Query().Get("station")}
+	if raw := r.URL.Query().Get("status"); raw != "" {
+		for _, s := range strings.Split(raw, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				filter.Statuses = append(filter.Statuses, s)
+			}
+		}
+	}
+
+	tickets, err := h.service.GetTickets(filter)
+	if errors.Is(err, services.ErrValidation) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tickets)
+}
+
+// HandleTicketStatus - POST /api/kitchen/tickets/{lineID}/status {"status": "cooking"}
+func (h *KitchenHandler) HandleTicketStatus(w http.ResponseWriter, r *http.Request) {
+	parts := pathParams(r, "/api/kitchen/tickets/")
+	if len(parts) != 2 || parts[1] != "status" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	lineID, err := strconv.Atoi(parts[0])
+	if err != nil {
+		http.Error(w, "Invalid ticket ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Status string `json:"status"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	ticket, err := h.service.SetStatus(lineID, req.Status)
+	if errors.Is(err, services.ErrValidation) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, repositories.ErrCartLineNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, repositories.ErrKitchenTransition) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ticket)
+}
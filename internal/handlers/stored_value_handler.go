@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"kasir-api/internal/models"
+	"kasir-api/internal/repositories"
+	"kasir-api/internal/services"
+	"net/http"
+	"strconv"
+)
+
+type StoredValueHandler struct {
+	service *services.StoredValueService
+}
+
+func NewStoredValueHandler(service *services.StoredValueService) *StoredValueHandler {
+	return &StoredValueHandler{service: service}
+}
+
+// HandleAccounts - GET/POST /api/stored-value
+func (h *StoredValueHandler) HandleAccounts(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	case http.MethodPost:
+		h.Issue(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// GetAll - GET /api/stored-value?customer_id=3
+func (h *StoredValueHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	var customerID *int
+	if raw := r.URL.Query().Get("customer_id"); raw != "" {
+		id, err := strconv.Atoi(raw)
+		if err != nil {
+			http.Error(w, "Invalid customer_id", http.StatusBadRequest)
+			return
+		}
+		customerID = &id
+	}
+
+	accounts, err := h.service.GetAll(customerID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(accounts)
+}
+
+// Issue - POST /api/stored-value {"type": "gift_card", "amount": 100000}, kode kosong dibuat acak
+func (h *StoredValueHandler) Issue(w http.ResponseWriter, r *http.Request) {
+	var req models.IssueStoredValueRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	account, err := h.service.Issue(actorFrom(r), req)
+	if errors.Is(err, services.ErrValidation) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, repositories.ErrCustomerNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, repositories.ErrStoredValueCodeExists) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(account)
+}
+
+// HandleAccountByCode - /api/stored-value/{code}, /top-up, /redeem
+func (h *StoredValueHandler) HandleAccountByCode(w http.ResponseWriter, r *http.Request) {
+	parts := pathParams(r, "/api/stored-value/")
+	if len(parts) == 0 {
+		http.NotFound(w, r)
+		return
+	}
+	code := parts[0]
+
+	switch {
+	case len(parts) == 1:
+		switch r.Method {
+		case http.MethodGet:
+			h.GetByCode(w, r, code)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	case len(parts) == 2 && parts[1] == "top-up":
+		switch r.Method {
+		case http.MethodPost:
+			h.post(w, r, code, h.service.TopUp)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	case len(parts) == 2 && parts[1] == "redeem":
+		switch r.Method {
+		case http.MethodPost:
+			h.post(w, r, code, h.service.Redeem)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// GetByCode - saldo dan buku besar, dipakai kasir untuk cek saldo kartu
+func (h *StoredValueHandler) GetByCode(w http.ResponseWriter, r *http.Request, code string) {
+	account, err := h.service.GetByCode(code)
+	if errors.Is(err, repositories.ErrStoredValueNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(account)
+}
+
+// post - POST /api/stored-value/{code}/top-up atau /redeem {"amount": 50000, "note": "..."}
+func (h *StoredValueHandler) post(w http.ResponseWriter, r *http.Request, code string, op func(actor, code string, req models.StoredValueOperation) (*models.StoredValueAccount, error)) {
+	var req models.StoredValueOperation
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	account, err := op(actorFrom(r), code, req)
+	if errors.Is(err, services.ErrValidation) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, repositories.ErrStoredValueNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, repositories.ErrInsufficientBalance) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(account)
+}
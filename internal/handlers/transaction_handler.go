@@ -2,9 +2,13 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"kasir-api/internal/models"
+	"kasir-api/internal/repositories"
 	"kasir-api/internal/services"
 	"net/http"
+	"path"
+	"strconv"
 	"time"
 )
 
@@ -30,7 +34,31 @@ func (h *TransactionHandler) HandleCheckout(w http.ResponseWriter, r *http.Reque
 	}
 
 	// useLock removed from service
-	transaction, err := h.service.Checkout(req.Items)
+	transaction, err := h.service.Checkout(actorFrom(r), req)
+	if errors.Is(err, services.ErrValidation) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, repositories.ErrLotExpired) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if errors.Is(err, repositories.ErrCustomerNotFound) || errors.Is(err, repositories.ErrProductNotFound) || errors.Is(err, repositories.ErrVariantNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, repositories.ErrStoredValueNotFound) || errors.Is(err, repositories.ErrReservationNotFound) ||
+		errors.Is(err, repositories.ErrTableNotFound) || errors.Is(err, repositories.ErrModifierNotFound) ||
+		errors.Is(err, repositories.ErrOutletNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, repositories.ErrPointsRedemption) || errors.Is(err, repositories.ErrInsufficientBalance) || errors.Is(err, repositories.ErrPaymentMismatch) ||
+		errors.Is(err, repositories.ErrReservationClosed) || errors.Is(err, repositories.ErrOutletInUse) ||
+		errors.Is(err, repositories.ErrProductArchived) || errors.Is(err, repositories.ErrVariantArchived) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Failed to process checkout: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -40,15 +68,126 @@ func (h *TransactionHandler) HandleCheckout(w http.ResponseWriter, r *http.Reque
 	json.NewEncoder(w).Encode(transaction)
 }
 
+// HandleTransactionByID - GET /api/transactions/{id}
+func (h *TransactionHandler) HandleTransactionByID(w http.ResponseWriter, r *http.Request) {
+	if parts := pathParams(r, "/api/transactions/"); len(parts) > 1 {
+		h.handleTransactionSubresource(w, r, parts)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(path.Base(r.URL.Path))
+	if err != nil {
+		http.Error(w, "Invalid transaction ID", http.StatusBadRequest)
+		return
+	}
+
+	transaction, err := h.service.GetByID(id)
+	if errors.Is(err, repositories.ErrTransactionNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to get transaction: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transaction)
+}
+
+// handleTransactionSubresource - /api/transactions/{id}/...
+func (h *TransactionHandler) handleTransactionSubresource(w http.ResponseWriter, r *http.Request, parts []string) {
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		http.Error(w, "Invalid transaction ID", http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case len(parts) == 2 && parts[1] == "refunds":
+		switch r.Method {
+		case http.MethodGet:
+			h.GetRefunds(w, r, id)
+		case http.MethodPost:
+			h.Refund(w, r, id)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// Refund - POST /api/transactions/{id}/refunds {"items": [{"detail_id": 7, "quantity": 1}], "reason": "rusak"}
+// Items kosong berarti seluruh sisa transaksi diretur
+func (h *TransactionHandler) Refund(w http.ResponseWriter, r *http.Request, id int) {
+	var req models.RefundRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	refund, err := h.service.Refund(actorFrom(r), id, req)
+	if errors.Is(err, repositories.ErrTransactionNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, services.ErrValidation) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, repositories.ErrRefundInvalid) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to process refund: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(refund)
+}
+
+// GetRefunds - GET /api/transactions/{id}/refunds
+func (h *TransactionHandler) GetRefunds(w http.ResponseWriter, r *http.Request, id int) {
+	refunds, err := h.service.GetRefunds(id)
+	if errors.Is(err, repositories.ErrTransactionNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(refunds)
+}
+
+// HandleDailyReport - GET /api/report/hari-ini?outlet_id=2 (tanpa outlet_id: semua outlet)
 func (h *TransactionHandler) HandleDailyReport(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
+	outletID, err := queryOptionalInt(r, "outlet_id")
+	if err != nil {
+		http.Error(w, "Invalid outlet_id", http.StatusBadRequest)
+		return
+	}
+
 	// Get report for today
 	today := time.Now()
-	report, err := h.service.GetDailyReport(today)
+	report, err := h.service.GetDailyReport(today, outletID)
 	if err != nil {
 		http.Error(w, "Failed to get daily report: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -58,6 +197,7 @@ func (h *TransactionHandler) HandleDailyReport(w http.ResponseWriter, r *http.Re
 	json.NewEncoder(w).Encode(report)
 }
 
+// HandleReportByDate - GET /api/report?start_date=YYYY-MM-DD&end_date=YYYY-MM-DD&outlet_id=2
 func (h *TransactionHandler) HandleReportByDate(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -84,7 +224,76 @@ func (h *TransactionHandler) HandleReportByDate(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	report, err := h.service.GetReportByDateRange(startDate, endDate)
+	outletID, err := queryOptionalInt(r, "outlet_id")
+	if err != nil {
+		http.Error(w, "Invalid outlet_id", http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.service.GetReportByDateRange(startDate, endDate, outletID)
+	if err != nil {
+		http.Error(w, "Failed to get report: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// HandleStockMovementReport - GET /api/report/stock-movements?start_date=YYYY-MM-DD&end_date=YYYY-MM-DD&outlet_id=2
+func (h *TransactionHandler) HandleStockMovementReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	startDate, err := time.Parse("2006-01-02", r.URL.Query().Get("start_date"))
+	if err != nil {
+		http.Error(w, "Invalid start_date format (YYYY-MM-DD)", http.StatusBadRequest)
+		return
+	}
+	endDate, err := time.Parse("2006-01-02", r.URL.Query().Get("end_date"))
+	if err != nil {
+		http.Error(w, "Invalid end_date format (YYYY-MM-DD)", http.StatusBadRequest)
+		return
+	}
+
+	outletID, err := queryOptionalInt(r, "outlet_id")
+	if err != nil {
+		http.Error(w, "Invalid outlet_id", http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.service.GetMovementReport(startDate, endDate, outletID)
+	if err != nil {
+		http.Error(w, "Failed to get report: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// HandleOutletReport - GET /api/report/outlets?start_date=YYYY-MM-DD&end_date=YYYY-MM-DD,
+// omzet dan jumlah transaksi tiap outlet beserta totalnya
+func (h *TransactionHandler) HandleOutletReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	startDate, err := time.Parse("2006-01-02", r.URL.Query().Get("start_date"))
+	if err != nil {
+		http.Error(w, "Invalid start_date format (YYYY-MM-DD)", http.StatusBadRequest)
+		return
+	}
+	endDate, err := time.Parse("2006-01-02", r.URL.Query().Get("end_date"))
+	if err != nil {
+		http.Error(w, "Invalid end_date format (YYYY-MM-DD)", http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.service.GetConsolidatedReport(startDate, endDate)
 	if err != nil {
 		http.Error(w, "Failed to get report: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"kasir-api/internal/models"
+	"kasir-api/internal/repositories"
+	"kasir-api/internal/services"
+	"net/http"
+	"strconv"
+)
+
+type TransferHandler struct {
+	service *services.TransferService
+}
+
+func NewTransferHandler(service *services.TransferService) *TransferHandler {
+	return &TransferHandler{service: service}
+}
+
+// HandleTransfers - GET/POST /api/transfers
+func (h *TransferHandler) HandleTransfers(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	case http.MethodPost:
+		h.Create(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// GetAll - GET /api/transfers?status=in_transit&outlet_id=2 (outlet asal maupun tujuan)
+func (h *TransferHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	outletID, err := queryOptionalInt(r, "outlet_id")
+	if err != nil {
+		http.Error(w, "Invalid outlet_id", http.StatusBadRequest)
+		return
+	}
+
+	filter := models.TransferFilter{Status: r.URL.Query().Get("status"), OutletID: outletID}
+	transfers, err := h.service.GetAll(filter)
+	if err != nil {
+		writeTransferError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transfers)
+}
+
+// Create - POST /api/transfers {"source_outlet_id": 3, "destination_outlet_id": 1, "lines": [{"product_id": 5, "quantity": 24}]}
+func (h *TransferHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var transfer models.StockTransfer
+	err := json.NewDecoder(r.Body).Decode(&transfer)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	created, err := h.service.Create(actorFrom(r), &transfer)
+	if err != nil {
+		writeTransferError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", versionETag(created.Version))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// HandleTransferByID - /api/transfers/{id}, /dispatch, /receive
+func (h *TransferHandler) HandleTransferByID(w http.ResponseWriter, r *http.Request) {
+	parts := pathParams(r, "/api/transfers/")
+	if len(parts) == 0 || len(parts) > 2 {
+		http.NotFound(w, r)
+		return
+	}
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		http.Error(w, "Invalid transfer ID", http.StatusBadRequest)
+		return
+	}
+
+	if len(parts) == 2 {
+		if parts[1] != "dispatch" && parts[1] != "receive" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if parts[1] == "dispatch" {
+			h.changeStatus(w, r, id, h.service.Dispatch)
+		} else {
+			h.Receive(w, r, id)
+		}
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.GetByID(w, r, id)
+	case http.MethodPut:
+		h.Update(w, r, id)
+	case http.MethodDelete:
+		h.changeStatus(w, r, id, h.service.Cancel)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *TransferHandler) GetByID(w http.ResponseWriter, r *http.Request, id int) {
+	transfer, err := h.service.GetByID(id)
+	if err != nil {
+		writeTransferError(w, err)
+		return
+	}
+	writeJSONWithETag(w, r, versionETag(transfer.Version), transfer)
+}
+
+// Update - PUT /api/transfers/{id}, hanya selama masih draft
+func (h *TransferHandler) Update(w http.ResponseWriter, r *http.Request, id int) {
+	version, ok := optionalIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	var transfer models.StockTransfer
+	err := json.NewDecoder(r.Body).Decode(&transfer)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	transfer.ID = id
+	transfer.Version = version
+
+	updated, err := h.service.Update(actorFrom(r), &transfer)
+	if err != nil {
+		writeTransferError(w, err)
+		return
+	}
+	writeTransfer(w, updated)
+}
+
+// Receive - POST /api/transfers/{id}/receive {"lines": [{"line_id": 7, "received_quantity": 22, "note": "2 pecah"}]}
+// body boleh kosong kalau semua barang diterima utuh
+func (h *TransferHandler) Receive(w http.ResponseWriter, r *http.Request, id int) {
+	version, ok := optionalIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	var receipt models.TransferReceipt
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&receipt); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	transfer, err := h.service.Receive(actorFrom(r), id, version, receipt)
+	if err != nil {
+		writeTransferError(w, err)
+		return
+	}
+	writeTransfer(w, transfer)
+}
+
+// changeStatus - dispatch dan cancel (DELETE /api/transfers/{id})
+func (h *TransferHandler) changeStatus(w http.ResponseWriter, r *http.Request, id int, change func(actor string, id, version int) (*models.StockTransfer, error)) {
+	version, ok := optionalIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	transfer, err := change(actorFrom(r), id, version)
+	if err != nil {
+		writeTransferError(w, err)
+		return
+	}
+	writeTransfer(w, transfer)
+}
+
+func writeTransfer(w http.ResponseWriter, transfer *models.StockTransfer) {
+	w.Header().Set("ETag", versionETag(transfer.Version))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transfer)
+}
+
+func writeTransferError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrValidation), errors.Is(err, repositories.ErrTransferInvalid):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, repositories.ErrTransferNotFound), errors.Is(err, repositories.ErrTransferLineNotFound),
+		errors.Is(err, repositories.ErrOutletNotFound), errors.Is(err, repositories.ErrProductNotFound),
+		errors.Is(err, repositories.ErrVariantNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, repositories.ErrVersionMismatch):
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+	case errors.Is(err, repositories.ErrTransferClosed), errors.Is(err, repositories.ErrOutletStock):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"kasir-api/internal/models"
+	"kasir-api/internal/repositories"
+	"kasir-api/internal/services"
+	"net/http"
+	"strconv"
+)
+
+// Penerima webhook memverifikasi header X-Kasir-Signature: "sha256=" + hex HMAC-SHA256 dengan secret
+// langganan atas "<X-Kasir-Timestamp>.<body>". X-Kasir-Delivery sama di setiap percobaan ulang,
+// pakai untuk membuang kiriman ganda.
+
+type WebhookHandler struct {
+	service *services.WebhookService
+}
+
+func NewWebhookHandler(service *services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{service: service}
+}
+
+// HandleWebhooks - GET/POST /api/webhooks
+func (h *WebhookHandler) HandleWebhooks(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	case http.MethodPost:
+		h.Create(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// GetAll - GET /api/webhooks?include_archived=true
+func (h *WebhookHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	subscriptions, err := h.service.GetAll(queryBool(r, "include_archived"))
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(subscriptions)
+}
+
+// Create - POST /api/webhooks {"url": "https://...", "events": ["transaction.created"], "secret": "..."}
+// secret kosong dibuat acak dan hanya ditampilkan di respons ini
+func (h *WebhookHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req models.WebhookSubscriptionRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	subscription, err := h.service.Create(actorFrom(r), req)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", versionETag(subscription.Version))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(subscription)
+}
+
+// HandleWebhookByID - /api/webhooks/{id}, /deliveries, /deliveries/{deliveryID}, /deliveries/{deliveryID}/retry
+func (h *WebhookHandler) HandleWebhookByID(w http.ResponseWriter, r *http.Request) {
+	parts := pathParams(r, "/api/webhooks/")
+	if len(parts) == 0 || len(parts) > 4 {
+		http.NotFound(w, r)
+		return
+	}
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		http.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+		return
+	}
+
+	if len(parts) == 1 {
+		switch r.Method {
+		case http.MethodGet:
+			h.GetByID(w, r, id)
+		case http.MethodPut:
+			h.Update(w, r, id)
+		case http.MethodDelete:
+			h.Delete(w, r, id)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	if parts[1] != "deliveries" {
+		http.NotFound(w, r)
+		return
+	}
+	if len(parts) == 2 {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.GetDeliveries(w, r, id)
+		return
+	}
+
+	deliveryID, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid delivery ID", http.StatusBadRequest)
+		return
+	}
+	switch {
+	case len(parts) == 3 && r.Method == http.MethodGet:
+		h.GetDelivery(w, r, id, deliveryID)
+	case len(parts) == 4 && parts[3] == "retry" && r.Method == http.MethodPost:
+		h.RetryDelivery(w, r, id, deliveryID)
+	case len(parts) == 4 && parts[3] != "retry":
+		http.NotFound(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *WebhookHandler) GetByID(w http.ResponseWriter, r *http.Request, id int) {
+	subscription, err := h.service.GetByID(id)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	writeJSONWithETag(w, r, versionETag(subscription.Version), subscription)
+}
+
+// Update - PUT /api/webhooks/{id}, secret diisi untuk rotasi, active false untuk menjeda pengiriman
+func (h *WebhookHandler) Update(w http.ResponseWriter, r *http.Request, id int) {
+	version, ok := optionalIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.WebhookSubscriptionRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	subscription, err := h.service.Update(actorFrom(r), id, version, req)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", versionETag(subscription.Version))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(subscription)
+}
+
+func (h *WebhookHandler) Delete(w http.ResponseWriter, r *http.Request, id int) {
+	version, ok := optionalIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(actorFrom(r), id, version); err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Webhook deleted successfully",
+	})
+}
+
+// GetDeliveries - GET /api/webhooks/{id}/deliveries?status=failed&event=transaction.created&limit=50&offset=0
+func (h *WebhookHandler) GetDeliveries(w http.ResponseWriter, r *http.Request, id int) {
+	filter := models.DeliveryFilter{
+		SubscriptionID: id,
+		Status:         r.URL.Query().Get("status"),
+		EventType:      r.URL.Query().Get("event"),
+	}
+	var err error
+	if filter.Limit, err = queryInt(r, "limit", 0); err != nil {
+		http.Error(w, "Invalid limit", http.StatusBadRequest)
+		return
+	}
+	if filter.Offset, err = queryInt(r, "offset", 0); err != nil {
+		http.Error(w, "Invalid offset", http.StatusBadRequest)
+		return
+	}
+
+	deliveries, err := h.service.GetDeliveries(filter)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deliveries)
+}
+
+// GetDelivery - isi event dan log setiap percobaan kirim
+func (h *WebhookHandler) GetDelivery(w http.ResponseWriter, r *http.Request, id int, deliveryID int64) {
+	delivery, err := h.service.GetDelivery(id, deliveryID)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(delivery)
+}
+
+// RetryDelivery - POST /api/webhooks/{id}/deliveries/{deliveryID}/retry, dikirim di putaran dispatcher berikutnya
+func (h *WebhookHandler) RetryDelivery(w http.ResponseWriter, r *http.Request, id int, deliveryID int64) {
+	delivery, err := h.service.RetryDelivery(id, deliveryID)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(delivery)
+}
+
+func writeWebhookError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrValidation):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, repositories.ErrWebhookNotFound), errors.Is(err, repositories.ErrDeliveryNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, repositories.ErrVersionMismatch):
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+	case errors.Is(err, repositories.ErrDeliveryPending):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
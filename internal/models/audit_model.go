@@ -0,0 +1,33 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditEntry - satu perubahan baris. Entity adalah nama tabel (mis. "products"), Before kosong untuk
+// create dan After kosong untuk delete permanen. Entry dengan TxID yang sama berasal dari satu transaksi DB.
+type AuditEntry struct {
+	ID        int64           `json:"id"`
+	Actor     string          `json:"actor"`
+	Entity    string          `json:"entity"`
+	EntityID  *string         `json:"entity_id"`
+	Action    string          `json:"action"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+	TxID      int64           `json:"tx_id"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// AuditFilter - field kosong tidak memfilter. Start/End membatasi created_at (inklusif).
+type AuditFilter struct {
+	Entity   string
+	EntityID string
+	Action   string
+	Actor    string
+	TxID     *int64
+	Start    *time.Time
+	End      *time.Time
+	Limit    int
+	Offset   int
+}
@@ -0,0 +1,43 @@
+package models
+
+import "encoding/json"
+
+// Operasi batch produk
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+)
+
+type ProductBatchRequest struct {
+	Operations []ProductBatchOperation `json:"operations"`
+}
+
+// ProductBatchOperation - update/delete butuh ID dan Version (setara If-Match).
+// Data berisi body produk seperti PUT: untuk update, field yang tidak dikirim tetap memakai nilai tersimpan.
+type ProductBatchOperation struct {
+	Op      string          `json:"op"`
+	ID      int             `json:"id,omitempty"`
+	Version int             `json:"version,omitempty"`
+	Data    json.RawMessage `json:"product,omitempty"`
+	// Product - hasil Data (update: didekode di atas produk tersimpan), diisi service
+	Product *Product `json:"-"`
+}
+
+// ProductBatchResult - Status mengikuti kode HTTP endpoint tunggalnya (201, 200, 404, 412, ...),
+// 424 berarti operasi ini valid tapi ikut dibatalkan karena operasi lain gagal
+type ProductBatchResult struct {
+	Index   int    `json:"index"`
+	Op      string `json:"op"`
+	ID      int    `json:"id,omitempty"`
+	Version int    `json:"version,omitempty"`
+	Status  int    `json:"status"`
+	Error   string `json:"error,omitempty"`
+	// Err - error operasi ini, dipetakan ke Status/Error oleh handler
+	Err error `json:"-"`
+}
+
+type ProductBatchResponse struct {
+	Committed bool                 `json:"committed"`
+	Results   []ProductBatchResult `json:"results"`
+}
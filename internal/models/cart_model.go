@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// Status keranjang: open bisa diubah, held diparkir, converted sudah jadi transaksi
+const (
+	CartOpen      = "open"
+	CartHeld      = "held"
+	CartConverted = "converted"
+	CartCancelled = "cancelled"
+)
+
+type Cart struct {
+	ID     int    `json:"id"`
+	Status string `json:"status"`
+	// Label - penanda di layar kasir, mis. nama pelanggan atau "Meja 5"
+	Label string `json:"label"`
+	// OutletID - outlet tempat keranjang dibuat, kosong saat dibuat berarti outlet default.
+	// Tidak bisa dipindah setelah keranjang dibuat.
+	OutletID   *int   `json:"outlet_id"`
+	CustomerID *int   `json:"customer_id"`
+	TableID    *int   `json:"table_id"`
+	Note       string `json:"note"`
+	// ReserveStock - stok baris keranjang ditahan supaya tidak terjual ke pelanggan lain
+	ReserveStock  bool       `json:"reserve_stock"`
+	TransactionID *int       `json:"transaction_id,omitempty"`
+	Version       int        `json:"version"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	Lines         []CartLine `json:"lines"`
+}
+
+type CartLine struct {
+	ID          int    `json:"id"`
+	CartID      int    `json:"cart_id"`
+	ProductID   int    `json:"product_id"`
+	ProductName string `json:"product_name,omitempty"`
+	VariantID   *int   `json:"variant_id,omitempty"`
+	VariantName string `json:"variant_name,omitempty"`
+	// Unit - satuan jual, kosong berarti satuan dasar produk
+	Unit     string   `json:"unit,omitempty"`
+	Quantity Quantity `json:"quantity"`
+	Note     string   `json:"note,omitempty"`
+	// ModifierIDs - input pilihan modifier, Modifiers berisi nama dan harga tambahannya saat ini
+	ModifierIDs []int          `json:"modifier_ids,omitempty"`
+	Modifiers   []LineModifier `json:"modifiers"`
+	// KitchenStatus - status tiket dapur, nil untuk produk yang tidak lewat dapur
+	KitchenStation string  `json:"kitchen_station,omitempty"`
+	KitchenStatus  *string `json:"kitchen_status,omitempty"`
+}
+
+// CartCheckoutRequest - pembayaran untuk menutup keranjang, item dan pelanggan diambil dari keranjang
+type CartCheckoutRequest struct {
+	RedeemPoints int               `json:"redeem_points,omitempty"`
+	Payments     []CheckoutPayment `json:"payments,omitempty"`
+}
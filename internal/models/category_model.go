@@ -1,7 +1,68 @@
 package models
 
+import "time"
+
 type Category struct {
 	ID          int    `json:"id"`
 	Name        string `json:"name"`
 	Description string `json:"description"`
+	// ParentID is nil for top-level categories (departments)
+	ParentID *int `json:"parent_id"`
+	Version  int  `json:"version"`
+	// DeletedAt is set once the category has been archived
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// Path is the breadcrumb from the root down to this category, Depth 0 is a root
+	Path  []CategoryRef `json:"path,omitempty"`
+	Depth int           `json:"depth"`
+	// Children is only filled in the tree view
+	Children []Category `json:"children,omitempty"`
+	// Stats is only filled with ?include=stats
+	Stats *CategoryStats `json:"stats,omitempty"`
+}
+
+// CategoryFilter - options for GET /api/categories
+type CategoryFilter struct {
+	IncludeArchived bool
+	Tree            bool
+	IncludeStats    bool
+	// SalesStart/SalesEnd is the window for CategoryStats.SalesRevenue
+	SalesStart time.Time
+	SalesEnd   time.Time
+}
+
+// CategoryStats covers the category and all of its subcategories.
+// Stock of products with variants is the sum of their active variants, bundles hold no stock.
+type CategoryStats struct {
+	ActiveProducts int      `json:"active_products"`
+	StockUnits     Quantity `json:"stock_units"`
+	// InventoryValue is stock at selling price, InventoryCost at cost price;
+	// SalesRevenue is net of refunded lines
+	InventoryValue int       `json:"inventory_value"`
+	InventoryCost  int       `json:"inventory_cost"`
+	SalesRevenue   int       `json:"sales_revenue"`
+	SalesStart     time.Time `json:"sales_start"`
+	SalesEnd       time.Time `json:"sales_end"`
+}
+
+type CategoryRef struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// CategorySales - sales of every product in a category and all of its subcategories
+type CategorySales struct {
+	CategoryID     int                    `json:"category_id"`
+	CategoryName   string                 `json:"category_name"`
+	TotalRevenue   int                    `json:"total_revenue"`
+	TotalQuantity  Quantity               `json:"total_quantity"`
+	TotalTransaksi int                    `json:"total_transaksi"`
+	Products       []CategoryProductSales `json:"products"`
+}
+
+type CategoryProductSales struct {
+	ProductID   int      `json:"product_id"`
+	ProductName string   `json:"product_name"`
+	CategoryID  int      `json:"category_id"`
+	Quantity    Quantity `json:"quantity"`
+	Revenue     int      `json:"revenue"`
 }
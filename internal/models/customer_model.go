@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+type Customer struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	// Phone - disimpan ternormalisasi (mis. "+62 812-3456" menjadi "08123456")
+	Phone *string `json:"phone"`
+	Email *string `json:"email"`
+	Notes string  `json:"notes"`
+	// PointsBalance - saldo poin loyalitas, rinciannya di /api/customers/{id}/points
+	PointsBalance int            `json:"points_balance"`
+	Version       int            `json:"version"`
+	DeletedAt     *time.Time     `json:"deleted_at,omitempty"`
+	CreatedAt     time.Time      `json:"created_at"`
+	Stats         *CustomerStats `json:"stats,omitempty"`
+}
+
+// CustomerStats - nilai belanja dan frekuensi kunjungan, satu kunjungan = satu hari dengan transaksi
+type CustomerStats struct {
+	TransactionCount  int        `json:"transaction_count"`
+	LifetimeValue     int        `json:"lifetime_value"`
+	AverageOrderValue int        `json:"average_order_value"`
+	Visits            int        `json:"visits"`
+	FirstVisit        *time.Time `json:"first_visit"`
+	LastVisit         *time.Time `json:"last_visit"`
+	// AverageDaysBetweenVisits nil kalau baru satu kunjungan
+	AverageDaysBetweenVisits *float64 `json:"average_days_between_visits"`
+	// VisitsPerMonth - kunjungan per 30 hari sejak kunjungan pertama
+	VisitsPerMonth float64 `json:"visits_per_month"`
+}
+
+type CustomerFilter struct {
+	// Search mencocokkan nama, telepon atau email
+	Search          string
+	IncludeArchived bool
+}
@@ -0,0 +1,39 @@
+package models
+
+// Mode impor katalog
+const (
+	// ImportAllOrNothing - satu baris gagal, semua dibatalkan
+	ImportAllOrNothing = "all_or_nothing"
+	// ImportSkipInvalid - baris yang gagal dilewati, sisanya tetap disimpan
+	ImportSkipInvalid = "skip_invalid"
+)
+
+// ImportClearMarker - isi sel untuk mengosongkan kolom opsional (plu, category_id) saat update
+const ImportClearMarker = "-"
+
+// ProductImportRow - satu baris file impor yang sudah lolos validasi format
+type ProductImportRow struct {
+	Line    int
+	Product Product
+	// Filled - kolom yang selnya terisi di baris ini. Saat update hanya kolom ini yang diubah,
+	// sel kosong berarti nilai lama dipertahankan (ImportClearMarker untuk mengosongkan).
+	Filled map[string]bool
+}
+
+type ImportError struct {
+	Line    int    `json:"line"`
+	SKU     string `json:"sku,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportResult - Created/Updated tetap dihitung saat dry run atau dibatalkan, Committed menandai tersimpan
+type ImportResult struct {
+	DryRun    bool          `json:"dry_run"`
+	Mode      string        `json:"mode"`
+	TotalRows int           `json:"total_rows"`
+	Created   int           `json:"created"`
+	Updated   int           `json:"updated"`
+	Skipped   int           `json:"skipped"`
+	Committed bool          `json:"committed"`
+	Errors    []ImportError `json:"errors"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// LoyaltySettings - RupiahPerPoint 0 berarti pengumpulan poin nonaktif, ExpiryDays 0 = poin tidak kedaluwarsa
+type LoyaltySettings struct {
+	RupiahPerPoint  int `json:"rupiah_per_point"`
+	PointValue      int `json:"point_value"`
+	MinRedeemPoints int `json:"min_redeem_points"`
+	ExpiryDays      int `json:"expiry_days"`
+	Version         int `json:"version"`
+}
+
+// LoyaltyCategoryRate - tarif khusus kategori, berlaku juga untuk subkategorinya. 0 = tanpa poin.
+type LoyaltyCategoryRate struct {
+	CategoryID     int    `json:"category_id"`
+	CategoryName   string `json:"category_name"`
+	RupiahPerPoint int    `json:"rupiah_per_point"`
+}
+
+// Jenis entri buku besar poin
+const (
+	PointsEarn    = "earn"
+	PointsRedeem  = "redeem"
+	PointsReverse = "reverse" // poin dari transaksi yang diretur ditarik kembali
+	PointsReturn  = "return"  // poin yang dipakai di transaksi yang diretur dikembalikan
+	PointsExpire  = "expire"
+)
+
+type LoyaltyEntry struct {
+	ID         int    `json:"id"`
+	CustomerID int    `json:"customer_id"`
+	Points     int    `json:"points"`
+	Type       string `json:"type"`
+	// Remaining - sisa poin entri earn/return yang belum ditukar atau kedaluwarsa
+	Remaining     int        `json:"remaining"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	TransactionID *int       `json:"transaction_id,omitempty"`
+	RefundID      *int       `json:"refund_id,omitempty"`
+	Note          string     `json:"note,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+type PointsStatement struct {
+	CustomerID int            `json:"customer_id"`
+	Balance    int            `json:"balance"`
+	Entries    []LoyaltyEntry `json:"entries"`
+}
@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+// Jenis outlet
+const (
+	OutletStore     = "store"
+	OutletWarehouse = "warehouse"
+)
+
+// Outlet - cabang toko. Katalog produk dan kategori dipakai bersama, stok dicatat per outlet.
+type Outlet struct {
+	ID      int    `json:"id"`
+	Code    string `json:"code"`
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	// Kind - store (toko) atau warehouse (gudang, tidak bisa dipakai untuk penjualan)
+	Kind string `json:"kind"`
+	// IsDefault - outlet yang dipakai kalau request tidak menyebut outlet_id,
+	// juga tempat perubahan stok dari katalog (PUT produk, impor) dibukukan
+	IsDefault bool       `json:"is_default"`
+	Version   int        `json:"version"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// OutletStock - stok satu produk/varian di satu outlet dalam satuan dasar
+type OutletStock struct {
+	OutletID    int      `json:"outlet_id"`
+	ProductID   int      `json:"product_id"`
+	ProductName string   `json:"product_name"`
+	VariantID   *int     `json:"variant_id,omitempty"`
+	VariantName string   `json:"variant_name,omitempty"`
+	BaseUnit    string   `json:"base_unit"`
+	Quantity    Quantity `json:"quantity"`
+	Reserved    Quantity `json:"reserved"`
+	Available   Quantity `json:"available"`
+}
+
+// StockAdjustment - koreksi stok satu outlet dari hasil hitung fisik, Quantity adalah jumlah baru
+type StockAdjustment struct {
+	ProductID int      `json:"product_id"`
+	VariantID *int     `json:"variant_id,omitempty"`
+	Quantity  Quantity `json:"quantity"`
+}
+
+// OutletSales - ringkasan penjualan satu outlet dalam satu periode
+type OutletSales struct {
+	OutletID       int    `json:"outlet_id"`
+	Code           string `json:"code"`
+	Name           string `json:"name"`
+	TotalRevenue   int    `json:"total_revenue"`
+	TotalRefunds   int    `json:"total_refunds"`
+	NetRevenue     int    `json:"net_revenue"`
+	TotalTransaksi int    `json:"total_transaksi"`
+}
+
+// ConsolidatedReport - laporan gabungan semua outlet, total sama dengan laporan tanpa filter outlet
+type ConsolidatedReport struct {
+	TotalRevenue   int           `json:"total_revenue"`
+	TotalRefunds   int           `json:"total_refunds"`
+	NetRevenue     int           `json:"net_revenue"`
+	TotalTransaksi int           `json:"total_transaksi"`
+	Outlets        []OutletSales `json:"outlets"`
+}
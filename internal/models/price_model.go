@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// PriceHistory - satu baris setiap kali harga produk berubah. Harga awal produk baru dicatat dengan OldPrice 0.
+type PriceHistory struct {
+	ID         int       `json:"id"`
+	ProductID  int       `json:"product_id"`
+	OldPrice   int       `json:"old_price"`
+	NewPrice   int       `json:"new_price"`
+	Source     string    `json:"source"`
+	ScheduleID *int      `json:"schedule_id,omitempty"`
+	ChangedAt  time.Time `json:"changed_at"`
+}
+
+// PriceSchedule - harga yang berlaku mulai EffectiveFrom
+type PriceSchedule struct {
+	ID            int        `json:"id"`
+	ProductID     int        `json:"product_id"`
+	Price         int        `json:"price"`
+	EffectiveFrom time.Time  `json:"effective_from"`
+	AppliedAt     *time.Time `json:"applied_at,omitempty"`
+	CancelledAt   *time.Time `json:"cancelled_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
@@ -1,8 +1,104 @@
 package models
 
+import "time"
+
 type Product struct {
-	ID    int    `json:"id"`
-	Name  string `json:"name"`
-	Price int    `json:"price"`
-	Stock int    `json:"stock"`
+	ID int `json:"id"`
+	// SKU - kode barang unik, kunci upsert saat impor katalog
+	SKU   *string  `json:"sku,omitempty"`
+	Name  string   `json:"name"`
+	Price int      `json:"price"`
+	Stock Quantity `json:"stock"`
+	// Available - stok dikurangi reservasi aktif, hanya diisi saat produk dibaca
+	Available *Quantity `json:"available,omitempty"`
+	// Version naik setiap kali baris berubah, dipakai sebagai ETag
+	Version int `json:"version"`
+	// DeletedAt terisi kalau produk diarsipkan (soft delete)
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// Options - sumbu varian (ukuran, warna, rasa), diatur lewat /api/products/{id}/options
+	Options  []ProductOption  `json:"options"`
+	Variants []ProductVariant `json:"variants,omitempty"`
+	// BaseUnit - satuan stok, harga dan stok selalu dalam satuan ini
+	BaseUnit string `json:"base_unit"`
+	// Cost - harga pokok per satuan dasar, diperbarui dari penerimaan barang terakhir
+	Cost  int           `json:"cost"`
+	Units []ProductUnit `json:"units,omitempty"`
+	// QuantityPrecision - jumlah desimal yang boleh dijual, mis. 3 untuk gula per kg (0.750)
+	QuantityPrecision int `json:"quantity_precision"`
+	// PLU - kode barang 5 digit di barcode timbangan
+	PLU *string `json:"plu,omitempty"`
+	// IsBundle - produk paket, stok diambil dari BundleItems
+	IsBundle    bool         `json:"is_bundle"`
+	BundleItems []BundleItem `json:"bundle_items,omitempty"`
+	CategoryID  *int         `json:"category_id"`
+	// KitchenStation - stasiun dapur yang menyiapkan produk, kosong berarti tidak lewat dapur
+	KitchenStation string            `json:"kitchen_station"`
+	Modifiers      []ProductModifier `json:"modifiers,omitempty"`
+	// Images - gambar pertama adalah gambar utama
+	Images []ProductImage `json:"images,omitempty"`
+}
+
+// ProductImage - URL diisi dari storage, key-nya tidak dikirim ke client
+type ProductImage struct {
+	ID           int       `json:"id"`
+	ProductID    int       `json:"product_id"`
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+	ContentType  string    `json:"content_type"`
+	Size         int       `json:"size"`
+	Width        int       `json:"width"`
+	Height       int       `json:"height"`
+	Position     int       `json:"position"`
+	CreatedAt    time.Time `json:"created_at"`
+	Key          string    `json:"-"`
+	ThumbnailKey string    `json:"-"`
+}
+
+// BundleItem - satu komponen paket, Quantity dalam satuan dasar komponen
+type BundleItem struct {
+	ComponentID        int      `json:"component_id"`
+	ComponentVariantID *int     `json:"component_variant_id,omitempty"`
+	ComponentName      string   `json:"component_name,omitempty"`
+	Quantity           Quantity `json:"quantity"`
+}
+
+// ProductUnit - satuan jual/beli lain, mis. "dus" dengan Factor 40 (1 dus = 40 pcs)
+type ProductUnit struct {
+	ID        int      `json:"id"`
+	ProductID int      `json:"product_id"`
+	Name      string   `json:"name"`
+	Factor    Quantity `json:"factor"`
+	// Price - harga khusus per satuan ini, nil berarti harga satuan dasar x Factor
+	Price       *int `json:"price,omitempty"`
+	Sellable    bool `json:"sellable"`
+	Purchasable bool `json:"purchasable"`
+}
+
+type ProductOption struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+// ProductVariant - satu kombinasi opsi, mis. {"Ukuran": "L"}, dengan SKU, harga dan stok sendiri
+type ProductVariant struct {
+	ID        int               `json:"id"`
+	ProductID int               `json:"product_id"`
+	SKU       *string           `json:"sku,omitempty"`
+	Name      string            `json:"name"`
+	Options   map[string]string `json:"options"`
+	Price     int               `json:"price"`
+	Stock     Quantity          `json:"stock"`
+	Available *Quantity         `json:"available,omitempty"`
+	Version   int               `json:"version"`
+	DeletedAt *time.Time        `json:"deleted_at,omitempty"`
+}
+
+// ProductFilter - filter untuk GET /api/products
+type ProductFilter struct {
+	Name            string
+	IncludeArchived bool
+	// CategoryID - produk di kategori ini beserta semua subkategorinya
+	CategoryID *int
+	// IDs - hanya produk dengan ID ini, dipakai internal (batch), bukan dari query string
+	IDs []int64
 }
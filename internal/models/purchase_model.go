@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// PurchaseReceipt - penerimaan barang dari supplier, menambah stok
+type PurchaseReceipt struct {
+	ID       int    `json:"id"`
+	Supplier string `json:"supplier"`
+	// OutletID - outlet yang menerima barang, kosong berarti outlet default
+	OutletID   *int                  `json:"outlet_id"`
+	Note       string                `json:"note"`
+	TotalCost  int                   `json:"total_cost"`
+	ReceivedAt time.Time             `json:"received_at"`
+	Lines      []PurchaseReceiptLine `json:"lines"`
+}
+
+type PurchaseReceiptLine struct {
+	ID        int  `json:"id"`
+	ReceiptID int  `json:"receipt_id"`
+	ProductID int  `json:"product_id"`
+	VariantID *int `json:"variant_id,omitempty"`
+	// Unit - satuan beli, kosong berarti satuan dasar produk
+	Unit         string   `json:"unit"`
+	UnitFactor   Quantity `json:"unit_factor"`
+	Quantity     Quantity `json:"quantity"`
+	BaseQuantity Quantity `json:"base_quantity"`
+	// UnitCost - harga beli per Unit
+	UnitCost int `json:"unit_cost"`
+	Subtotal int `json:"subtotal"`
+	// LotNumber / ExpiresAt (YYYY-MM-DD) - kalau salah satu diisi, barang dicatat sebagai lot baru
+	LotNumber string  `json:"lot_number,omitempty"`
+	ExpiresAt *string `json:"expires_at,omitempty"`
+	LotID     *int    `json:"lot_id,omitempty"`
+}
@@ -0,0 +1,208 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// MaxQuantityPrecision - jumlah desimal maksimum yang disimpan database (NUMERIC(14, 3))
+const MaxQuantityPrecision = 3
+
+// QuantityScale - Quantity(QuantityScale) sama dengan 1 satuan
+const QuantityScale = 1000
+
+// Quantity - jumlah barang (stok, jumlah jual, faktor satuan) sebagai bilangan bulat perseribu,
+// sama persis dengan NUMERIC(14, 3) di database. Penjumlahan dan pengurangan cukup dengan + dan -,
+// perkalian dan pembagian lewat Mul/Div yang membulatkan ke 3 desimal (setengah menjauhi nol).
+// Di JSON ditulis sebagai angka biasa, mis. 0.75.
+type Quantity int64
+
+// Units - jumlah bulat, mis. Units(2) untuk 2 pcs
+func Units(n int) Quantity {
+	return Quantity(n) * QuantityScale
+}
+
+// QuantityRatio - a/b dibulatkan ke 3 desimal, mis. harga label dibagi harga per kg
+func QuantityRatio(a, b int) Quantity {
+	return Quantity(mulDiv(int64(a), QuantityScale, int64(b)))
+}
+
+// QuantityFromFloat - untuk nilai konfigurasi, dibulatkan ke 3 desimal
+func QuantityFromFloat(f float64) Quantity {
+	return Quantity(math.Round(f * QuantityScale))
+}
+
+// ParseQuantity membaca angka desimal dengan maksimal 3 angka di belakang koma tanpa pembulatan
+func ParseQuantity(s string) (Quantity, error) {
+	q, exact, err := parseDecimal(s)
+	if err != nil {
+		return 0, err
+	}
+	if !exact {
+		return 0, fmt.Errorf("jumlah %q melebihi %d angka desimal", s, MaxQuantityPrecision)
+	}
+	return q, nil
+}
+
+// parseDecimal membaca angka desimal (boleh bertanda, tanpa eksponen) dan membulatkannya ke
+// 3 desimal. exact false kalau ada angka bukan nol yang terbuang.
+func parseDecimal(s string) (Quantity, bool, error) {
+	digits, negative := strings.CutPrefix(s, "-")
+	whole, frac, _ := strings.Cut(digits, ".")
+	if whole == "" || !isDigits(whole) || !isDigits(frac) {
+		return 0, false, fmt.Errorf("jumlah %q bukan angka desimal", s)
+	}
+
+	exact := len(strings.TrimRight(frac, "0")) <= MaxQuantityPrecision
+	roundUp := len(frac) > MaxQuantityPrecision && frac[MaxQuantityPrecision] >= '5'
+	if len(frac) > MaxQuantityPrecision {
+		frac = frac[:MaxQuantityPrecision]
+	} else {
+		frac += strings.Repeat("0", MaxQuantityPrecision-len(frac))
+	}
+
+	n, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("jumlah %q terlalu besar", s)
+	}
+	if roundUp {
+		n++
+	}
+	if negative {
+		n = -n
+	}
+	return Quantity(n), exact, nil
+}
+
+func isDigits(s string) bool {
+	return strings.Trim(s, "0123456789") == ""
+}
+
+// Mul - q x r, mis. jumlah jual x faktor satuan
+func (q Quantity) Mul(r Quantity) Quantity {
+	return Quantity(mulDiv(int64(q), int64(r), QuantityScale))
+}
+
+// Div - q / r, r tidak boleh nol
+func (q Quantity) Div(r Quantity) Quantity {
+	return Quantity(mulDiv(int64(q), QuantityScale, int64(r)))
+}
+
+// Proportion - bagian q sebesar part/whole tanpa pembulatan antara, mis. porsi stok yang diretur
+func (q Quantity) Proportion(part, whole Quantity) Quantity {
+	return Quantity(mulDiv(int64(q), int64(part), int64(whole)))
+}
+
+// Rupiah - harga per satuan x q, dibulatkan ke rupiah terdekat
+func (q Quantity) Rupiah(price int) int {
+	return int(mulDiv(int64(price), int64(q), QuantityScale))
+}
+
+// ProportionRupiah - bagian amount sebesar part/whole, dibulatkan ke rupiah terdekat
+func ProportionRupiah(amount int, part, whole Quantity) int {
+	return int(mulDiv(int64(amount), int64(part), int64(whole)))
+}
+
+// HasPrecision - apakah q tidak punya angka bukan nol setelah desimal ke-precision
+func (q Quantity) HasPrecision(precision int) bool {
+	if precision >= MaxQuantityPrecision {
+		return true
+	}
+	return int64(q)%int64(math.Pow10(MaxQuantityPrecision-precision)) == 0
+}
+
+// Float64 - hanya untuk tampilan (spreadsheet), jangan dipakai untuk menghitung
+func (q Quantity) Float64() float64 {
+	return float64(q) / QuantityScale
+}
+
+// String - angka desimal tanpa nol di belakang, mis. "2", "0.75", "-1.5"
+func (q Quantity) String() string {
+	sign := ""
+	n := int64(q)
+	if n < 0 {
+		sign = "-"
+		n = -n
+	}
+	s := sign + strconv.FormatInt(n/QuantityScale, 10)
+	if frac := n % QuantityScale; frac != 0 {
+		s += "." + strings.TrimRight(fmt.Sprintf("%03d", frac), "0")
+	}
+	return s
+}
+
+func (q Quantity) MarshalJSON() ([]byte, error) {
+	return []byte(q.String()), nil
+}
+
+// UnmarshalJSON menerima angka JSON dengan maksimal 3 desimal, lebih dari itu ditolak
+// supaya jumlah tidak dibulatkan diam-diam
+func (q *Quantity) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if strings.ContainsAny(s, "eE") {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		s = strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	v, err := ParseQuantity(s)
+	if err != nil {
+		return err
+	}
+	*q = v
+	return nil
+}
+
+// Scan membaca NUMERIC dari database. Hasil perkalian dua kolom NUMERIC(14, 3)
+// punya 6 desimal, jadi dibulatkan ke 3 desimal.
+func (q *Quantity) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		parsed, _, err := parseDecimal(string(v))
+		*q = parsed
+		return err
+	case string:
+		parsed, _, err := parseDecimal(v)
+		*q = parsed
+		return err
+	case int64:
+		*q = Units(int(v))
+		return nil
+	case float64:
+		*q = QuantityFromFloat(v)
+		return nil
+	}
+	return fmt.Errorf("tidak bisa membaca %T sebagai jumlah", src)
+}
+
+// Value dikirim sebagai teks supaya Postgres membacanya sebagai NUMERIC yang persis
+func (q Quantity) Value() (driver.Value, error) {
+	return q.String(), nil
+}
+
+// mulDiv - a*b/c dibulatkan setengah menjauhi nol, dihitung dengan big.Int supaya tidak overflow
+func mulDiv(a, b, c int64) int64 {
+	n := new(big.Int).Mul(big.NewInt(a), big.NewInt(b))
+	d := big.NewInt(c)
+	if d.Sign() < 0 {
+		n.Neg(n)
+		d.Neg(d)
+	}
+	quo, rem := new(big.Int).QuoRem(n, d, new(big.Int))
+	if rem.Abs(rem).Lsh(rem, 1).Cmp(d) >= 0 {
+		if n.Sign() < 0 {
+			quo.Sub(quo, big.NewInt(1))
+		} else {
+			quo.Add(quo, big.NewInt(1))
+		}
+	}
+	return quo.Int64()
+}
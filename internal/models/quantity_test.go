@@ -0,0 +1,155 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseQuantity(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Quantity
+		wantErr bool
+	}{
+		{in: "2", want: 2000},
+		{in: "0.75", want: 750},
+		{in: "1.500", want: 1500},
+		{in: "-0.125", want: -125},
+		{in: "007.1", want: 7100},
+		{in: "1.2340", want: 1234},
+		{in: "1.2345", wantErr: true},
+		{in: "", wantErr: true},
+		{in: ".5", wantErr: true},
+		{in: "1,5", wantErr: true},
+		{in: "abc", wantErr: true},
+		{in: "99999999999999999999", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseQuantity(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseQuantity(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("ParseQuantity(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestQuantityString(t *testing.T) {
+	tests := []struct {
+		in   Quantity
+		want string
+	}{
+		{0, "0"},
+		{2000, "2"},
+		{750, "0.75"},
+		{1005, "1.005"},
+		{-1500, "-1.5"},
+		{-5, "-0.005"},
+	}
+	for _, tt := range tests {
+		if got := tt.in.String(); got != tt.want {
+			t.Errorf("Quantity(%d).String() = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestQuantityScan(t *testing.T) {
+	tests := []struct {
+		src  interface{}
+		want Quantity
+	}{
+		{[]byte("12.500"), 12500},
+		// Hasil perkalian dua NUMERIC(14, 3) punya 6 desimal
+		{[]byte("0.333333"), 333},
+		{[]byte("0.666500"), 667},
+		{[]byte("-0.000500"), -1},
+		{int64(3), 3000},
+		{"1.25", 1250},
+	}
+	for _, tt := range tests {
+		var got Quantity
+		if err := got.Scan(tt.src); err != nil {
+			t.Errorf("Scan(%v) error = %v", tt.src, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Scan(%v) = %d, want %d", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestQuantityJSON(t *testing.T) {
+	var item struct {
+		Quantity Quantity `json:"quantity"`
+	}
+	if err := json.Unmarshal([]byte(`{"quantity": 0.1}`), &item); err != nil {
+		t.Fatal(err)
+	}
+	if item.Quantity != 100 {
+		t.Errorf("quantity = %d, want 100", item.Quantity)
+	}
+	if err := json.Unmarshal([]byte(`{"quantity": 1.5e2}`), &item); err != nil || item.Quantity != 150000 {
+		t.Errorf("quantity = %d (err %v), want 150000", item.Quantity, err)
+	}
+	if err := json.Unmarshal([]byte(`{"quantity": 0.0001}`), &item); err == nil {
+		t.Error("quantity dengan 4 desimal seharusnya ditolak")
+	}
+
+	item.Quantity = 2250
+	out, err := json.Marshal(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `{"quantity":2.25}` {
+		t.Errorf("Marshal = %s", out)
+	}
+}
+
+func TestQuantityArithmetic(t *testing.T) {
+	tests := []struct {
+		name string
+		got  int64
+		want int64
+	}{
+		// 0.1 + 0.2 tepat 0.3, tidak seperti float64
+		{"add", int64(Quantity(100) + Quantity(200)), 300},
+		{"mul factor", int64(Quantity(1500).Mul(Units(40))), 60000},
+		{"mul rounds half away from zero", int64(Quantity(5).Mul(Quantity(500))), 3},
+		{"mul negative", int64(Quantity(-5).Mul(Quantity(500))), -3},
+		{"div", int64(Units(1).Div(Units(3))), 333},
+		{"ratio", int64(QuantityRatio(25000, 40000)), 625},
+		{"proportion", int64(Units(10).Proportion(Units(1), Units(3))), 3333},
+		{"rupiah", int64(Quantity(750).Rupiah(15000)), 11250},
+		{"rupiah rounds", int64(Quantity(333).Rupiah(1000)), 333},
+		{"rupiah half", int64(Quantity(1500).Rupiah(3)), 5},
+		{"proportion rupiah", int64(ProportionRupiah(10000, Units(1), Units(3))), 3333},
+		{"from float", int64(QuantityFromFloat(2.5)), 2500},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %d, want %d", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestQuantityHasPrecision(t *testing.T) {
+	tests := []struct {
+		q         Quantity
+		precision int
+		want      bool
+	}{
+		{Units(2), 0, true},
+		{1500, 0, false},
+		{1500, 1, true},
+		{1250, 1, false},
+		{1250, 2, true},
+		{1, 3, true},
+	}
+	for _, tt := range tests {
+		if got := tt.q.HasPrecision(tt.precision); got != tt.want {
+			t.Errorf("Quantity(%d).HasPrecision(%d) = %v, want %v", tt.q, tt.precision, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// RefundRequest - Items kosong berarti seluruh sisa transaksi diretur
+type RefundRequest struct {
+	Items  []RefundItem `json:"items"`
+	Reason string       `json:"reason"`
+	// StoreCredit - uang retur diberikan sebagai store credit, bukan tunai
+	StoreCredit bool `json:"store_credit"`
+}
+
+type RefundItem struct {
+	DetailID int      `json:"detail_id"`
+	Quantity Quantity `json:"quantity"`
+}
+
+type Refund struct {
+	ID            int `json:"id"`
+	TransactionID int `json:"transaction_id"`
+	// Amount - uang yang dikembalikan, sudah dikurangi bagian potongan poin
+	Amount         int    `json:"amount"`
+	PointsDiscount int    `json:"points_discount"`
+	PointsReturned int    `json:"points_returned"`
+	PointsReversed int    `json:"points_reversed"`
+	Reason         string `json:"reason"`
+	// StoreCredit - akun store credit yang menerima Amount, nil kalau dikembalikan tunai
+	StoreCredit *StoredValueAccount `json:"store_credit,omitempty"`
+	CreatedAt   time.Time           `json:"created_at"`
+	Lines       []RefundLine        `json:"lines"`
+}
+
+type RefundLine struct {
+	ID          int      `json:"id"`
+	DetailID    int      `json:"detail_id"`
+	ProductName string   `json:"product_name"`
+	Quantity    Quantity `json:"quantity"`
+	Amount      int      `json:"amount"`
+}
@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// Status reservasi: active menahan stok sampai ExpiresAt, sisanya sudah melepas stoknya
+const (
+	ReservationActive   = "active"
+	ReservationConsumed = "consumed"
+	ReservationExpired  = "expired"
+	ReservationReleased = "released"
+)
+
+// Reservation - stok yang ditahan sementara untuk pesanan web shop/kiosk yang belum dibayar
+type Reservation struct {
+	ID int `json:"id"`
+	// Reference - nomor pesanan di sistem asal, unik di antara reservasi aktif
+	Reference string `json:"reference"`
+	// OutletID - outlet tempat stok ditahan, checkout reservasi harus di outlet ini
+	OutletID      int               `json:"outlet_id"`
+	Status        string            `json:"status"`
+	ExpiresAt     time.Time         `json:"expires_at"`
+	TransactionID *int              `json:"transaction_id,omitempty"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+	Lines         []ReservationLine `json:"lines"`
+}
+
+// ReservationLine - stok yang ditahan dalam satuan dasar, paket diuraikan menjadi komponennya
+type ReservationLine struct {
+	ProductID   int      `json:"product_id"`
+	ProductName string   `json:"product_name"`
+	VariantID   *int     `json:"variant_id,omitempty"`
+	VariantName string   `json:"variant_name,omitempty"`
+	Quantity    Quantity `json:"quantity"`
+}
+
+type ReservationRequest struct {
+	Reference string `json:"reference"`
+	// OutletID - kosong berarti outlet default
+	OutletID *int `json:"outlet_id,omitempty"`
+	// TTLMinutes - lama stok ditahan, 0 berarti default
+	TTLMinutes int            `json:"ttl_minutes"`
+	Items      []CheckoutItem `json:"items"`
+}
+
+type ReservationFilter struct {
+	Status    string
+	Reference string
+	OutletID  *int
+}
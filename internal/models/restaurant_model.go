@@ -0,0 +1,72 @@
+package models
+
+import "time"
+
+// DiningTable - meja/tempat duduk di kafe
+type DiningTable struct {
+	ID        int        `json:"id"`
+	Name      string     `json:"name"`
+	Seats     int        `json:"seats"`
+	Version   int        `json:"version"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	// OpenCartID - pesanan yang sedang berjalan di meja ini, nil berarti meja kosong
+	OpenCartID *int `json:"open_cart_id"`
+}
+
+// ProductModifier - pilihan tambahan untuk produk, PriceDelta ditambahkan ke harga per satuan jual
+type ProductModifier struct {
+	ID         int    `json:"id"`
+	ProductID  int    `json:"product_id"`
+	Name       string `json:"name"`
+	PriceDelta int    `json:"price_delta"`
+}
+
+// LineModifier - modifier yang dipilih di baris pesanan atau detail transaksi (snapshot)
+type LineModifier struct {
+	ModifierID *int   `json:"modifier_id"`
+	Name       string `json:"name"`
+	PriceDelta int    `json:"price_delta"`
+}
+
+// Status tiket dapur, hanya bisa maju sesuai urutan ini
+const (
+	KitchenQueued  = "queued"
+	KitchenCooking = "cooking"
+	KitchenReady   = "ready"
+	KitchenServed  = "served"
+)
+
+// KitchenStatusOrder - posisi tiap status tiket dapur
+var KitchenStatusOrder = map[string]int{
+	KitchenQueued:  0,
+	KitchenCooking: 1,
+	KitchenReady:   2,
+	KitchenServed:  3,
+}
+
+// KitchenTicket - satu baris pesanan di layar dapur
+type KitchenTicket struct {
+	LineID      int            `json:"line_id"`
+	CartID      int            `json:"cart_id"`
+	CartLabel   string         `json:"cart_label"`
+	TableID     *int           `json:"table_id,omitempty"`
+	TableName   string         `json:"table_name,omitempty"`
+	Station     string         `json:"station"`
+	Status      string         `json:"status"`
+	ProductID   int            `json:"product_id"`
+	ProductName string         `json:"product_name"`
+	VariantName string         `json:"variant_name,omitempty"`
+	Unit        string         `json:"unit,omitempty"`
+	Quantity    Quantity       `json:"quantity"`
+	Note        string         `json:"note,omitempty"`
+	Modifiers   []LineModifier `json:"modifiers"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   *time.Time     `json:"updated_at,omitempty"`
+}
+
+type KitchenFilter struct {
+	Station string
+	// Statuses kosong berarti tiket yang belum disajikan (queued, cooking, ready)
+	Statuses []string
+}
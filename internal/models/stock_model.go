@@ -0,0 +1,73 @@
+package models
+
+import "time"
+
+// StockMovement - satu baris riwayat stok, Quantity negatif berarti stok keluar
+type StockMovement struct {
+	ID              int       `json:"id"`
+	OutletID        int       `json:"outlet_id"`
+	ProductID       int       `json:"product_id"`
+	VariantID       *int      `json:"variant_id,omitempty"`
+	Quantity        Quantity  `json:"quantity"`
+	Reason          string    `json:"reason"`
+	ReferenceType   string    `json:"reference_type,omitempty"`
+	ReferenceID     *int      `json:"reference_id,omitempty"`
+	BundleProductID *int      `json:"bundle_product_id,omitempty"`
+	LotID           *int      `json:"lot_id,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// MovementSummary - rekap pergerakan stok satu produk dalam satu periode
+type MovementSummary struct {
+	ProductID   int    `json:"product_id"`
+	ProductName string `json:"product_name"`
+	// SoldDirect - terjual langsung, SoldInBundles - terpakai sebagai komponen paket
+	SoldDirect    Quantity `json:"sold_direct"`
+	SoldInBundles Quantity `json:"sold_in_bundles"`
+	Purchased     Quantity `json:"purchased"`
+	// TransferredIn/TransferredOut - transfer stok antar outlet, saling meniadakan di laporan gabungan
+	TransferredIn  Quantity `json:"transferred_in"`
+	TransferredOut Quantity `json:"transferred_out"`
+	NetChange      Quantity `json:"net_change"`
+}
+
+// BundleSales - rekap penjualan produk paket
+type BundleSales struct {
+	ProductID   int      `json:"product_id"`
+	ProductName string   `json:"product_name"`
+	QtySold     Quantity `json:"qty_sold"`
+	Revenue     int      `json:"revenue"`
+}
+
+// MovementReport - OutletID nil berarti gabungan semua outlet
+type MovementReport struct {
+	OutletID *int              `json:"outlet_id,omitempty"`
+	Bundles  []BundleSales     `json:"bundles"`
+	Products []MovementSummary `json:"products"`
+}
+
+// StockLot - batch stok dari satu penerimaan barang, Quantity adalah sisa dalam satuan dasar
+type StockLot struct {
+	ID        int    `json:"id"`
+	OutletID  int    `json:"outlet_id"`
+	ProductID int    `json:"product_id"`
+	VariantID *int   `json:"variant_id,omitempty"`
+	LotNumber string `json:"lot_number"`
+	// ExpiresAt - format YYYY-MM-DD, nil untuk barang tanpa tanggal kedaluwarsa
+	ExpiresAt        *string   `json:"expires_at"`
+	Quantity         Quantity  `json:"quantity"`
+	ReceivedQuantity Quantity  `json:"received_quantity"`
+	ReceiptID        *int      `json:"receipt_id,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// ExpiringLot - lot yang akan (atau sudah) kedaluwarsa, DaysLeft negatif berarti sudah lewat
+type ExpiringLot struct {
+	StockLot
+	OutletName  string `json:"outlet_name"`
+	ProductName string `json:"product_name"`
+	VariantName string `json:"variant_name,omitempty"`
+	BaseUnit    string `json:"base_unit"`
+	DaysLeft    int    `json:"days_left"`
+	Expired     bool   `json:"expired"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// Jenis akun saldo tersimpan
+const (
+	GiftCard    = "gift_card"
+	StoreCredit = "store_credit"
+)
+
+// Jenis entri buku besar saldo tersimpan
+const (
+	StoredValueIssue  = "issue"
+	StoredValueTopUp  = "top_up"
+	StoredValueRedeem = "redeem"
+)
+
+type StoredValueAccount struct {
+	ID   int    `json:"id"`
+	Code string `json:"code"`
+	Type string `json:"type"`
+	// CustomerID - pemilik, opsional untuk kartu hadiah
+	CustomerID *int      `json:"customer_id,omitempty"`
+	Balance    int       `json:"balance"`
+	CreatedAt  time.Time `json:"created_at"`
+	// Ledger hanya diisi di GET /api/stored-value/{code}
+	Ledger []StoredValueEntry `json:"ledger,omitempty"`
+}
+
+type StoredValueEntry struct {
+	ID            int       `json:"id"`
+	AccountID     int       `json:"account_id"`
+	Amount        int       `json:"amount"`
+	Type          string    `json:"type"`
+	BalanceAfter  int       `json:"balance_after"`
+	TransactionID *int      `json:"transaction_id,omitempty"`
+	RefundID      *int      `json:"refund_id,omitempty"`
+	Note          string    `json:"note,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// IssueStoredValueRequest - Code kosong berarti kode dibuat acak
+type IssueStoredValueRequest struct {
+	Type       string `json:"type"`
+	Code       string `json:"code"`
+	CustomerID *int   `json:"customer_id"`
+	Amount     int    `json:"amount"`
+	Note       string `json:"note"`
+}
+
+// StoredValueOperation - top up atau penukaran manual di luar checkout
+type StoredValueOperation struct {
+	Amount int    `json:"amount"`
+	Note   string `json:"note"`
+}
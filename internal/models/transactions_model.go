@@ -3,10 +3,43 @@ package models
 import "time"
 
 type Transaction struct {
-	ID          int                 `json:"id"`
-	TotalAmount int                 `json:"total_amount"`
-	CreatedAt   time.Time           `json:"created_at"`
-	Details     []TransactionDetail `json:"details"`
+	ID int `json:"id"`
+	// TotalAmount - yang dibayar pelanggan, sudah dikurangi potongan poin
+	TotalAmount int `json:"total_amount"`
+	// OutletID - outlet tempat transaksi terjadi dan stoknya dikurangi
+	OutletID   int  `json:"outlet_id"`
+	CustomerID *int `json:"customer_id,omitempty"`
+	TableID    *int `json:"table_id,omitempty"`
+	// PointsDiscount - potongan rupiah dari PointsRedeemed poin
+	PointsRedeemed int                  `json:"points_redeemed"`
+	PointsDiscount int                  `json:"points_discount"`
+	PointsEarned   int                  `json:"points_earned"`
+	CreatedAt      time.Time            `json:"created_at"`
+	Details        []TransactionDetail  `json:"details"`
+	Payments       []TransactionPayment `json:"payments"`
+}
+
+// Metode pembayaran
+const (
+	PaymentCash        = "cash"
+	PaymentCard        = "card"
+	PaymentStoredValue = "stored_value"
+)
+
+type TransactionPayment struct {
+	ID     int    `json:"id"`
+	Method string `json:"method"`
+	// AccountID/Code - akun saldo tersimpan untuk metode stored_value
+	AccountID *int   `json:"account_id,omitempty"`
+	Code      string `json:"code,omitempty"`
+	Amount    int    `json:"amount"`
+}
+
+// CheckoutPayment - Code wajib untuk stored_value
+type CheckoutPayment struct {
+	Method string `json:"method"`
+	Code   string `json:"code,omitempty"`
+	Amount int    `json:"amount"`
 }
 
 type TransactionDetail struct {
@@ -14,26 +47,65 @@ type TransactionDetail struct {
 	TransactionID int    `json:"transaction_id"`
 	ProductID     int    `json:"product_id"`
 	ProductName   string `json:"product_name,omitempty"`
-	Quantity      int    `json:"quantity"`
-	Subtotal      int    `json:"subtotal"`
+	VariantID     *int   `json:"variant_id,omitempty"`
+	VariantName   string `json:"variant_name,omitempty"`
+	// SKU - snapshot SKU varian (atau produk) saat checkout
+	SKU *string `json:"sku,omitempty"`
+	// Unit - satuan jual, Quantity dan UnitPrice dalam satuan ini (UnitFactor satuan dasar per unit)
+	Unit       string   `json:"unit,omitempty"`
+	UnitFactor Quantity `json:"unit_factor"`
+	UnitPrice  int      `json:"unit_price"`
+	Quantity   Quantity `json:"quantity"`
+	Subtotal   int      `json:"subtotal"`
+	// Modifiers - snapshot modifier yang dipilih, PriceDelta sudah termasuk di UnitPrice
+	Modifiers []LineModifier `json:"modifiers"`
+	// RefundedQuantity - jumlah yang sudah diretur, dalam satuan jual
+	RefundedQuantity Quantity `json:"refunded_quantity"`
 }
 
 type CheckoutItem struct {
 	ProductID int `json:"product_id"`
-	Quantity  int `json:"quantity"`
+	// VariantID wajib untuk produk yang punya varian
+	VariantID *int `json:"variant_id,omitempty"`
+	// Unit - satuan jual, kosong berarti satuan dasar produk
+	Unit     string   `json:"unit,omitempty"`
+	Quantity Quantity `json:"quantity"`
+	// ModifierIDs - modifier produk yang dipilih, harga tambahannya dihitung per satuan jual
+	ModifierIDs []int `json:"modifier_ids,omitempty"`
+	// Barcode - barcode timbangan EAN-13 (awalan 20-29), menggantikan product_id dan quantity
+	Barcode string `json:"barcode,omitempty"`
+	// LabelPrice - harga yang tertera di barcode harga (awalan 25-29), diisi saat barcode diurai
+	LabelPrice int `json:"-"`
 }
 
 type CheckoutRequest struct {
 	Items []CheckoutItem `json:"items"`
+	// OutletID - outlet kasir, kosong berarti outlet default (atau outlet keranjang/reservasi)
+	OutletID *int `json:"outlet_id,omitempty"`
+	// CustomerID - opsional, menghubungkan transaksi ke pelanggan
+	CustomerID *int `json:"customer_id,omitempty"`
+	// TableID - opsional, meja tempat pesanan disajikan
+	TableID *int `json:"table_id,omitempty"`
+	// RedeemPoints - poin yang ditukar jadi potongan harga, butuh customer_id
+	RedeemPoints int `json:"redeem_points,omitempty"`
+	// Payments - jumlahnya harus sama dengan total setelah potongan, kosong berarti tunai semua
+	Payments []CheckoutPayment `json:"payments,omitempty"`
+	// ReservationID - reservasi stok pesanan ini, stoknya dilepas untuk checkout lalu ditandai consumed
+	ReservationID *int `json:"reservation_id,omitempty"`
 }
 
 type ProductSales struct {
-	Nama       string `json:"nama"`
-	QtyTerjual int    `json:"qty_terjual"`
+	Nama       string   `json:"nama"`
+	QtyTerjual Quantity `json:"qty_terjual"`
 }
 
+// SalesReport - OutletID nil berarti gabungan semua outlet. TotalRevenue adalah omzet kotor,
+// TotalRefunds uang retur yang dibayarkan di periode ini dan NetRevenue selisih keduanya.
 type SalesReport struct {
+	OutletID       *int         `json:"outlet_id,omitempty"`
 	TotalRevenue   int          `json:"total_revenue"`
+	TotalRefunds   int          `json:"total_refunds"`
+	NetRevenue     int          `json:"net_revenue"`
 	TotalTransaksi int          `json:"total_transaksi"`
 	ProdukTerlaris ProductSales `json:"produk_terlaris"`
 }
@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+// Status transfer stok: stok asal berkurang saat dikirim (in_transit), stok tujuan bertambah saat diterima
+const (
+	TransferDraft     = "draft"
+	TransferInTransit = "in_transit"
+	TransferReceived  = "received"
+	TransferCancelled = "cancelled"
+)
+
+// StockTransfer - perpindahan stok dari satu outlet/gudang ke outlet/gudang lain
+type StockTransfer struct {
+	ID                  int                 `json:"id"`
+	SourceOutletID      int                 `json:"source_outlet_id"`
+	SourceOutletName    string              `json:"source_outlet_name,omitempty"`
+	DestinationOutletID int                 `json:"destination_outlet_id"`
+	DestinationName     string              `json:"destination_outlet_name,omitempty"`
+	Status              string              `json:"status"`
+	Note                string              `json:"note"`
+	Version             int                 `json:"version"`
+	CreatedAt           time.Time           `json:"created_at"`
+	DispatchedAt        *time.Time          `json:"dispatched_at,omitempty"`
+	ReceivedAt          *time.Time          `json:"received_at,omitempty"`
+	Lines               []StockTransferLine `json:"lines"`
+}
+
+// StockTransferLine - jumlah dalam satuan dasar. Discrepancy = ReceivedQuantity - Quantity,
+// negatif berarti barang kurang/hilang di perjalanan.
+type StockTransferLine struct {
+	ID               int       `json:"id"`
+	TransferID       int       `json:"transfer_id"`
+	ProductID        int       `json:"product_id"`
+	ProductName      string    `json:"product_name,omitempty"`
+	VariantID        *int      `json:"variant_id,omitempty"`
+	VariantName      string    `json:"variant_name,omitempty"`
+	BaseUnit         string    `json:"base_unit,omitempty"`
+	Quantity         Quantity  `json:"quantity"`
+	ReceivedQuantity *Quantity `json:"received_quantity"`
+	Discrepancy      *Quantity `json:"discrepancy,omitempty"`
+	Note             string    `json:"note,omitempty"`
+}
+
+// TransferReceipt - hasil hitung barang yang datang. Baris yang tidak disebut dianggap diterima utuh.
+type TransferReceipt struct {
+	Lines []TransferReceiptLine `json:"lines"`
+}
+
+type TransferReceiptLine struct {
+	LineID           int      `json:"line_id"`
+	ReceivedQuantity Quantity `json:"received_quantity"`
+	Note             string   `json:"note,omitempty"`
+}
+
+// TransferFilter - OutletID mencocokkan outlet asal maupun tujuan
+type TransferFilter struct {
+	Status   string
+	OutletID *int
+}
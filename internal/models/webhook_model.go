@@ -0,0 +1,112 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Jenis event yang bisa dilanggan webhook
+const (
+	EventTransactionCreated  = "transaction.created"
+	EventTransactionRefunded = "transaction.refunded"
+	EventProductStockLow     = "product.stock_low"
+)
+
+// WebhookEventTypes - semua jenis event yang dikenal, urutan dipakai untuk pesan validasi
+var WebhookEventTypes = []string{EventTransactionCreated, EventTransactionRefunded, EventProductStockLow}
+
+// Status pengiriman webhook
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// WebhookSubscription - URL yang menerima event. Secret hanya dikirim di respons create
+// (atau saat dirotasi lewat update), selebihnya tidak pernah ditampilkan.
+type WebhookSubscription struct {
+	ID          int        `json:"id"`
+	URL         string     `json:"url"`
+	Events      []string   `json:"events"`
+	Secret      string     `json:"secret,omitempty"`
+	Description string     `json:"description"`
+	Active      bool       `json:"active"`
+	Version     int        `json:"version"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// WebhookSubscriptionRequest - Active nil saat create berarti aktif, saat update berarti tidak diubah.
+// Secret kosong saat create dibuat acak, saat update berarti secret lama dipakai terus.
+type WebhookSubscriptionRequest struct {
+	URL         string   `json:"url"`
+	Events      []string `json:"events"`
+	Secret      string   `json:"secret"`
+	Description string   `json:"description"`
+	Active      *bool    `json:"active"`
+}
+
+// WebhookEvent - body yang dikirim ke penerima, Data berisi payload sesuai Type
+type WebhookEvent struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// StockLowEvent - payload product.stock_low: stok di outlet baru saja turun di bawah Threshold
+type StockLowEvent struct {
+	ProductID     int      `json:"product_id"`
+	ProductName   string   `json:"product_name"`
+	VariantID     *int     `json:"variant_id"`
+	VariantName   *string  `json:"variant_name"`
+	OutletID      int      `json:"outlet_id"`
+	Stock         Quantity `json:"stock"`
+	Threshold     Quantity `json:"threshold"`
+	TransactionID int      `json:"transaction_id"`
+}
+
+// WebhookDelivery - satu event untuk satu langganan. Attempts dan Event hanya diisi di detail pengiriman.
+type WebhookDelivery struct {
+	ID             int64                    `json:"id"`
+	SubscriptionID int                      `json:"subscription_id"`
+	EventID        int64                    `json:"event_id"`
+	EventType      string                   `json:"event_type"`
+	Status         string                   `json:"status"`
+	AttemptCount   int                      `json:"attempt_count"`
+	NextAttemptAt  *time.Time               `json:"next_attempt_at,omitempty"`
+	LastStatusCode *int                     `json:"last_status_code"`
+	LastError      string                   `json:"last_error"`
+	CreatedAt      time.Time                `json:"created_at"`
+	DeliveredAt    *time.Time               `json:"delivered_at,omitempty"`
+	Event          *WebhookEvent            `json:"event,omitempty"`
+	Attempts       []WebhookDeliveryAttempt `json:"attempts,omitempty"`
+}
+
+// WebhookDeliveryAttempt - log satu kali kirim. StatusCode nil kalau penerima tidak bisa dihubungi.
+// Isi respons penerima sengaja tidak disimpan.
+type WebhookDeliveryAttempt struct {
+	Attempt     int       `json:"attempt"`
+	StatusCode  *int      `json:"status_code"`
+	Error       string    `json:"error"`
+	DurationMS  int       `json:"duration_ms"`
+	AttemptedAt time.Time `json:"attempted_at"`
+}
+
+// DeliveryFilter - Status kosong berarti semua status
+type DeliveryFilter struct {
+	SubscriptionID int
+	Status         string
+	EventType      string
+	Limit          int
+	Offset         int
+}
+
+// PendingDelivery - pengiriman yang sudah diklaim dispatcher, lengkap dengan tujuan dan event-nya
+type PendingDelivery struct {
+	ID      int64
+	Attempt int
+	URL     string
+	Secret  string
+	Event   WebhookEvent
+}
@@ -0,0 +1,124 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"kasir-api/internal/models"
+	"strings"
+)
+
+// Jejak audit ditulis oleh trigger di database (migrasi 022), jadi selalu berada di transaksi DB
+// yang sama dengan perubahannya. Repository hanya perlu memberi tahu siapa actor-nya:
+// perubahan dijalankan lewat beginTx / execAs / queryRowAs, bukan langsung lewat repo.db.
+
+type AuditRepository struct {
+	db *sql.DB
+}
+
+func NewAuditRepository(db *sql.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// GetAll - entry terbaru dulu
+func (repo *AuditRepository) GetAll(filter models.AuditFilter) ([]models.AuditEntry, error) {
+	query := "SELECT id, actor, entity, entity_id, action, before_data, after_data, tx_id, created_at FROM audit_log"
+	conditions := []string{}
+	args := []interface{}{}
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.Entity != "" {
+		add("entity = $%d", filter.Entity)
+	}
+	if filter.EntityID != "" {
+		add("entity_id = $%d", filter.EntityID)
+	}
+	if filter.Action != "" {
+		add("action = $%d", filter.Action)
+	}
+	if filter.Actor != "" {
+		add("actor = $%d", filter.Actor)
+	}
+	if filter.TxID != nil {
+		add("tx_id = $%d", *filter.TxID)
+	}
+	if filter.Start != nil {
+		add("created_at >= $%d", *filter.Start)
+	}
+	if filter.End != nil {
+		add("created_at <= $%d", *filter.End)
+	}
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit, filter.Offset)
+	query += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]models.AuditEntry, 0)
+	for rows.Next() {
+		var e models.AuditEntry
+		var before, after []byte
+		err := rows.Scan(&e.ID, &e.Actor, &e.Entity, &e.EntityID, &e.Action, &before, &after, &e.TxID, &e.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		// NULL tetap nil, ditulis sebagai null di JSON
+		e.Before = before
+		e.After = after
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// beginTx memulai transaksi DB yang perubahannya dicatat atas nama actor.
+// Actor kosong dicatat sebagai "system" (job latar belakang).
+func beginTx(db *sql.DB, actor string) (*sql.Tx, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	if actor != "" {
+		if _, err := tx.Exec("SELECT set_config('kasir.actor', $1, true)", actor); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	return tx, nil
+}
+
+// execAs - pengganti db.Exec untuk satu perintah yang perlu dicatat atas nama actor
+func execAs(db *sql.DB, actor, query string, args ...interface{}) (sql.Result, error) {
+	tx, err := beginTx(db, actor)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return result, tx.Commit()
+}
+
+// queryRowAs - pengganti db.QueryRow(...).Scan(...) untuk INSERT/UPDATE ... RETURNING atas nama actor.
+// Error Scan (termasuk sql.ErrNoRows) dikembalikan apa adanya supaya pemanggil bisa memetakannya.
+func queryRowAs(db *sql.DB, actor, query string, args []interface{}, dest ...interface{}) error {
+	tx, err := beginTx(db, actor)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := tx.QueryRow(query, args...).Scan(dest...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
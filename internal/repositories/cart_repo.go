@@ -0,0 +1,602 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"kasir-api/internal/models"
+
+	"github.com/lib/pq"
+)
+
+type CartRepository struct {
+	db *sql.DB
+	// transactions dipakai untuk menutup keranjang lewat logika checkout yang sama
+	transactions *TransactionRepository
+}
+
+func NewCartRepository(db *sql.DB, transactions *TransactionRepository) *CartRepository {
+	return &CartRepository{db: db, transactions: transactions}
+}
+
+const cartColumns = "id, status, label, outlet_id, customer_id, table_id, note, reserve_stock, transaction_id, version, created_at, updated_at"
+
+func scanCart(row rowScanner) (models.Cart, error) {
+	var c models.Cart
+	err := row.Scan(&c.ID, &c.Status, &c.Label, &c.OutletID, &c.CustomerID, &c.TableID, &c.Note, &c.ReserveStock, &c.TransactionID, &c.Version, &c.CreatedAt, &c.UpdatedAt)
+	return c, err
+}
+
+// GetAll - statuses kosong berarti keranjang aktif (open dan held), outletID nil berarti semua outlet
+func (repo *CartRepository) GetAll(statuses []string, outletID *int) ([]models.Cart, error) {
+	if len(statuses) == 0 {
+		statuses = []string{models.CartOpen, models.CartHeld}
+	}
+	rows, err := repo.db.Query("SELECT "+cartColumns+" FROM carts WHERE status = ANY($1) AND ($2::int IS NULL OR outlet_id = $2) ORDER BY updated_at DESC, id DESC",
+		pq.Array(statuses), outletID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	carts := make([]models.Cart, 0)
+	for rows.Next() {
+		c, err := scanCart(rows)
+		if err != nil {
+			return nil, err
+		}
+		carts = append(carts, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := repo.attachLines(carts); err != nil {
+		return nil, err
+	}
+	return carts, nil
+}
+
+func (repo *CartRepository) GetByID(id int) (*models.Cart, error) {
+	c, err := scanCart(repo.db.QueryRow("SELECT "+cartColumns+" FROM carts WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, ErrCartNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	carts := []models.Cart{c}
+	if err := repo.attachLines(carts); err != nil {
+		return nil, err
+	}
+	return &carts[0], nil
+}
+
+func (repo *CartRepository) attachLines(carts []models.Cart) error {
+	if len(carts) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(carts))
+	index := make(map[int]int, len(carts))
+	for i := range carts {
+		ids[i] = int64(carts[i].ID)
+		index[carts[i].ID] = i
+		carts[i].Lines = make([]models.CartLine, 0)
+	}
+
+	rows, err := repo.db.Query(`
+		SELECT l.id, l.cart_id, l.product_id, p.name, l.variant_id, COALESCE(v.name, ''), l.unit, l.quantity, l.note,
+			l.kitchen_station, l.kitchen_status
+		FROM cart_lines l
+		JOIN products p ON p.id = l.product_id
+		LEFT JOIN product_variants v ON v.id = l.variant_id
+		WHERE l.cart_id = ANY($1)
+		ORDER BY l.id`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	lines := make(map[int]*models.CartLine)
+	var lineIDs []int64
+	for rows.Next() {
+		var l models.CartLine
+		err := rows.Scan(&l.ID, &l.CartID, &l.ProductID, &l.ProductName, &l.VariantID, &l.VariantName, &l.Unit, &l.Quantity, &l.Note,
+			&l.KitchenStation, &l.KitchenStatus)
+		if err != nil {
+			return err
+		}
+		l.Modifiers = make([]models.LineModifier, 0)
+		i := index[l.CartID]
+		carts[i].Lines = append(carts[i].Lines, l)
+		lineIDs = append(lineIDs, int64(l.ID))
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(lineIDs) == 0 {
+		return nil
+	}
+	for i := range carts {
+		for j := range carts[i].Lines {
+			lines[carts[i].Lines[j].ID] = &carts[i].Lines[j]
+		}
+	}
+
+	// Modifier ditampilkan dengan harga saat ini, harga final dihitung saat checkout
+	modRows, err := repo.db.Query(`
+		SELECT lm.cart_line_id, m.id, m.name, m.price_delta
+		FROM cart_line_modifiers lm
+		JOIN product_modifiers m ON m.id = lm.modifier_id
+		WHERE lm.cart_line_id = ANY($1)
+		ORDER BY m.id`, pq.Array(lineIDs))
+	if err != nil {
+		return err
+	}
+	defer modRows.Close()
+
+	for modRows.Next() {
+		var lineID, modifierID int
+		var m models.LineModifier
+		if err := modRows.Scan(&lineID, &modifierID, &m.Name, &m.PriceDelta); err != nil {
+			return err
+		}
+		m.ModifierID = &modifierID
+		l := lines[lineID]
+		l.Modifiers = append(l.Modifiers, m)
+		l.ModifierIDs = append(l.ModifierIDs, modifierID)
+	}
+	return modRows.Err()
+}
+
+// Create membuat keranjang beserta baris awalnya (boleh kosong)
+func (repo *CartRepository) Create(actor string, cart *models.Cart) error {
+	tx, err := beginTx(repo.db, actor)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := checkCartRefs(tx, cart); err != nil {
+		return err
+	}
+	outletID, err := resolveSalesOutlet(tx, cart.OutletID)
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRow(`INSERT INTO carts (label, outlet_id, customer_id, table_id, note, reserve_stock) VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+cartColumns, cart.Label, outletID, cart.CustomerID, cart.TableID, cart.Note, cart.ReserveStock).
+		Scan(&cart.ID, &cart.Status, &cart.Label, &cart.OutletID, &cart.CustomerID, &cart.TableID, &cart.Note, &cart.ReserveStock, &cart.TransactionID, &cart.Version, &cart.CreatedAt, &cart.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	for i := range cart.Lines {
+		if err := insertCartLine(tx, cart.ID, outletID, cart.ReserveStock, &cart.Lines[i]); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// checkCartRefs memastikan pelanggan dan meja keranjang masih aktif
+func checkCartRefs(tx *sql.Tx, cart *models.Cart) error {
+	if cart.CustomerID != nil {
+		if _, err := lockCustomer(tx, *cart.CustomerID); err != nil {
+			return err
+		}
+	}
+	if cart.TableID != nil {
+		return requireTable(tx, *cart.TableID)
+	}
+	return nil
+}
+
+// cartState - header keranjang yang sudah dikunci
+type cartState struct {
+	status       string
+	outletID     int
+	customerID   *int
+	tableID      *int
+	reserveStock bool
+}
+
+// lockCart mengunci keranjang dan memeriksa versinya (0 = tanpa cek versi)
+func lockCart(tx *sql.Tx, id, version int) (*cartState, error) {
+	var s cartState
+	var current int
+	err := tx.QueryRow("SELECT status, outlet_id, customer_id, table_id, reserve_stock, version FROM carts WHERE id = $1 FOR UPDATE", id).
+		Scan(&s.status, &s.outletID, &s.customerID, &s.tableID, &s.reserveStock, &current)
+	if err == sql.ErrNoRows {
+		return nil, ErrCartNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if version != 0 && version != current {
+		return nil, ErrVersionMismatch
+	}
+	return &s, nil
+}
+
+// requireStatus - ErrCartClosed kalau status keranjang bukan salah satu dari allowed
+func (s *cartState) requireStatus(action string, allowed ...string) error {
+	for _, a := range allowed {
+		if s.status == a {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: keranjang berstatus %s tidak bisa %s", ErrCartClosed, s.status, action)
+}
+
+func touchCart(tx *sql.Tx, id int) error {
+	_, err := tx.Exec("UPDATE carts SET version = version + 1, updated_at = NOW() WHERE id = $1", id)
+	return err
+}
+
+func insertCartLine(tx *sql.Tx, cartID, outletID int, reserve bool, line *models.CartLine) error {
+	item := models.CheckoutItem{ProductID: line.ProductID, VariantID: line.VariantID, Unit: line.Unit, Quantity: line.Quantity}
+	checked, _, err := lockCartLine(tx, outletID, item)
+	if err != nil {
+		return err
+	}
+	line.CartID = cartID
+	line.ProductName = checked.productName
+	line.VariantName = checked.variantName
+
+	// Produk yang punya stasiun dapur langsung masuk antrean tiket dapur
+	err = tx.QueryRow("SELECT kitchen_station FROM products WHERE id = $1", line.ProductID).Scan(&line.KitchenStation)
+	if err != nil {
+		return err
+	}
+	if line.KitchenStation != "" {
+		queued := models.KitchenQueued
+		line.KitchenStatus = &queued
+	}
+
+	err = tx.QueryRow(`INSERT INTO cart_lines (cart_id, product_id, variant_id, unit, quantity, note, kitchen_station, kitchen_status, kitchen_updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, CASE WHEN $8::text IS NULL THEN NULL ELSE NOW() END) RETURNING id`,
+		cartID, line.ProductID, line.VariantID, line.Unit, line.Quantity, line.Note, line.KitchenStation, line.KitchenStatus).Scan(&line.ID)
+	if err != nil {
+		return err
+	}
+	if line.Modifiers, err = setLineModifiers(tx, line.ID, line.ProductID, line.ModifierIDs); err != nil {
+		return err
+	}
+	if reserve {
+		return reserveCartLine(tx, outletID, cartID, line.ID, item)
+	}
+	return nil
+}
+
+// setLineModifiers mengganti pilihan modifier baris keranjang
+func setLineModifiers(tx *sql.Tx, lineID, productID int, ids []int) ([]models.LineModifier, error) {
+	modifiers, err := lineModifiers(tx, productID, ids)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec("DELETE FROM cart_line_modifiers WHERE cart_line_id = $1", lineID); err != nil {
+		return nil, err
+	}
+	for _, m := range modifiers {
+		if _, err := tx.Exec("INSERT INTO cart_line_modifiers (cart_line_id, modifier_id) VALUES ($1, $2)", lineID, *m.ModifierID); err != nil {
+			return nil, err
+		}
+	}
+	return modifiers, nil
+}
+
+// requireKitchenQueued - baris yang sudah mulai dibuat dapur tidak boleh diubah atau dihapus
+func requireKitchenQueued(status *string) error {
+	if status != nil && *status != models.KitchenQueued {
+		return fmt.Errorf("%w: status tiket %s", ErrKitchenStarted, *status)
+	}
+	return nil
+}
+
+// Update mengubah header keranjang. Mengaktifkan reserve_stock menahan stok semua baris,
+// menonaktifkannya melepas semua reservasi.
+func (repo *CartRepository) Update(actor string, cart *models.Cart) error {
+	tx, err := beginTx(repo.db, actor)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	state, err := lockCart(tx, cart.ID, cart.Version)
+	if err != nil {
+		return err
+	}
+	if err := state.requireStatus("diubah", models.CartOpen, models.CartHeld); err != nil {
+		return err
+	}
+	if err := checkCartRefs(tx, cart); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE carts SET label = $1, customer_id = $2, table_id = $3, note = $4, reserve_stock = $5 WHERE id = $6",
+		cart.Label, cart.CustomerID, cart.TableID, cart.Note, cart.ReserveStock, cart.ID)
+	if err != nil {
+		return err
+	}
+	if cart.ReserveStock != state.reserveStock {
+		if err := releaseCartReservations(tx, cart.ID); err != nil {
+			return err
+		}
+		if cart.ReserveStock {
+			if err := reserveCart(tx, state.outletID, cart.ID); err != nil {
+				return err
+			}
+		}
+	}
+	if err := touchCart(tx, cart.ID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// reserveCart menahan stok semua baris keranjang di outlet keranjang
+func reserveCart(tx *sql.Tx, outletID, cartID int) error {
+	lines, err := cartItems(tx, cartID)
+	if err != nil {
+		return err
+	}
+	for _, l := range lines {
+		if err := reserveCartLine(tx, outletID, cartID, l.id, l.item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type cartItem struct {
+	id   int
+	item models.CheckoutItem
+}
+
+func cartItems(tx *sql.Tx, cartID int) ([]cartItem, error) {
+	rows, err := tx.Query(`
+		SELECT l.id, l.product_id, l.variant_id, l.unit, l.quantity,
+			ARRAY(SELECT m.modifier_id FROM cart_line_modifiers m WHERE m.cart_line_id = l.id ORDER BY m.modifier_id)
+		FROM cart_lines l
+		WHERE l.cart_id = $1
+		ORDER BY l.id`, cartID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []cartItem
+	for rows.Next() {
+		var c cartItem
+		var modifierIDs pq.Int64Array
+		if err := rows.Scan(&c.id, &c.item.ProductID, &c.item.VariantID, &c.item.Unit, &c.item.Quantity, &modifierIDs); err != nil {
+			return nil, err
+		}
+		for _, id := range modifierIDs {
+			c.item.ModifierIDs = append(c.item.ModifierIDs, int(id))
+		}
+		items = append(items, c)
+	}
+	return items, rows.Err()
+}
+
+// AddLine menambah baris ke keranjang yang masih open
+func (repo *CartRepository) AddLine(actor string, cartID, version int, line *models.CartLine) error {
+	tx, err := beginTx(repo.db, actor)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	state, err := lockCart(tx, cartID, version)
+	if err != nil {
+		return err
+	}
+	if err := state.requireStatus("diubah", models.CartOpen); err != nil {
+		return err
+	}
+	if err := insertCartLine(tx, cartID, state.outletID, state.reserveStock, line); err != nil {
+		return err
+	}
+	if err := touchCart(tx, cartID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UpdateLine mengganti jumlah dan catatan baris, reservasinya dihitung ulang
+func (repo *CartRepository) UpdateLine(actor string, cartID, version int, line *models.CartLine) error {
+	tx, err := beginTx(repo.db, actor)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	state, err := lockCart(tx, cartID, version)
+	if err != nil {
+		return err
+	}
+	if err := state.requireStatus("diubah", models.CartOpen); err != nil {
+		return err
+	}
+
+	item := models.CheckoutItem{Quantity: line.Quantity}
+	err = tx.QueryRow("SELECT product_id, variant_id, unit, kitchen_station, kitchen_status FROM cart_lines WHERE id = $1 AND cart_id = $2 FOR UPDATE", line.ID, cartID).
+		Scan(&item.ProductID, &item.VariantID, &item.Unit, &line.KitchenStation, &line.KitchenStatus)
+	if err == sql.ErrNoRows {
+		return ErrCartLineNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if err := requireKitchenQueued(line.KitchenStatus); err != nil {
+		return err
+	}
+	checked, _, err := lockCartLine(tx, state.outletID, item)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("UPDATE cart_lines SET quantity = $1, note = $2 WHERE id = $3", line.Quantity, line.Note, line.ID); err != nil {
+		return err
+	}
+	if line.Modifiers, err = setLineModifiers(tx, line.ID, item.ProductID, line.ModifierIDs); err != nil {
+		return err
+	}
+	if state.reserveStock {
+		if _, err := tx.Exec("DELETE FROM stock_reservations WHERE cart_line_id = $1", line.ID); err != nil {
+			return err
+		}
+		if err := reserveCartLine(tx, state.outletID, cartID, line.ID, item); err != nil {
+			return err
+		}
+	}
+	if err := touchCart(tx, cartID); err != nil {
+		return err
+	}
+
+	line.CartID = cartID
+	line.ProductID, line.VariantID, line.Unit = item.ProductID, item.VariantID, item.Unit
+	line.ProductName, line.VariantName = checked.productName, checked.variantName
+	return tx.Commit()
+}
+
+// DeleteLine menghapus baris, reservasinya ikut terhapus (ON DELETE CASCADE)
+func (repo *CartRepository) DeleteLine(actor string, cartID, version, lineID int) error {
+	tx, err := beginTx(repo.db, actor)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	state, err := lockCart(tx, cartID, version)
+	if err != nil {
+		return err
+	}
+	if err := state.requireStatus("diubah", models.CartOpen); err != nil {
+		return err
+	}
+
+	var kitchenStatus *string
+	err = tx.QueryRow("SELECT kitchen_status FROM cart_lines WHERE id = $1 AND cart_id = $2 FOR UPDATE", lineID, cartID).Scan(&kitchenStatus)
+	if err == sql.ErrNoRows {
+		return ErrCartLineNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if err := requireKitchenQueued(kitchenStatus); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM cart_lines WHERE id = $1", lineID); err != nil {
+		return err
+	}
+	if err := touchCart(tx, cartID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// SetStatus menjalankan perpindahan status hold/resume/cancel.
+// Keranjang yang dibatalkan melepas semua reservasinya.
+func (repo *CartRepository) SetStatus(actor string, cartID, version int, status string) error {
+	tx, err := beginTx(repo.db, actor)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	state, err := lockCart(tx, cartID, version)
+	if err != nil {
+		return err
+	}
+
+	switch status {
+	case models.CartHeld:
+		err = state.requireStatus("diparkir", models.CartOpen)
+	case models.CartOpen:
+		err = state.requireStatus("dilanjutkan", models.CartHeld)
+	case models.CartCancelled:
+		err = state.requireStatus("dibatalkan", models.CartOpen, models.CartHeld)
+		if err == nil {
+			err = releaseCartReservations(tx, cartID)
+		}
+	default:
+		err = fmt.Errorf("status keranjang '%s' tidak dikenal", status)
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("UPDATE carts SET status = $1 WHERE id = $2", status, cartID); err != nil {
+		return err
+	}
+	if err := touchCart(tx, cartID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Checkout mengubah keranjang menjadi transaksi dalam satu transaksi DB: reservasi keranjang
+// dilepas dulu lalu stoknya langsung dipakai oleh checkout, jadi tidak bisa direbut pihak lain.
+func (repo *CartRepository) Checkout(actor string, cartID, version int, req models.CartCheckoutRequest) (*models.Transaction, error) {
+	tx, err := beginTx(repo.db, actor)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	state, err := lockCart(tx, cartID, version)
+	if err != nil {
+		return nil, err
+	}
+	if err := state.requireStatus("dibayar", models.CartOpen, models.CartHeld); err != nil {
+		return nil, err
+	}
+
+	lines, err := cartItems(tx, cartID)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("%w: keranjang kosong", ErrCartClosed)
+	}
+	if err := releaseCartReservations(tx, cartID); err != nil {
+		return nil, err
+	}
+
+	checkout := models.CheckoutRequest{
+		OutletID:     &state.outletID,
+		CustomerID:   state.customerID,
+		TableID:      state.tableID,
+		RedeemPoints: req.RedeemPoints,
+		Payments:     req.Payments,
+	}
+	for _, l := range lines {
+		checkout.Items = append(checkout.Items, l.item)
+	}
+	transaction, err := repo.transactions.createTransaction(tx, checkout)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec("UPDATE carts SET status = $1, transaction_id = $2 WHERE id = $3", models.CartConverted, transaction.ID, cartID); err != nil {
+		return nil, err
+	}
+	if err := touchCart(tx, cartID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
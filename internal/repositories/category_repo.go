@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"errors"
 	"kasir-api/internal/models"
+	"time"
 )
 
 type CategoryRepository struct {
@@ -16,9 +17,30 @@ func NewCategoryRepository(db *sql.DB) *CategoryRepository {
 
 // Add methods for CategoryRepository as needed
 
-func (repo *CategoryRepository) GetAll() ([]models.Category, error) {
+const categoryColumns = "id, name, description, parent_id, version, deleted_at"
+
+func scanCategory(row rowScanner) (models.Category, error) {
+	var c models.Category
+	err := row.Scan(&c.ID, &c.Name, &c.Description, &c.ParentID, &c.Version, &c.DeletedAt)
+	return c, err
+}
+
+// categorySubtree returns a subquery selecting the category given by param and all of its descendants
+func categorySubtree(param string) string {
+	return `WITH RECURSIVE subtree AS (
+			SELECT id FROM categories WHERE id = ` + param + `
+			UNION
+			SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+		) SELECT id FROM subtree`
+}
+
+func (repo *CategoryRepository) GetAll(includeArchived bool) ([]models.Category, error) {
 	// Implementation for fetching all categories from the database
-	query := "SELECT id, name, description FROM categories"
+	query := "SELECT " + categoryColumns + " FROM categories"
+	if !includeArchived {
+		query += " WHERE deleted_at IS NULL"
+	}
+	query += " ORDER BY id"
 	rows, err := repo.db.Query(query)
 	if err != nil {
 		return nil, err
@@ -27,32 +49,48 @@ func (repo *CategoryRepository) GetAll() ([]models.Category, error) {
 
 	categories := make([]models.Category, 0)
 	for rows.Next() {
-		var c models.Category
-		err := rows.Scan(&c.ID, &c.Name, &c.Description)
+		c, err := scanCategory(rows)
 		if err != nil {
 			return nil, err
 		}
 		categories = append(categories, c)
 	}
 
-	return categories, nil
+	return categories, rows.Err()
 }
 
-func (repo *CategoryRepository) Create(category *models.Category) error {
+func (repo *CategoryRepository) Create(actor string, category *models.Category) error {
 	// Implementation for creating a new category in the database
-	query := "INSERT INTO categories (name, description) VALUES ($1, $2) RETURNING id"
-	err := repo.db.QueryRow(query, category.Name, category.Description).Scan(&category.ID)
-	return err
+	tx, err := beginTx(repo.db, actor)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockCategoryTree(tx); err != nil {
+		return err
+	}
+	if category.ParentID != nil {
+		if err := checkParent(tx, 0, *category.ParentID); err != nil {
+			return err
+		}
+	}
+
+	query := "INSERT INTO categories (name, description, parent_id) VALUES ($1, $2, $3) RETURNING id, version"
+	err = tx.QueryRow(query, category.Name, category.Description, category.ParentID).Scan(&category.ID, &category.Version)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (repo *CategoryRepository) GetByID(id int) (*models.Category, error) {
 	// Implementation for fetching a category by ID from the database
-	query := "SELECT id, name, description FROM categories WHERE id = $1"
+	query := "SELECT " + categoryColumns + " FROM categories WHERE id = $1"
 
-	var c models.Category
-	err := repo.db.QueryRow(query, id).Scan(&c.ID, &c.Name, &c.Description)
+	c, err := scanCategory(repo.db.QueryRow(query, id))
 	if err == sql.ErrNoRows {
-		return nil, errors.New("category not found")
+		return nil, ErrCategoryNotFound
 	}
 	if err != nil {
 		return nil, err
@@ -60,10 +98,100 @@ func (repo *CategoryRepository) GetByID(id int) (*models.Category, error) {
 	return &c, nil
 }
 
-func (repo *CategoryRepository) Update(category *models.Category) error {
-	// Implementation for updating a category in the database
-	query := "UPDATE categories SET name = $1, description = $2 WHERE id = $3"
-	result, err := repo.db.Exec(query, category.Name, category.Description, category.ID)
+func (repo *CategoryRepository) Update(actor string, category *models.Category) error {
+	// Implementation for updating a category in the database.
+	// category.Version holds the expected version (0 skips the check) and receives the new one.
+	// Changing parent_id moves the category together with its whole subtree.
+	tx, err := beginTx(repo.db, actor)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockCategoryTree(tx); err != nil {
+		return err
+	}
+	if category.ParentID != nil {
+		if err := checkParent(tx, category.ID, *category.ParentID); err != nil {
+			return err
+		}
+	}
+
+	query := `UPDATE categories SET name = $1, description = $2, parent_id = $3, version = version + 1
+		WHERE id = $4 AND deleted_at IS NULL AND ($5 = 0 OR version = $5)
+		RETURNING version`
+	err = tx.QueryRow(query, category.Name, category.Description, category.ParentID, category.ID, category.Version).Scan(&category.Version)
+	if err == sql.ErrNoRows {
+		return repo.archiveConflict(category.ID, false)
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// lockCategoryTree serializes changes to the hierarchy, otherwise two concurrent moves
+// (A under B, B under A) could each pass the cycle check and together create a loop
+func lockCategoryTree(tx *sql.Tx) error {
+	_, err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext('categories.parent_id'))")
+	return err
+}
+
+// checkParent makes sure parentID is an active category outside the subtree of id (0 for a new category)
+func checkParent(tx *sql.Tx, id, parentID int) error {
+	var archived bool
+	err := tx.QueryRow("SELECT deleted_at IS NOT NULL FROM categories WHERE id = $1", parentID).Scan(&archived)
+	if err == sql.ErrNoRows || archived {
+		return ErrParentNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if id == 0 {
+		return nil
+	}
+
+	// Walk up from the new parent, reaching id means the parent lies inside id's subtree
+	var cycle bool
+	err = tx.QueryRow(`
+		WITH RECURSIVE ancestors AS (
+			SELECT id, parent_id FROM categories WHERE id = $1
+			UNION
+			SELECT c.id, c.parent_id FROM categories c JOIN ancestors a ON c.id = a.parent_id
+		)
+		SELECT EXISTS (SELECT 1 FROM ancestors WHERE id = $2)`, parentID, id).Scan(&cycle)
+	if err != nil {
+		return err
+	}
+	if cycle {
+		return ErrCategoryCycle
+	}
+	return nil
+}
+
+func (repo *CategoryRepository) Delete(actor string, id, version int) error {
+	// Categories are archived rather than removed, so they can be restored later.
+	// Subcategories have to be archived or moved first so the tree never has hidden branches.
+	tx, err := beginTx(repo.db, actor)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockCategoryTree(tx); err != nil {
+		return err
+	}
+	var hasChildren bool
+	err = tx.QueryRow("SELECT EXISTS (SELECT 1 FROM categories WHERE parent_id = $1 AND deleted_at IS NULL)", id).Scan(&hasChildren)
+	if err != nil {
+		return err
+	}
+	if hasChildren {
+		return ErrCategoryHasChildren
+	}
+
+	result, err := tx.Exec(`UPDATE categories SET deleted_at = COALESCE(deleted_at, NOW()), version = version + 1
+		WHERE id = $1 AND ($2 = 0 OR version = $2)`, id, version)
 	if err != nil {
 		return err
 	}
@@ -72,15 +200,88 @@ func (repo *CategoryRepository) Update(category *models.Category) error {
 		return err
 	}
 	if rowsAffected == 0 {
-		return errors.New("no category updated")
+		return repo.missingOrStale(id)
 	}
-	return nil
+	return tx.Commit()
+}
+
+func (repo *CategoryRepository) Restore(actor string, id, version int) error {
+	// A category under an archived parent can only come back once the parent is restored
+	var parentArchived bool
+	err := repo.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM categories c JOIN categories p ON c.parent_id = p.id
+		WHERE c.id = $1 AND p.deleted_at IS NOT NULL)`, id).Scan(&parentArchived)
+	if err != nil {
+		return err
+	}
+	if parentArchived {
+		return ErrParentNotFound
+	}
+
+	// Only archived categories can be restored
+	query := `UPDATE categories SET deleted_at = NULL, version = version + 1
+		WHERE id = $1 AND deleted_at IS NOT NULL AND ($2 = 0 OR version = $2)`
+	err = repo.execVersioned(actor, query, id, version)
+	if errors.Is(err, ErrVersionMismatch) {
+		return repo.archiveConflict(id, true)
+	}
+	return err
+}
+
+// GetSales sums sales made while the product was in the category or any of its subcategories
+// (the category is snapshotted on each transaction line at checkout, the subtree is the current one),
+// net of the quantities and amounts refunded from those sales
+func (repo *CategoryRepository) GetSales(id int, startDate, endDate time.Time) (*models.CategorySales, error) {
+	category, err := repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	sales := &models.CategorySales{
+		CategoryID:   category.ID,
+		CategoryName: category.Name,
+		Products:     make([]models.CategoryProductSales, 0),
+	}
+
+	query := `
+		SELECT td.product_id, (ARRAY_AGG(td.product_name ORDER BY td.id DESC))[1], td.category_id,
+			SUM(` + detailNetQuantity + `), SUM(` + detailNetRevenue + `)
+		FROM transaction_details td
+		JOIN transactions t ON td.transaction_id = t.id
+		WHERE td.category_id IN (` + categorySubtree("$1") + `)
+			AND t.created_at >= $2 AND t.created_at <= $3
+		GROUP BY td.product_id, td.category_id
+		ORDER BY 5 DESC`
+	rows, err := repo.db.Query(query, id, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ps models.CategoryProductSales
+		if err := rows.Scan(&ps.ProductID, &ps.ProductName, &ps.CategoryID, &ps.Quantity, &ps.Revenue); err != nil {
+			return nil, err
+		}
+		sales.TotalRevenue += ps.Revenue
+		sales.TotalQuantity += ps.Quantity
+		sales.Products = append(sales.Products, ps)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	err = repo.db.QueryRow(`
+		SELECT COUNT(DISTINCT td.transaction_id)
+		FROM transaction_details td
+		JOIN transactions t ON td.transaction_id = t.id
+		WHERE td.category_id IN (`+categorySubtree("$1")+`)
+			AND t.created_at >= $2 AND t.created_at <= $3`, id, startDate, endDate).Scan(&sales.TotalTransaksi)
+	if err != nil {
+		return nil, err
+	}
+	return sales, nil
 }
 
-func (repo *CategoryRepository) Delete(id int) error {
-	// Implementation for deleting a category from the database
-	query := "DELETE FROM categories WHERE id = $1"
-	result, err := repo.db.Exec(query, id)
+func (repo *CategoryRepository) execVersioned(actor, query string, id, version int) error {
+	result, err := execAs(repo.db, actor, query, id, version)
 	if err != nil {
 		return err
 	}
@@ -89,7 +290,108 @@ func (repo *CategoryRepository) Delete(id int) error {
 		return err
 	}
 	if rowsAffected == 0 {
-		return errors.New("no category deleted")
+		return repo.missingOrStale(id)
 	}
 	return nil
 }
+
+func (repo *CategoryRepository) missingOrStale(id int) error {
+	var exists bool
+	err := repo.db.QueryRow("SELECT EXISTS (SELECT 1 FROM categories WHERE id = $1)", id).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrCategoryNotFound
+	}
+	return ErrVersionMismatch
+}
+
+// archiveConflict explains an UPDATE guarded on the archive state that matched no row:
+// the category is missing, its archive state is not wantArchived, or the version is stale
+func (repo *CategoryRepository) archiveConflict(id int, wantArchived bool) error {
+	var archived bool
+	err := repo.db.QueryRow("SELECT deleted_at IS NOT NULL FROM categories WHERE id = $1", id).Scan(&archived)
+	if err == sql.ErrNoRows {
+		return ErrCategoryNotFound
+	}
+	if err != nil {
+		return err
+	}
+	switch {
+	case archived && !wantArchived:
+		return ErrCategoryArchived
+	case !archived && wantArchived:
+		return ErrCategoryNotArchived
+	}
+	return ErrVersionMismatch
+}
+
+// GetStats computes CategoryStats for every category in a single query, each category
+// rolls up its whole subtree through the closure of the parent links. Sales use the
+// category snapshotted at checkout, stock and product counts the current one.
+func (repo *CategoryRepository) GetStats(salesStart, salesEnd time.Time) (map[int]models.CategoryStats, error) {
+	query := `
+		WITH RECURSIVE closure AS (
+			SELECT id AS ancestor_id, id AS category_id FROM categories
+			UNION ALL
+			SELECT cl.ancestor_id, c.id FROM closure cl JOIN categories c ON c.parent_id = cl.category_id
+		),
+		stock_rows AS (
+			SELECT p.category_id, v.stock, v.price, p.cost
+			FROM products p
+			JOIN product_variants v ON v.product_id = p.id AND v.deleted_at IS NULL
+			WHERE p.deleted_at IS NULL AND NOT p.is_bundle
+			UNION ALL
+			SELECT p.category_id, p.stock, p.price, p.cost
+			FROM products p
+			WHERE p.deleted_at IS NULL AND NOT p.is_bundle
+				AND NOT EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = p.id AND v.deleted_at IS NULL)
+		),
+		product_counts AS (
+			SELECT cl.ancestor_id, COUNT(*) AS active_products
+			FROM closure cl JOIN products p ON p.category_id = cl.category_id AND p.deleted_at IS NULL
+			GROUP BY cl.ancestor_id
+		),
+		stock_totals AS (
+			SELECT cl.ancestor_id, SUM(s.stock) AS stock_units,
+				SUM(s.stock * s.price) AS inventory_value, SUM(s.stock * s.cost) AS inventory_cost
+			FROM closure cl JOIN stock_rows s ON s.category_id = cl.category_id
+			GROUP BY cl.ancestor_id
+		),
+		sales AS (
+			SELECT cl.ancestor_id, SUM(` + detailNetRevenue + `) AS revenue
+			FROM closure cl
+			JOIN transaction_details td ON td.category_id = cl.category_id
+			JOIN transactions t ON td.transaction_id = t.id
+			WHERE t.created_at >= $1 AND t.created_at <= $2
+			GROUP BY cl.ancestor_id
+		)
+		SELECT c.id,
+			COALESCE(pc.active_products, 0),
+			COALESCE(st.stock_units, 0),
+			COALESCE(ROUND(st.inventory_value), 0),
+			COALESCE(ROUND(st.inventory_cost), 0),
+			COALESCE(sa.revenue, 0)
+		FROM categories c
+		LEFT JOIN product_counts pc ON pc.ancestor_id = c.id
+		LEFT JOIN stock_totals st ON st.ancestor_id = c.id
+		LEFT JOIN sales sa ON sa.ancestor_id = c.id`
+	rows, err := repo.db.Query(query, salesStart, salesEnd)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := make(map[int]models.CategoryStats)
+	for rows.Next() {
+		var id int
+		st := models.CategoryStats{SalesStart: salesStart, SalesEnd: salesEnd}
+		err := rows.Scan(&id, &st.ActiveProducts, &st.StockUnits, &st.InventoryValue, &st.InventoryCost, &st.SalesRevenue)
+		if err != nil {
+			return nil, err
+		}
+		stats[id] = st
+	}
+	return stats, rows.Err()
+}
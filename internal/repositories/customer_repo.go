@@ -0,0 +1,271 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"kasir-api/internal/models"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+type CustomerRepository struct {
+	db *sql.DB
+}
+
+func NewCustomerRepository(db *sql.DB) *CustomerRepository {
+	return &CustomerRepository{db: db}
+}
+
+// customerColumns harus sama urutannya dengan scanCustomer
+const customerColumns = "id, name, phone, email, notes, points_balance, version, deleted_at, created_at"
+
+func scanCustomer(row rowScanner) (models.Customer, error) {
+	var c models.Customer
+	err := row.Scan(&c.ID, &c.Name, &c.Phone, &c.Email, &c.Notes, &c.PointsBalance, &c.Version, &c.DeletedAt, &c.CreatedAt)
+	return c, err
+}
+
+func (repo *CustomerRepository) GetAll(filter models.CustomerFilter) ([]models.Customer, error) {
+	query := "SELECT " + customerColumns + " FROM customers"
+	conditions := []string{}
+	args := []interface{}{}
+	if filter.Search != "" {
+		args = append(args, "%"+filter.Search+"%")
+		conditions = append(conditions, fmt.Sprintf("(name ILIKE $%d OR phone ILIKE $%d OR email ILIKE $%d)", len(args), len(args), len(args)))
+	}
+	if !filter.IncludeArchived {
+		conditions = append(conditions, "deleted_at IS NULL")
+	}
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY name, id"
+
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	customers := make([]models.Customer, 0)
+	for rows.Next() {
+		c, err := scanCustomer(rows)
+		if err != nil {
+			return nil, err
+		}
+		customers = append(customers, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := repo.attachStats(customers); err != nil {
+		return nil, err
+	}
+	return customers, nil
+}
+
+func (repo *CustomerRepository) GetByID(id int) (*models.Customer, error) {
+	return repo.getOne("SELECT "+customerColumns+" FROM customers WHERE id = $1", id)
+}
+
+// GetByPhone - pencarian di kasir, hanya pelanggan aktif. phone harus sudah ternormalisasi.
+func (repo *CustomerRepository) GetByPhone(phone string) (*models.Customer, error) {
+	return repo.getOne("SELECT "+customerColumns+" FROM customers WHERE phone = $1 AND deleted_at IS NULL", phone)
+}
+
+func (repo *CustomerRepository) getOne(query string, arg interface{}) (*models.Customer, error) {
+	c, err := scanCustomer(repo.db.QueryRow(query, arg))
+	if err == sql.ErrNoRows {
+		return nil, ErrCustomerNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	customers := []models.Customer{c}
+	if err := repo.attachStats(customers); err != nil {
+		return nil, err
+	}
+	return &customers[0], nil
+}
+
+// attachStats mengisi angka mentah CustomerStats dengan satu query, nilai belanja sudah dikurangi retur,
+// nilai turunan (rata-rata, frekuensi) dihitung di service
+func (repo *CustomerRepository) attachStats(customers []models.Customer) error {
+	if len(customers) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(customers))
+	index := make(map[int]int, len(customers))
+	for i := range customers {
+		ids[i] = int64(customers[i].ID)
+		index[customers[i].ID] = i
+		customers[i].Stats = &models.CustomerStats{}
+	}
+
+	rows, err := repo.db.Query(`
+		SELECT t.customer_id, COUNT(*), COALESCE(SUM(t.total_amount), 0) - COALESCE(SUM(r.amount), 0),
+			COUNT(DISTINCT t.created_at::date), MIN(t.created_at), MAX(t.created_at)
+		FROM transactions t
+		LEFT JOIN (SELECT transaction_id, SUM(amount) AS amount FROM refunds GROUP BY transaction_id) r ON r.transaction_id = t.id
+		WHERE t.customer_id = ANY($1)
+		GROUP BY t.customer_id`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var customerID int
+		var st models.CustomerStats
+		if err := rows.Scan(&customerID, &st.TransactionCount, &st.LifetimeValue, &st.Visits, &st.FirstVisit, &st.LastVisit); err != nil {
+			return err
+		}
+		*customers[index[customerID]].Stats = st
+	}
+	return rows.Err()
+}
+
+func (repo *CustomerRepository) Create(actor string, customer *models.Customer) error {
+	query := `INSERT INTO customers (name, phone, email, notes) VALUES ($1, $2, $3, $4)
+		RETURNING id, version, created_at`
+	err := queryRowAs(repo.db, actor, query, []interface{}{customer.Name, customer.Phone, customer.Email, customer.Notes},
+		&customer.ID, &customer.Version, &customer.CreatedAt)
+	if isUniqueViolation(err) {
+		return ErrPhoneExists
+	}
+	return err
+}
+
+// Update - customer.Version berisi versi yang diharapkan (0 = tanpa cek versi)
+func (repo *CustomerRepository) Update(actor string, customer *models.Customer) error {
+	query := `UPDATE customers SET name = $1, phone = $2, email = $3, notes = $4, version = version + 1
+		WHERE id = $5 AND ($6 = 0 OR version = $6)
+		RETURNING version`
+	err := queryRowAs(repo.db, actor, query, []interface{}{customer.Name, customer.Phone, customer.Email, customer.Notes, customer.ID, customer.Version},
+		&customer.Version)
+	if err == sql.ErrNoRows {
+		return repo.missingOrStale(customer.ID)
+	}
+	if isUniqueViolation(err) {
+		return ErrPhoneExists
+	}
+	return err
+}
+
+// Delete - soft delete, transaksi lama tetap terhubung ke pelanggan
+func (repo *CustomerRepository) Delete(actor string, id, version int) error {
+	query := `UPDATE customers SET deleted_at = COALESCE(deleted_at, NOW()), version = version + 1
+		WHERE id = $1 AND ($2 = 0 OR version = $2)`
+	return repo.execVersioned(actor, query, id, version)
+}
+
+// Restore gagal dengan ErrPhoneExists kalau nomornya sudah dipakai pelanggan aktif lain
+func (repo *CustomerRepository) Restore(actor string, id, version int) error {
+	query := `UPDATE customers SET deleted_at = NULL, version = version + 1
+		WHERE id = $1 AND ($2 = 0 OR version = $2)`
+	err := repo.execVersioned(actor, query, id, version)
+	if isUniqueViolation(err) {
+		return ErrPhoneExists
+	}
+	return err
+}
+
+func (repo *CustomerRepository) execVersioned(actor, query string, id, version int) error {
+	result, err := execAs(repo.db, actor, query, id, version)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return repo.missingOrStale(id)
+	}
+	return nil
+}
+
+func (repo *CustomerRepository) missingOrStale(id int) error {
+	var exists bool
+	err := repo.db.QueryRow("SELECT EXISTS (SELECT 1 FROM customers WHERE id = $1)", id).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrCustomerNotFound
+	}
+	return ErrVersionMismatch
+}
+
+// GetTransactions - riwayat belanja pelanggan, terbaru dulu
+func (repo *CustomerRepository) GetTransactions(customerID, limit, offset int) ([]models.Transaction, error) {
+	var exists bool
+	if err := repo.db.QueryRow("SELECT EXISTS (SELECT 1 FROM customers WHERE id = $1)", customerID).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrCustomerNotFound
+	}
+
+	rows, err := repo.db.Query(`SELECT `+transactionColumns+` FROM transactions
+		WHERE customer_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3`, customerID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transactions := make([]models.Transaction, 0)
+	for rows.Next() {
+		t, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := attachTransactionLines(repo.db, transactions); err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+// GetPoints - saldo dan riwayat poin pelanggan, entri terbaru dulu
+func (repo *CustomerRepository) GetPoints(customerID, limit int) (*models.PointsStatement, error) {
+	statement := &models.PointsStatement{CustomerID: customerID, Entries: make([]models.LoyaltyEntry, 0)}
+	err := repo.db.QueryRow("SELECT points_balance FROM customers WHERE id = $1", customerID).Scan(&statement.Balance)
+	if err == sql.ErrNoRows {
+		return nil, ErrCustomerNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.db.Query(`SELECT id, customer_id, points, type, remaining, expires_at, transaction_id, refund_id, note, created_at
+		FROM loyalty_ledger
+		WHERE customer_id = $1
+		ORDER BY id DESC
+		LIMIT $2`, customerID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e models.LoyaltyEntry
+		err := rows.Scan(&e.ID, &e.CustomerID, &e.Points, &e.Type, &e.Remaining, &e.ExpiresAt, &e.TransactionID, &e.RefundID, &e.Note, &e.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		statement.Entries = append(statement.Entries, e)
+	}
+	return statement, rows.Err()
+}
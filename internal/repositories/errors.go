@@ -0,0 +1,66 @@
+package repositories
+
+import "errors"
+
+// Error sentinel supaya handler bisa memetakan error ke status HTTP yang tepat
+var (
+	// ErrValidation - input tidak valid yang baru ketahuan setelah data dibaca, mis. jumlah dari barcode harga
+	ErrValidation = errors.New("validasi gagal")
+
+	ErrProductNotFound       = errors.New("produk tidak ditemukan")
+	ErrProductArchived       = errors.New("produk sudah diarsipkan, pulihkan dulu sebelum diubah")
+	ErrProductNotArchived    = errors.New("produk tidak sedang diarsipkan")
+	ErrCategoryNotFound      = errors.New("category not found")
+	ErrCategoryArchived      = errors.New("category is archived, restore it before editing")
+	ErrCategoryNotArchived   = errors.New("category is not archived")
+	ErrParentNotFound        = errors.New("parent category not found or archived")
+	ErrCategoryCycle         = errors.New("a category cannot be moved under itself or one of its subcategories")
+	ErrCategoryHasChildren   = errors.New("category still has active subcategories")
+	ErrTransactionNotFound   = errors.New("transaksi tidak ditemukan")
+	ErrVariantNotFound       = errors.New("varian produk tidak ditemukan")
+	ErrVariantArchived       = errors.New("varian produk sudah diarsipkan")
+	ErrUnitNotFound          = errors.New("satuan produk tidak ditemukan")
+	ErrUnitExists            = errors.New("satuan dengan nama tersebut sudah ada")
+	ErrPurchaseNotFound      = errors.New("penerimaan barang tidak ditemukan")
+	ErrCustomerNotFound      = errors.New("pelanggan tidak ditemukan")
+	ErrPhoneExists           = errors.New("nomor telepon sudah dipakai pelanggan lain")
+	ErrPointsRedemption      = errors.New("penukaran poin ditolak")
+	ErrRefundInvalid         = errors.New("retur tidak valid")
+	ErrStoredValueNotFound   = errors.New("kartu hadiah / store credit tidak ditemukan")
+	ErrStoredValueCodeExists = errors.New("kode kartu sudah dipakai")
+	ErrInsufficientBalance   = errors.New("saldo tidak cukup")
+	ErrPaymentMismatch       = errors.New("pembayaran tidak sesuai total")
+	ErrCartNotFound          = errors.New("keranjang tidak ditemukan")
+	ErrCartLineNotFound      = errors.New("baris keranjang tidak ditemukan")
+	ErrCartClosed            = errors.New("status keranjang tidak mengizinkan perubahan ini")
+	ErrStockUnavailable      = errors.New("stok tidak tersedia untuk ditahan")
+	ErrReservationNotFound   = errors.New("reservasi tidak ditemukan")
+	ErrReservationClosed     = errors.New("reservasi sudah tidak aktif")
+	ErrReferenceExists       = errors.New("nomor pesanan sudah punya reservasi aktif")
+	ErrTableNotFound         = errors.New("meja tidak ditemukan")
+	ErrTableExists           = errors.New("nama meja sudah dipakai")
+	ErrModifierNotFound      = errors.New("modifier produk tidak ditemukan")
+	ErrModifierExists        = errors.New("modifier dengan nama tersebut sudah ada")
+	ErrKitchenStarted        = errors.New("pesanan sudah diproses dapur")
+	ErrKitchenTransition     = errors.New("perubahan status tiket dapur tidak valid")
+	ErrOutletNotFound        = errors.New("outlet tidak ditemukan")
+	ErrOutletExists          = errors.New("kode outlet sudah dipakai")
+	ErrOutletInUse           = errors.New("outlet masih dipakai")
+	ErrOutletStock           = errors.New("perubahan stok outlet ditolak")
+	ErrTransferNotFound      = errors.New("transfer stok tidak ditemukan")
+	ErrTransferLineNotFound  = errors.New("baris transfer tidak ditemukan")
+	ErrTransferClosed        = errors.New("status transfer tidak mengizinkan perubahan ini")
+	ErrTransferInvalid       = errors.New("transfer stok tidak valid")
+	ErrWebhookNotFound       = errors.New("langganan webhook tidak ditemukan")
+	ErrDeliveryNotFound      = errors.New("pengiriman webhook tidak ditemukan")
+	ErrDeliveryPending       = errors.New("pengiriman webhook masih dalam antrean")
+	ErrImageNotFound         = errors.New("gambar produk tidak ditemukan")
+	ErrPLUExists             = errors.New("kode PLU sudah dipakai produk lain")
+	ErrSKUExists             = errors.New("SKU sudah dipakai produk lain")
+	ErrPriceScheduleNotFound = errors.New("jadwal harga tidak ditemukan atau sudah diterapkan")
+	ErrLotExpired            = errors.New("stok kedaluwarsa tidak bisa dijual")
+	ErrBatchCancelled        = errors.New("dibatalkan karena operasi lain di batch gagal")
+
+	// ErrVersionMismatch dikembalikan kalau versi baris sudah berubah sejak dibaca client
+	ErrVersionMismatch = errors.New("data sudah diubah oleh pengguna lain, silakan muat ulang")
+)
@@ -0,0 +1,48 @@
+package repositories
+
+import (
+	"database/sql"
+	"kasir-api/internal/models"
+)
+
+type InventoryRepository struct {
+	db *sql.DB
+}
+
+func NewInventoryRepository(db *sql.DB) *InventoryRepository {
+	return &InventoryRepository{db: db}
+}
+
+// GetExpiring - lot yang masih bersisa dan kedaluwarsa dalam days hari ke depan,
+// termasuk yang sudah lewat tanggal, urut dari yang paling dulu kedaluwarsa. outletID nil berarti semua outlet.
+func (repo *InventoryRepository) GetExpiring(days int, outletID *int) ([]models.ExpiringLot, error) {
+	rows, err := repo.db.Query(`
+		SELECT l.id, l.outlet_id, o.name, l.product_id, l.variant_id, l.lot_number, TO_CHAR(l.expires_at, 'YYYY-MM-DD'),
+			l.quantity, l.received_quantity, l.receipt_id, l.created_at,
+			p.name, COALESCE(v.name, ''), p.base_unit, l.expires_at - CURRENT_DATE
+		FROM stock_lots l
+		JOIN outlets o ON l.outlet_id = o.id
+		JOIN products p ON l.product_id = p.id
+		LEFT JOIN product_variants v ON l.variant_id = v.id
+		WHERE l.quantity > 0 AND l.expires_at <= CURRENT_DATE + $1::int AND p.deleted_at IS NULL
+			AND ($2::int IS NULL OR l.outlet_id = $2)
+		ORDER BY l.expires_at, l.id`, days, outletID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lots := make([]models.ExpiringLot, 0)
+	for rows.Next() {
+		var l models.ExpiringLot
+		err := rows.Scan(&l.ID, &l.OutletID, &l.OutletName, &l.ProductID, &l.VariantID, &l.LotNumber, &l.ExpiresAt,
+			&l.Quantity, &l.ReceivedQuantity, &l.ReceiptID, &l.CreatedAt,
+			&l.ProductName, &l.VariantName, &l.BaseUnit, &l.DaysLeft)
+		if err != nil {
+			return nil, err
+		}
+		l.Expired = l.DaysLeft < 0
+		lots = append(lots, l)
+	}
+	return lots, rows.Err()
+}
@@ -0,0 +1,161 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"kasir-api/internal/models"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+type KitchenRepository struct {
+	db *sql.DB
+}
+
+func NewKitchenRepository(db *sql.DB) *KitchenRepository {
+	return &KitchenRepository{db: db}
+}
+
+const ticketColumns = `l.id, l.cart_id, c.label, c.table_id, COALESCE(t.name, ''), l.kitchen_station, l.kitchen_status,
+	l.product_id, p.name, COALESCE(v.name, ''), l.unit, l.quantity, l.note, l.created_at, l.kitchen_updated_at`
+
+const ticketFrom = `
+	FROM cart_lines l
+	JOIN carts c ON c.id = l.cart_id
+	JOIN products p ON p.id = l.product_id
+	LEFT JOIN product_variants v ON v.id = l.variant_id
+	LEFT JOIN dining_tables t ON t.id = c.table_id`
+
+func scanTicket(row rowScanner) (models.KitchenTicket, error) {
+	var k models.KitchenTicket
+	err := row.Scan(&k.LineID, &k.CartID, &k.CartLabel, &k.TableID, &k.TableName, &k.Station, &k.Status,
+		&k.ProductID, &k.ProductName, &k.VariantName, &k.Unit, &k.Quantity, &k.Note, &k.CreatedAt, &k.UpdatedAt)
+	return k, err
+}
+
+// GetTickets - antrean dapur urut dari pesanan terlama. Pesanan yang sudah dibayar tetap tampil
+// sampai disajikan, pesanan yang dibatalkan tidak.
+func (repo *KitchenRepository) GetTickets(filter models.KitchenFilter) ([]models.KitchenTicket, error) {
+	statuses := filter.Statuses
+	if len(statuses) == 0 {
+		statuses = []string{models.KitchenQueued, models.KitchenCooking, models.KitchenReady}
+	}
+
+	conditions := []string{"l.kitchen_status = ANY($1)", fmt.Sprintf("c.status <> '%s'", models.CartCancelled)}
+	args := []interface{}{pq.Array(statuses)}
+	if filter.Station != "" {
+		args = append(args, filter.Station)
+		conditions = append(conditions, fmt.Sprintf("l.kitchen_station = $%d", len(args)))
+	}
+
+	rows, err := repo.db.Query("SELECT "+ticketColumns+ticketFrom+" WHERE "+strings.Join(conditions, " AND ")+" ORDER BY l.created_at, l.id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tickets := make([]models.KitchenTicket, 0)
+	for rows.Next() {
+		k, err := scanTicket(rows)
+		if err != nil {
+			return nil, err
+		}
+		tickets = append(tickets, k)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := repo.attachModifiers(tickets); err != nil {
+		return nil, err
+	}
+	return tickets, nil
+}
+
+func (repo *KitchenRepository) GetTicket(lineID int) (*models.KitchenTicket, error) {
+	k, err := scanTicket(repo.db.QueryRow("SELECT "+ticketColumns+ticketFrom+" WHERE l.id = $1 AND l.kitchen_status IS NOT NULL", lineID))
+	if err == sql.ErrNoRows {
+		return nil, ErrCartLineNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tickets := []models.KitchenTicket{k}
+	if err := repo.attachModifiers(tickets); err != nil {
+		return nil, err
+	}
+	return &tickets[0], nil
+}
+
+func (repo *KitchenRepository) attachModifiers(tickets []models.KitchenTicket) error {
+	if len(tickets) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(tickets))
+	index := make(map[int]int, len(tickets))
+	for i := range tickets {
+		ids[i] = int64(tickets[i].LineID)
+		index[tickets[i].LineID] = i
+		tickets[i].Modifiers = make([]models.LineModifier, 0)
+	}
+
+	rows, err := repo.db.Query(`
+		SELECT lm.cart_line_id, m.id, m.name, m.price_delta
+		FROM cart_line_modifiers lm
+		JOIN product_modifiers m ON m.id = lm.modifier_id
+		WHERE lm.cart_line_id = ANY($1)
+		ORDER BY m.id`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var lineID, modifierID int
+		var m models.LineModifier
+		if err := rows.Scan(&lineID, &modifierID, &m.Name, &m.PriceDelta); err != nil {
+			return err
+		}
+		m.ModifierID = &modifierID
+		i := index[lineID]
+		tickets[i].Modifiers = append(tickets[i].Modifiers, m)
+	}
+	return rows.Err()
+}
+
+// SetStatus memajukan status tiket dapur. Status hanya boleh maju (boleh melompat,
+// mis. minuman dingin langsung ready), tiket dari pesanan yang dibatalkan tidak bisa diubah.
+func (repo *KitchenRepository) SetStatus(lineID int, status string) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var current *string
+	var cartStatus string
+	err = tx.QueryRow(`SELECT l.kitchen_status, c.status FROM cart_lines l JOIN carts c ON c.id = l.cart_id
+		WHERE l.id = $1 FOR UPDATE OF l`, lineID).Scan(&current, &cartStatus)
+	if err == sql.ErrNoRows || (err == nil && current == nil) {
+		return ErrCartLineNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if cartStatus == models.CartCancelled {
+		return fmt.Errorf("%w: pesanan sudah dibatalkan", ErrKitchenTransition)
+	}
+	if models.KitchenStatusOrder[status] <= models.KitchenStatusOrder[*current] {
+		return fmt.Errorf("%w: dari %s ke %s", ErrKitchenTransition, *current, status)
+	}
+
+	_, err = tx.Exec("UPDATE cart_lines SET kitchen_status = $1, kitchen_updated_at = NOW() WHERE id = $2", status, lineID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,180 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"kasir-api/internal/models"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const loyaltySettingsQuery = "SELECT rupiah_per_point, point_value, min_redeem_points, expiry_days, version FROM loyalty_settings WHERE id = 1"
+
+func getLoyaltySettings(tx *sql.Tx) (models.LoyaltySettings, error) {
+	var s models.LoyaltySettings
+	err := tx.QueryRow(loyaltySettingsQuery).
+		Scan(&s.RupiahPerPoint, &s.PointValue, &s.MinRedeemPoints, &s.ExpiryDays, &s.Version)
+	return s, err
+}
+
+// lockCustomer mengunci baris pelanggan aktif (saldo poin ikut terkunci) dan mengembalikan saldonya
+func lockCustomer(tx *sql.Tx, customerID int) (int, error) {
+	var balance int
+	var active bool
+	err := tx.QueryRow("SELECT points_balance, deleted_at IS NULL FROM customers WHERE id = $1 FOR UPDATE", customerID).Scan(&balance, &active)
+	if err == sql.ErrNoRows || (err == nil && !active) {
+		return 0, ErrCustomerNotFound
+	}
+	return balance, err
+}
+
+// earnRates mengembalikan tarif rupiah per poin untuk tiap kategori: tarif kategori itu sendiri
+// atau leluhur terdekat yang punya tarif. Kategori tanpa tarif tidak ada di map.
+func earnRates(tx *sql.Tx, categoryIDs []int64) (map[int]int, error) {
+	rates := make(map[int]int)
+	if len(categoryIDs) == 0 {
+		return rates, nil
+	}
+
+	rows, err := tx.Query(`
+		WITH RECURSIVE up AS (
+			SELECT id AS origin, id, parent_id, 0 AS depth FROM categories WHERE id = ANY($1)
+			UNION ALL
+			SELECT up.origin, c.id, c.parent_id, up.depth + 1 FROM categories c JOIN up ON c.id = up.parent_id
+		)
+		SELECT DISTINCT ON (up.origin) up.origin, r.rupiah_per_point
+		FROM up JOIN loyalty_category_rates r ON r.category_id = up.id
+		ORDER BY up.origin, up.depth`, pq.Array(categoryIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var categoryID, rate int
+		if err := rows.Scan(&categoryID, &rate); err != nil {
+			return nil, err
+		}
+		rates[categoryID] = rate
+	}
+	return rates, rows.Err()
+}
+
+// pointsExpiry - tanggal kedaluwarsa untuk poin yang ditambahkan sekarang
+func pointsExpiry(settings models.LoyaltySettings) *time.Time {
+	if settings.ExpiryDays == 0 {
+		return nil
+	}
+	t := time.Now().AddDate(0, 0, settings.ExpiryDays)
+	return &t
+}
+
+// addPoints mencatat entri poin bertambah (earn/return) dan menaikkan saldo pelanggan
+func addPoints(tx *sql.Tx, entry models.LoyaltyEntry) error {
+	if entry.Points <= 0 {
+		return nil
+	}
+	entry.Remaining = entry.Points
+	if err := insertLedgerEntry(tx, entry); err != nil {
+		return err
+	}
+	_, err := tx.Exec("UPDATE customers SET points_balance = points_balance + $1 WHERE id = $2", entry.Points, entry.CustomerID)
+	return err
+}
+
+// deductPoints memakai sisa poin urut dari yang paling cepat kedaluwarsa, mencatat entri negatif
+// dan menurunkan saldo. Kalau sisa poin tidak cukup (mis. poin dari retur sudah terpakai),
+// saldo boleh minus; pemanggil yang memastikan saldo cukup untuk penukaran.
+func deductPoints(tx *sql.Tx, entry models.LoyaltyEntry) error {
+	if entry.Points <= 0 {
+		return nil
+	}
+
+	rows, err := tx.Query(`SELECT id, remaining FROM loyalty_ledger
+		WHERE customer_id = $1 AND remaining > 0
+		ORDER BY expires_at NULLS LAST, id
+		FOR UPDATE`, entry.CustomerID)
+	if err != nil {
+		return err
+	}
+	type openEntry struct{ id, remaining int }
+	var open []openEntry
+	for rows.Next() {
+		var e openEntry
+		if err := rows.Scan(&e.id, &e.remaining); err != nil {
+			rows.Close()
+			return err
+		}
+		open = append(open, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	needed := entry.Points
+	for _, e := range open {
+		if needed == 0 {
+			break
+		}
+		take := min(e.remaining, needed)
+		if _, err := tx.Exec("UPDATE loyalty_ledger SET remaining = remaining - $1 WHERE id = $2", take, e.id); err != nil {
+			return err
+		}
+		needed -= take
+	}
+
+	points := entry.Points
+	entry.Points = -points
+	entry.Remaining = 0
+	entry.ExpiresAt = nil
+	if err := insertLedgerEntry(tx, entry); err != nil {
+		return err
+	}
+	_, err = tx.Exec("UPDATE customers SET points_balance = points_balance - $1 WHERE id = $2", points, entry.CustomerID)
+	return err
+}
+
+func insertLedgerEntry(tx *sql.Tx, e models.LoyaltyEntry) error {
+	_, err := tx.Exec(`INSERT INTO loyalty_ledger (customer_id, points, type, remaining, expires_at, transaction_id, refund_id, note)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`, e.CustomerID, e.Points, e.Type, e.Remaining, e.ExpiresAt, e.TransactionID, e.RefundID, e.Note)
+	return err
+}
+
+// allocate membagi total secara proporsional terhadap weights, sisa pembulatan masuk ke bobot terakhir
+// yang tidak nol supaya jumlahnya selalu tepat total
+func allocate(total int, weights []int) []int {
+	shares := make([]int, len(weights))
+	sum := 0
+	for _, w := range weights {
+		sum += w
+	}
+	if sum == 0 || total == 0 {
+		return shares
+	}
+
+	allocated, last := 0, -1
+	for i, w := range weights {
+		if w == 0 {
+			continue
+		}
+		shares[i] = total * w / sum
+		allocated += shares[i]
+		last = i
+	}
+	shares[last] += total - allocated
+	return shares
+}
+
+// proportion - round(total * part / whole) dengan aritmetika integer
+func proportion(total, part, whole int) int {
+	if whole == 0 {
+		return 0
+	}
+	return (2*total*part + whole) / (2 * whole)
+}
+
+func redeemError(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: "+format, append([]interface{}{ErrPointsRedemption}, args...)...)
+}
@@ -0,0 +1,170 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"kasir-api/internal/models"
+)
+
+type LoyaltyRepository struct {
+	db *sql.DB
+}
+
+func NewLoyaltyRepository(db *sql.DB) *LoyaltyRepository {
+	return &LoyaltyRepository{db: db}
+}
+
+func (repo *LoyaltyRepository) GetSettings() (*models.LoyaltySettings, error) {
+	var s models.LoyaltySettings
+	err := repo.db.QueryRow(loyaltySettingsQuery).
+		Scan(&s.RupiahPerPoint, &s.PointValue, &s.MinRedeemPoints, &s.ExpiryDays, &s.Version)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// UpdateSettings - settings.Version berisi versi yang diharapkan (0 = tanpa cek versi).
+// Perubahan masa berlaku hanya berlaku untuk poin yang didapat setelahnya.
+func (repo *LoyaltyRepository) UpdateSettings(actor string, settings *models.LoyaltySettings) error {
+	query := `UPDATE loyalty_settings SET rupiah_per_point = $1, point_value = $2, min_redeem_points = $3, expiry_days = $4, version = version + 1
+		WHERE id = 1 AND ($5 = 0 OR version = $5)
+		RETURNING version`
+	err := queryRowAs(repo.db, actor, query, []interface{}{settings.RupiahPerPoint, settings.PointValue, settings.MinRedeemPoints, settings.ExpiryDays, settings.Version},
+		&settings.Version)
+	if err == sql.ErrNoRows {
+		return ErrVersionMismatch
+	}
+	return err
+}
+
+func (repo *LoyaltyRepository) GetCategoryRates() ([]models.LoyaltyCategoryRate, error) {
+	rows, err := repo.db.Query(`SELECT r.category_id, c.name, r.rupiah_per_point
+		FROM loyalty_category_rates r
+		JOIN categories c ON c.id = r.category_id
+		ORDER BY c.name, r.category_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rates := make([]models.LoyaltyCategoryRate, 0)
+	for rows.Next() {
+		var r models.LoyaltyCategoryRate
+		if err := rows.Scan(&r.CategoryID, &r.CategoryName, &r.RupiahPerPoint); err != nil {
+			return nil, err
+		}
+		rates = append(rates, r)
+	}
+	return rates, rows.Err()
+}
+
+// SetCategoryRate - buat atau ganti tarif kategori
+func (repo *LoyaltyRepository) SetCategoryRate(actor string, rate *models.LoyaltyCategoryRate) error {
+	err := queryRowAs(repo.db, actor, `INSERT INTO loyalty_category_rates (category_id, rupiah_per_point)
+		SELECT id, $2::int FROM categories WHERE id = $1 AND deleted_at IS NULL
+		ON CONFLICT (category_id) DO UPDATE SET rupiah_per_point = EXCLUDED.rupiah_per_point
+		RETURNING (SELECT name FROM categories WHERE id = $1)`, []interface{}{rate.CategoryID, rate.RupiahPerPoint}, &rate.CategoryName)
+	if err == sql.ErrNoRows {
+		return ErrCategoryNotFound
+	}
+	return err
+}
+
+func (repo *LoyaltyRepository) DeleteCategoryRate(actor string, categoryID int) error {
+	result, err := execAs(repo.db, actor, "DELETE FROM loyalty_category_rates WHERE category_id = $1", categoryID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrCategoryNotFound
+	}
+	return nil
+}
+
+// ExpirePoints menghanguskan sisa poin yang sudah lewat masa berlaku. Tiap pelanggan diproses
+// dalam transaksi DB sendiri dan baris pelanggannya dikunci dulu, urutan kunci sama dengan checkout.
+func (repo *LoyaltyRepository) ExpirePoints() (int, error) {
+	rows, err := repo.db.Query("SELECT DISTINCT customer_id FROM loyalty_ledger WHERE remaining > 0 AND expires_at <= NOW()")
+	if err != nil {
+		return 0, err
+	}
+	var customerIDs []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		customerIDs = append(customerIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, customerID := range customerIDs {
+		expired, err := repo.expireCustomerPoints(customerID)
+		if err != nil {
+			return total, err
+		}
+		total += expired
+	}
+	return total, nil
+}
+
+func (repo *LoyaltyRepository) expireCustomerPoints(customerID int) (int, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("SELECT 1 FROM customers WHERE id = $1 FOR UPDATE", customerID); err != nil {
+		return 0, err
+	}
+
+	rows, err := tx.Query(`SELECT id, remaining FROM loyalty_ledger
+		WHERE customer_id = $1 AND remaining > 0 AND expires_at <= NOW()
+		ORDER BY id
+		FOR UPDATE`, customerID)
+	if err != nil {
+		return 0, err
+	}
+	type dueEntry struct{ id, remaining int }
+	var due []dueEntry
+	for rows.Next() {
+		var e dueEntry
+		if err := rows.Scan(&e.id, &e.remaining); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		due = append(due, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, e := range due {
+		if _, err := tx.Exec("UPDATE loyalty_ledger SET remaining = 0 WHERE id = $1", e.id); err != nil {
+			return 0, err
+		}
+		entry := models.LoyaltyEntry{CustomerID: customerID, Points: -e.remaining, Type: models.PointsExpire, Note: fmt.Sprintf("sisa poin entri #%d", e.id)}
+		if err := insertLedgerEntry(tx, entry); err != nil {
+			return 0, err
+		}
+		expired += e.remaining
+	}
+	if _, err := tx.Exec("UPDATE customers SET points_balance = points_balance - $1 WHERE id = $2", expired, customerID); err != nil {
+		return 0, err
+	}
+
+	return expired, tx.Commit()
+}
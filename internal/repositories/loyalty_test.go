@@ -0,0 +1,50 @@
+package repositories
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		name    string
+		total   int
+		weights []int
+		want    []int
+	}{
+		{"even split remainder to last", 100, []int{1, 1, 1}, []int{33, 33, 34}},
+		{"proportional", 7, []int{2, 3, 5}, []int{1, 2, 4}},
+		{"remainder skips zero weight", 10, []int{3, 0}, []int{10, 0}},
+		{"single non-zero weight", 10, []int{0, 2, 0}, []int{0, 10, 0}},
+		{"zero weights", 10, []int{0, 0}, []int{0, 0}},
+		{"zero total", 0, []int{1, 2}, []int{0, 0}},
+		{"negative total", -10, []int{1, 2}, []int{-3, -7}},
+		{"no weights", 10, nil, []int{}},
+	}
+	for _, tt := range tests {
+		got := allocate(tt.total, tt.weights)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: allocate(%d, %v) = %v, want %v", tt.name, tt.total, tt.weights, got, tt.want)
+		}
+	}
+}
+
+func TestProportion(t *testing.T) {
+	tests := []struct {
+		total, part, whole int
+		want               int
+	}{
+		{100, 1, 3, 33},
+		{100, 2, 3, 67},
+		// setengah dibulatkan ke atas
+		{5, 1, 2, 3},
+		{10000, 1500, 1500, 10000},
+		{10000, 0, 1500, 0},
+		{10000, 1, 0, 0},
+	}
+	for _, tt := range tests {
+		if got := proportion(tt.total, tt.part, tt.whole); got != tt.want {
+			t.Errorf("proportion(%d, %d, %d) = %d, want %d", tt.total, tt.part, tt.whole, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"kasir-api/internal/models"
+	"sort"
+)
+
+// insertOutboxEvent menulis event ke outbox di transaksi DB pemanggil. Event baru terlihat oleh
+// dispatcher webhook setelah transaksi commit, dan ikut batal kalau transaksi di-rollback.
+func insertOutboxEvent(tx *sql.Tx, eventType string, payload interface{}) error {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("INSERT INTO outbox_events (event_type, payload) VALUES ($1, $2)", eventType, raw)
+	return err
+}
+
+// insertStockLowEvents menulis product.stock_low untuk setiap produk/varian yang stok outletnya
+// baru saja turun dari >= threshold ke bawah threshold oleh pergerakan penjualan ini.
+// Produk yang memang sudah di bawah threshold tidak dikirim ulang di setiap penjualan.
+func insertStockLowEvents(tx *sql.Tx, threshold models.Quantity, transactionID int, movements []stockMovement) error {
+	if threshold <= 0 {
+		return nil
+	}
+
+	type stockKey struct{ outletID, productID, variantID int }
+	sold := map[stockKey]models.Quantity{}
+	variants := map[stockKey]*int{}
+	for _, m := range movements {
+		key := stockKey{outletID: m.outletID, productID: m.productID}
+		if m.variantID != nil {
+			key.variantID = *m.variantID
+		}
+		sold[key] -= m.quantity
+		variants[key] = m.variantID
+	}
+
+	keys := make([]stockKey, 0, len(sold))
+	for key := range sold {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].productID != keys[j].productID {
+			return keys[i].productID < keys[j].productID
+		}
+		if keys[i].variantID != keys[j].variantID {
+			return keys[i].variantID < keys[j].variantID
+		}
+		return keys[i].outletID < keys[j].outletID
+	})
+
+	for _, key := range keys {
+		stock, err := outletStock(tx, key.outletID, key.productID, variants[key])
+		if err != nil {
+			return err
+		}
+		before := stock + sold[key]
+		if stock >= threshold || before < threshold {
+			continue
+		}
+
+		event := models.StockLowEvent{
+			ProductID:     key.productID,
+			VariantID:     variants[key],
+			OutletID:      key.outletID,
+			Stock:         stock,
+			Threshold:     threshold,
+			TransactionID: transactionID,
+		}
+		err = tx.QueryRow("SELECT p.name, v.name FROM products p LEFT JOIN product_variants v ON v.id = $2 WHERE p.id = $1",
+			key.productID, variants[key]).Scan(&event.ProductName, &event.VariantName)
+		if err != nil {
+			return err
+		}
+		if err := insertOutboxEvent(tx, models.EventProductStockLow, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
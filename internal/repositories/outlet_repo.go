@@ -0,0 +1,314 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"kasir-api/internal/models"
+)
+
+type OutletRepository struct {
+	db *sql.DB
+}
+
+func NewOutletRepository(db *sql.DB) *OutletRepository {
+	return &OutletRepository{db: db}
+}
+
+// outletColumns harus sama urutannya dengan scanOutlet
+const outletColumns = "id, code, name, address, kind, is_default, version, deleted_at, created_at"
+
+func scanOutlet(row rowScanner) (models.Outlet, error) {
+	var o models.Outlet
+	err := row.Scan(&o.ID, &o.Code, &o.Name, &o.Address, &o.Kind, &o.IsDefault, &o.Version, &o.DeletedAt, &o.CreatedAt)
+	return o, err
+}
+
+func (repo *OutletRepository) GetAll(includeArchived bool) ([]models.Outlet, error) {
+	query := "SELECT " + outletColumns + " FROM outlets"
+	if !includeArchived {
+		query += " WHERE deleted_at IS NULL"
+	}
+	query += " ORDER BY is_default DESC, code, id"
+
+	rows, err := repo.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	outlets := make([]models.Outlet, 0)
+	for rows.Next() {
+		o, err := scanOutlet(rows)
+		if err != nil {
+			return nil, err
+		}
+		outlets = append(outlets, o)
+	}
+	return outlets, rows.Err()
+}
+
+func (repo *OutletRepository) GetByID(id int) (*models.Outlet, error) {
+	o, err := scanOutlet(repo.db.QueryRow("SELECT "+outletColumns+" FROM outlets WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, ErrOutletNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// Create - kalau outlet baru dijadikan default, outlet default sebelumnya dilepas dalam tx yang sama
+func (repo *OutletRepository) Create(actor string, outlet *models.Outlet) error {
+	tx, err := beginTx(repo.db, actor)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if outlet.IsDefault {
+		if _, err := tx.Exec("UPDATE outlets SET is_default = FALSE, version = version + 1 WHERE is_default"); err != nil {
+			return err
+		}
+	}
+	err = tx.QueryRow("INSERT INTO outlets (code, name, address, kind, is_default) VALUES ($1, $2, $3, $4, $5) RETURNING id, version, created_at",
+		outlet.Code, outlet.Name, outlet.Address, outlet.Kind, outlet.IsDefault).Scan(&outlet.ID, &outlet.Version, &outlet.CreatedAt)
+	if isUniqueViolation(err) {
+		return ErrOutletExists
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Update - outlet.Version berisi versi yang diharapkan (0 = tanpa cek versi).
+// Status default hanya bisa dipindah ke outlet lain, tidak bisa dilepas begitu saja.
+func (repo *OutletRepository) Update(actor string, outlet *models.Outlet) error {
+	tx, err := beginTx(repo.db, actor)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var isDefault, archived bool
+	err = tx.QueryRow("SELECT is_default, deleted_at IS NOT NULL FROM outlets WHERE id = $1 FOR UPDATE", outlet.ID).Scan(&isDefault, &archived)
+	if err == sql.ErrNoRows {
+		return ErrOutletNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if isDefault && !outlet.IsDefault {
+		return fmt.Errorf("%w: jadikan outlet lain sebagai default untuk memindahkan status default", ErrOutletInUse)
+	}
+	if archived && outlet.IsDefault {
+		return fmt.Errorf("%w: outlet yang diarsipkan tidak bisa dijadikan default", ErrOutletInUse)
+	}
+	if outlet.IsDefault && !isDefault {
+		if _, err := tx.Exec("UPDATE outlets SET is_default = FALSE, version = version + 1 WHERE is_default"); err != nil {
+			return err
+		}
+	}
+
+	query := `UPDATE outlets SET code = $1, name = $2, address = $3, kind = $4, is_default = $5, version = version + 1
+		WHERE id = $6 AND ($7 = 0 OR version = $7)
+		RETURNING version`
+	err = tx.QueryRow(query, outlet.Code, outlet.Name, outlet.Address, outlet.Kind, outlet.IsDefault, outlet.ID, outlet.Version).Scan(&outlet.Version)
+	if err == sql.ErrNoRows {
+		return ErrVersionMismatch
+	}
+	if isUniqueViolation(err) {
+		return ErrOutletExists
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Delete - soft delete, transaksi lama tetap terhubung ke outlet.
+// Outlet default, outlet yang masih punya stok, dan outlet dengan keranjang atau reservasi berjalan tidak bisa diarsipkan.
+func (repo *OutletRepository) Delete(actor string, id, version int) error {
+	tx, err := beginTx(repo.db, actor)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var isDefault bool
+	var currentVersion int
+	err = tx.QueryRow("SELECT is_default, version FROM outlets WHERE id = $1 FOR UPDATE", id).Scan(&isDefault, &currentVersion)
+	if err == sql.ErrNoRows {
+		return ErrOutletNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if version != 0 && version != currentVersion {
+		return ErrVersionMismatch
+	}
+	if isDefault {
+		return fmt.Errorf("%w: outlet default tidak bisa diarsipkan", ErrOutletInUse)
+	}
+
+	var hasStock, hasOpenDocuments bool
+	err = tx.QueryRow(`SELECT
+		EXISTS (SELECT 1 FROM outlet_stock WHERE outlet_id = $1 AND quantity <> 0),
+		EXISTS (SELECT 1 FROM carts WHERE outlet_id = $1 AND status IN ('open', 'held'))
+			OR EXISTS (SELECT 1 FROM reservations WHERE outlet_id = $1 AND status = 'active')
+			OR EXISTS (SELECT 1 FROM stock_transfers WHERE $1 IN (source_outlet_id, destination_outlet_id) AND status IN ('draft', 'in_transit'))`, id).
+		Scan(&hasStock, &hasOpenDocuments)
+	if err != nil {
+		return err
+	}
+	if hasStock {
+		return fmt.Errorf("%w: outlet masih punya stok", ErrOutletInUse)
+	}
+	if hasOpenDocuments {
+		return fmt.Errorf("%w: outlet masih punya keranjang, reservasi atau transfer stok yang berjalan", ErrOutletInUse)
+	}
+
+	if _, err := tx.Exec("UPDATE outlets SET deleted_at = COALESCE(deleted_at, NOW()), version = version + 1 WHERE id = $1", id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetStock - stok semua produk/varian di satu outlet, termasuk yang sedang ditahan reservasi.
+// Produk/varian yang belum pernah punya stok di outlet ini tidak ikut ditampilkan.
+func (repo *OutletRepository) GetStock(outletID int, productID *int) ([]models.OutletStock, error) {
+	if _, err := repo.GetByID(outletID); err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.db.Query(`
+		SELECT s.outlet_id, s.product_id, p.name, s.variant_id, COALESCE(v.name, ''), p.base_unit, s.quantity,
+			COALESCE((SELECT SUM(r.quantity) FROM stock_reservations r
+				WHERE r.outlet_id = s.outlet_id AND r.product_id = s.product_id AND r.variant_id IS NOT DISTINCT FROM s.variant_id
+				AND `+activeReservation+`), 0)
+		FROM outlet_stock s
+		JOIN products p ON p.id = s.product_id
+		LEFT JOIN product_variants v ON v.id = s.variant_id
+		WHERE s.outlet_id = $1 AND ($2::int IS NULL OR s.product_id = $2) AND p.deleted_at IS NULL AND v.deleted_at IS NULL
+		ORDER BY p.name, s.product_id, v.name NULLS FIRST, s.variant_id`, outletID, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stock := make([]models.OutletStock, 0)
+	for rows.Next() {
+		var s models.OutletStock
+		err := rows.Scan(&s.OutletID, &s.ProductID, &s.ProductName, &s.VariantID, &s.VariantName, &s.BaseUnit, &s.Quantity, &s.Reserved)
+		if err != nil {
+			return nil, err
+		}
+		s.Available = s.Quantity - s.Reserved
+		stock = append(stock, s)
+	}
+	return stock, rows.Err()
+}
+
+// AdjustStock mengganti stok satu produk/varian di outlet dengan hasil hitung fisik.
+// Selisihnya dicatat sebagai pergerakan stok 'adjustment' dan ikut mengubah total stok produk.
+func (repo *OutletRepository) AdjustStock(actor string, outletID int, adj models.StockAdjustment) error {
+	tx, err := beginTx(repo.db, actor)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := requireOutlet(tx, outletID); err != nil {
+		return err
+	}
+
+	var name string
+	var isBundle bool
+	err = tx.QueryRow("SELECT name, is_bundle FROM products WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", adj.ProductID).Scan(&name, &isBundle)
+	if err == sql.ErrNoRows {
+		return ErrProductNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if isBundle {
+		return fmt.Errorf("%w: stok paket '%s' mengikuti stok komponennya", ErrOutletStock, name)
+	}
+	if adj.VariantID != nil {
+		var variantID int
+		err := tx.QueryRow("SELECT id FROM product_variants WHERE id = $1 AND product_id = $2 AND deleted_at IS NULL FOR UPDATE", *adj.VariantID, adj.ProductID).Scan(&variantID)
+		if err == sql.ErrNoRows {
+			return ErrVariantNotFound
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	current, err := outletStock(tx, outletID, adj.ProductID, adj.VariantID)
+	if err != nil {
+		return err
+	}
+	delta := adj.Quantity - current
+	if delta == 0 {
+		return tx.Commit()
+	}
+	if err := addStock(tx, outletID, adj.ProductID, adj.VariantID, delta); err != nil {
+		return err
+	}
+	movements := []stockMovement{{outletID: outletID, productID: adj.ProductID, variantID: adj.VariantID, quantity: delta, reason: movementAdjustment}}
+	if err := insertStockMovements(tx, "adjustment", nil, movements); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// requireOutlet memastikan outlet ada dan belum diarsipkan
+func requireOutlet(tx *sql.Tx, id int) error {
+	var exists bool
+	err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM outlets WHERE id = $1 AND deleted_at IS NULL)", id).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrOutletNotFound
+	}
+	return nil
+}
+
+// resolveOutlet - outlet dokumen baru: id yang diminta (harus aktif) atau outlet default kalau nil
+func resolveOutlet(tx *sql.Tx, id *int) (int, error) {
+	outletID, _, err := resolveOutletKind(tx, id)
+	return outletID, err
+}
+
+// resolveSalesOutlet - seperti resolveOutlet, tapi gudang ditolak karena tidak melayani penjualan
+func resolveSalesOutlet(tx *sql.Tx, id *int) (int, error) {
+	outletID, kind, err := resolveOutletKind(tx, id)
+	if err != nil {
+		return 0, err
+	}
+	if kind == models.OutletWarehouse {
+		return 0, fmt.Errorf("%w: outlet %d adalah gudang dan tidak melayani penjualan", ErrOutletInUse, outletID)
+	}
+	return outletID, nil
+}
+
+func resolveOutletKind(tx *sql.Tx, id *int) (int, string, error) {
+	var outletID int
+	var kind string
+	var err error
+	if id != nil {
+		err = tx.QueryRow("SELECT id, kind FROM outlets WHERE id = $1 AND deleted_at IS NULL", *id).Scan(&outletID, &kind)
+		if err == sql.ErrNoRows {
+			return 0, "", ErrOutletNotFound
+		}
+	} else {
+		err = tx.QueryRow("SELECT id, kind FROM outlets WHERE is_default").Scan(&outletID, &kind)
+		if err == sql.ErrNoRows {
+			return 0, "", fmt.Errorf("%w: belum ada outlet default", ErrOutletNotFound)
+		}
+	}
+	return outletID, kind, err
+}
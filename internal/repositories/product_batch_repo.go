@@ -0,0 +1,334 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"kasir-api/internal/models"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// batchUpdateColumns - kolom yang diganti oleh operasi create/update, urutan sama dengan batchValues
+var batchUpdateColumns = []string{"sku", "name", "price", "stock", "base_unit", "cost", "quantity_precision", "plu", "is_bundle", "category_id", "kitchen_station"}
+
+func batchValues(p *models.Product) []interface{} {
+	return []interface{}{p.SKU, p.Name, p.Price, p.Stock, p.BaseUnit, p.Cost, p.QuantityPrecision, p.PLU, p.IsBundle, p.CategoryID, p.KitchenStation}
+}
+
+// Batch menjalankan semua operasi dalam satu transaksi DB, masing-masing jenis operasi
+// dengan satu statement multi-row. results[i] milik ops[i]; kalau ada yang gagal,
+// semua dibatalkan dan operasi yang sebenarnya valid diberi ErrBatchCancelled.
+// Operasi yang sudah ditandai gagal di results (validasi service) tidak dijalankan.
+func (repo *ProductRepository) Batch(actor string, ops []models.ProductBatchOperation, results []models.ProductBatchResult) (bool, error) {
+	tx, err := beginTx(repo.db, actor)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// 1. Kunci produk yang diubah/dihapus, cek keberadaan dan versinya
+	var ids []int64
+	for _, op := range ops {
+		if op.Op != models.BatchCreate {
+			ids = append(ids, int64(op.ID))
+		}
+	}
+	type lockedProduct struct {
+		version, price int
+		stock          models.Quantity
+		archived       bool
+	}
+	locked := make(map[int]lockedProduct, len(ids))
+	if len(ids) > 0 {
+		rows, err := tx.Query("SELECT id, version, price, stock, deleted_at IS NOT NULL FROM products WHERE id = ANY($1) ORDER BY id FOR UPDATE", pq.Array(ids))
+		if err != nil {
+			return false, err
+		}
+		for rows.Next() {
+			var id int
+			var p lockedProduct
+			if err := rows.Scan(&id, &p.version, &p.price, &p.stock, &p.archived); err != nil {
+				rows.Close()
+				return false, err
+			}
+			locked[id] = p
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return false, err
+		}
+	}
+	for i, op := range ops {
+		if op.Op == models.BatchCreate || results[i].Err != nil {
+			continue
+		}
+		p, ok := locked[op.ID]
+		if !ok {
+			results[i].Err = ErrProductNotFound
+		} else if p.version != op.Version {
+			results[i].Err = ErrVersionMismatch
+		} else if p.archived && op.Op == models.BatchUpdate {
+			results[i].Err = ErrProductArchived
+		}
+	}
+
+	// 2. SKU/PLU unik dan kategori harus ada, dicek sekaligus supaya error bisa ditunjuk per operasi
+	if err := checkBatchReferences(tx, ops, results); err != nil {
+		return false, err
+	}
+
+	for _, r := range results {
+		if r.Err != nil {
+			markCancelled(results)
+			return false, nil
+		}
+	}
+
+	// 3. Hapus (arsip)
+	var deleteIDs []int64
+	for _, op := range ops {
+		if op.Op == models.BatchDelete {
+			deleteIDs = append(deleteIDs, int64(op.ID))
+		}
+	}
+	newVersions := make(map[int]int)
+	if len(deleteIDs) > 0 {
+		rows, err := tx.Query(`UPDATE products SET deleted_at = COALESCE(deleted_at, NOW()), version = version + 1
+			WHERE id = ANY($1) RETURNING id, version`, pq.Array(deleteIDs))
+		if err != nil {
+			return false, err
+		}
+		if err := scanVersions(rows, newVersions); err != nil {
+			return false, err
+		}
+	}
+
+	// 4. Update, satu UPDATE ... FROM (VALUES ...) untuk semua baris
+	var updateValues, historyValues []interface{}
+	var updateRows, historyRows []string
+	columns := len(batchUpdateColumns) + 1
+	for _, op := range ops {
+		if op.Op != models.BatchUpdate {
+			continue
+		}
+		updateRows = append(updateRows, placeholderRow(len(updateRows)*columns, columns))
+		updateValues = append(updateValues, op.ID)
+		updateValues = append(updateValues, batchValues(op.Product)...)
+
+		if oldPrice := locked[op.ID].price; oldPrice != op.Product.Price {
+			historyRows = append(historyRows, placeholderRow(len(historyRows)*4, 4))
+			historyValues = append(historyValues, op.ID, oldPrice, op.Product.Price, "batch")
+		}
+	}
+	if len(updateRows) > 0 {
+		// Parameter di VALUES dikirim sebagai teks, jadi di-cast ke tipe kolomnya.
+		// op.Product sudah berisi produk tersimpan yang ditimpa field dari body operasi.
+		query := `UPDATE products p SET
+				sku = v.sku, name = v.name, price = v.price::int, stock = v.stock::numeric,
+				base_unit = v.base_unit, cost = v.cost::int,
+				quantity_precision = v.quantity_precision::int, plu = v.plu, is_bundle = v.is_bundle::boolean,
+				category_id = v.category_id::int, kitchen_station = v.kitchen_station, version = p.version + 1
+			FROM (VALUES ` + strings.Join(updateRows, ",") + `) AS v(id, ` + strings.Join(batchUpdateColumns, ", ") + `)
+			WHERE p.id = v.id::int
+			RETURNING p.id, p.version`
+		rows, err := tx.Query(query, updateValues...)
+		if err != nil {
+			return false, batchStatementError(err)
+		}
+		if err := scanVersions(rows, newVersions); err != nil {
+			return false, err
+		}
+	}
+	if len(historyRows) > 0 {
+		_, err := tx.Exec("INSERT INTO product_price_history (product_id, old_price, new_price, source) VALUES "+strings.Join(historyRows, ","), historyValues...)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	// 5. Create, satu INSERT multi-row, RETURNING mengikuti urutan VALUES
+	var createValues []interface{}
+	var createRows []string
+	var createIndexes []int
+	for i, op := range ops {
+		if op.Op != models.BatchCreate {
+			continue
+		}
+		createRows = append(createRows, placeholderRow(len(createRows)*len(batchUpdateColumns), len(batchUpdateColumns)))
+		createValues = append(createValues, batchValues(op.Product)...)
+		createIndexes = append(createIndexes, i)
+	}
+	if len(createRows) > 0 {
+		query := "INSERT INTO products (" + strings.Join(batchUpdateColumns, ", ") + ") VALUES " + strings.Join(createRows, ",") + " RETURNING id, version"
+		rows, err := tx.Query(query, createValues...)
+		if err != nil {
+			return false, batchStatementError(err)
+		}
+		for n := 0; rows.Next(); n++ {
+			i := createIndexes[n]
+			if err := rows.Scan(&results[i].ID, &results[i].Version); err != nil {
+				rows.Close()
+				return false, err
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return false, batchStatementError(err)
+		}
+
+		createdIDs := make([]int64, len(createIndexes))
+		for n, i := range createIndexes {
+			createdIDs[n] = int64(results[i].ID)
+		}
+		_, err = tx.Exec(`INSERT INTO product_price_history (product_id, old_price, new_price, source)
+			SELECT id, 0, price, 'batch' FROM products WHERE id = ANY($1)`, pq.Array(createdIDs))
+		if err != nil {
+			return false, err
+		}
+	}
+
+	// 6. Selisih stok produk baru dan yang diubah dibukukan ke outlet default
+	var stockIDs []int64
+	var movements []stockMovement
+	for i, op := range ops {
+		switch op.Op {
+		case models.BatchUpdate:
+			stockIDs = append(stockIDs, int64(op.ID))
+			movements = append(movements, stockMovement{productID: op.ID, quantity: op.Product.Stock - locked[op.ID].stock})
+		case models.BatchCreate:
+			stockIDs = append(stockIDs, int64(results[i].ID))
+			movements = append(movements, stockMovement{productID: results[i].ID, quantity: op.Product.Stock})
+		}
+	}
+	if err := syncDefaultOutletStock(tx, stockIDs); err != nil {
+		return false, err
+	}
+	if err := insertCatalogMovements(tx, "batch", nil, movements); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+
+	for i, op := range ops {
+		if op.Op != models.BatchCreate {
+			results[i].Version = newVersions[op.ID]
+		}
+	}
+	return true, nil
+}
+
+// checkBatchReferences menandai operasi yang SKU/PLU-nya bentrok (dengan produk lain atau
+// sesama operasi di batch) dan yang category_id-nya tidak ada
+func checkBatchReferences(tx *sql.Tx, ops []models.ProductBatchOperation, results []models.ProductBatchResult) error {
+	var skus, plus []string
+	var categoryIDs []int64
+	for _, op := range ops {
+		if op.Product == nil {
+			continue
+		}
+		if op.Product.SKU != nil {
+			skus = append(skus, *op.Product.SKU)
+		}
+		if op.Product.PLU != nil {
+			plus = append(plus, *op.Product.PLU)
+		}
+		if op.Product.CategoryID != nil {
+			categoryIDs = append(categoryIDs, int64(*op.Product.CategoryID))
+		}
+	}
+
+	owners := func(query string, args ...interface{}) (map[string]int, error) {
+		rows, err := tx.Query(query, args...)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		m := make(map[string]int)
+		for rows.Next() {
+			var key string
+			var id int
+			if err := rows.Scan(&key, &id); err != nil {
+				return nil, err
+			}
+			m[key] = id
+		}
+		return m, rows.Err()
+	}
+
+	skuOwners, err := owners("SELECT sku, id FROM products WHERE sku = ANY($1) AND deleted_at IS NULL", pq.Array(skus))
+	if err != nil {
+		return err
+	}
+	pluOwners, err := owners("SELECT plu, id FROM products WHERE plu = ANY($1)", pq.Array(plus))
+	if err != nil {
+		return err
+	}
+	categories, err := owners("SELECT id::text, id FROM categories WHERE id = ANY($1)", pq.Array(categoryIDs))
+	if err != nil {
+		return err
+	}
+
+	// Produk yang dihapus di batch yang sama tetap memegang SKU/PLU-nya (soft delete)
+	batchSKU := make(map[string]int)
+	batchPLU := make(map[string]int)
+	for i, op := range ops {
+		if op.Product == nil || results[i].Err != nil {
+			continue
+		}
+		p := op.Product
+		switch {
+		case p.SKU != nil && skuOwners[*p.SKU] != 0 && skuOwners[*p.SKU] != op.ID:
+			results[i].Err = ErrSKUExists
+		case p.PLU != nil && pluOwners[*p.PLU] != 0 && pluOwners[*p.PLU] != op.ID:
+			results[i].Err = ErrPLUExists
+		case p.CategoryID != nil && categories[fmt.Sprint(*p.CategoryID)] == 0:
+			results[i].Err = ErrCategoryNotFound
+		}
+		if p.SKU != nil {
+			if first, ok := batchSKU[*p.SKU]; ok && results[i].Err == nil {
+				results[i].Err = fmt.Errorf("%w (sama dengan operasi #%d)", ErrSKUExists, first)
+			}
+			batchSKU[*p.SKU] = i
+		}
+		if p.PLU != nil {
+			if first, ok := batchPLU[*p.PLU]; ok && results[i].Err == nil {
+				results[i].Err = fmt.Errorf("%w (sama dengan operasi #%d)", ErrPLUExists, first)
+			}
+			batchPLU[*p.PLU] = i
+		}
+	}
+	return nil
+}
+
+// markCancelled menandai operasi yang valid di batch yang dibatalkan
+func markCancelled(results []models.ProductBatchResult) {
+	for i := range results {
+		if results[i].Err == nil {
+			results[i].Err = ErrBatchCancelled
+		}
+	}
+}
+
+func scanVersions(rows *sql.Rows, versions map[int]int) error {
+	defer rows.Close()
+	for rows.Next() {
+		var id, version int
+		if err := rows.Scan(&id, &version); err != nil {
+			return err
+		}
+		versions[id] = version
+	}
+	return rows.Err()
+}
+
+// batchStatementError - bentrok unik yang lolos pengecekan awal (transaksi lain menyisipkan
+// SKU/PLU yang sama di saat bersamaan) tidak bisa ditunjuk per operasi
+func batchStatementError(err error) error {
+	if isUniqueViolation(err) {
+		return productUniqueError(err)
+	}
+	return err
+}
@@ -0,0 +1,88 @@
+package repositories
+
+import (
+	"kasir-api/internal/models"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+const bundleItemQuery = `
+	SELECT bi.bundle_id, bi.component_id, bi.component_variant_id,
+		p.name || COALESCE(' (' || v.name || ')', ''), bi.quantity
+	FROM product_bundle_items bi
+	JOIN products p ON p.id = bi.component_id
+	LEFT JOIN product_variants v ON v.id = bi.component_variant_id`
+
+func (repo *ProductRepository) attachBundleItems(products []models.Product) error {
+	ids := make([]int64, 0)
+	index := make(map[int]int)
+	for i, p := range products {
+		if p.IsBundle {
+			ids = append(ids, int64(p.ID))
+			index[p.ID] = i
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	rows, err := repo.db.Query(bundleItemQuery+" WHERE bi.bundle_id = ANY($1) ORDER BY bi.id", pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var bundleID int
+		var item models.BundleItem
+		if err := rows.Scan(&bundleID, &item.ComponentID, &item.ComponentVariantID, &item.ComponentName, &item.Quantity); err != nil {
+			return err
+		}
+		i := index[bundleID]
+		products[i].BundleItems = append(products[i].BundleItems, item)
+	}
+	return rows.Err()
+}
+
+// SetBundleItems mengganti seluruh komponen paket dalam satu transaksi
+func (repo *ProductRepository) SetBundleItems(actor string, bundleID, version int, items []models.BundleItem) error {
+	tx, err := beginTx(repo.db, actor)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("UPDATE products SET version = version + 1 WHERE id = $1 AND ($2 = 0 OR version = $2)", bundleID, version)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return repo.missingOrStale(bundleID)
+	}
+
+	if _, err := tx.Exec("DELETE FROM product_bundle_items WHERE bundle_id = $1", bundleID); err != nil {
+		return err
+	}
+
+	if len(items) > 0 {
+		query := "INSERT INTO product_bundle_items (bundle_id, component_id, component_variant_id, quantity) VALUES "
+		var values []interface{}
+		var placeholders []string
+
+		const columns = 4
+		for i, item := range items {
+			placeholders = append(placeholders, placeholderRow(i*columns, columns))
+			values = append(values, bundleID, item.ComponentID, item.ComponentVariantID, item.Quantity)
+		}
+		if _, err := tx.Exec(query+strings.Join(placeholders, ","), values...); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
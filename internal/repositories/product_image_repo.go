@@ -0,0 +1,113 @@
+package repositories
+
+import (
+	"database/sql"
+	"kasir-api/internal/models"
+
+	"github.com/lib/pq"
+)
+
+const imageColumns = "id, product_id, storage_key, thumbnail_key, content_type, size_bytes, width, height, position, created_at"
+
+func (repo *ProductRepository) scanImage(row rowScanner) (models.ProductImage, error) {
+	var img models.ProductImage
+	err := row.Scan(&img.ID, &img.ProductID, &img.Key, &img.ThumbnailKey, &img.ContentType, &img.Size, &img.Width, &img.Height, &img.Position, &img.CreatedAt)
+	if err != nil {
+		return img, err
+	}
+
+	img.URL, img.ThumbnailURL = img.Key, img.ThumbnailKey
+	if repo.ImageURL != nil {
+		img.URL, img.ThumbnailURL = repo.ImageURL(img.Key), repo.ImageURL(img.ThumbnailKey)
+	}
+	return img, nil
+}
+
+// attachImages mengisi Images untuk semua produk dalam satu query
+func (repo *ProductRepository) attachImages(products []models.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(products))
+	index := make(map[int]int, len(products))
+	for i, p := range products {
+		ids[i] = int64(p.ID)
+		index[p.ID] = i
+	}
+
+	rows, err := repo.db.Query("SELECT "+imageColumns+" FROM product_images WHERE product_id = ANY($1) ORDER BY position, id", pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		img, err := repo.scanImage(rows)
+		if err != nil {
+			return err
+		}
+		i := index[img.ProductID]
+		products[i].Images = append(products[i].Images, img)
+	}
+	return rows.Err()
+}
+
+func (repo *ProductRepository) GetImages(productID int) ([]models.ProductImage, error) {
+	rows, err := repo.db.Query("SELECT "+imageColumns+" FROM product_images WHERE product_id = $1 ORDER BY position, id", productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	images := make([]models.ProductImage, 0)
+	for rows.Next() {
+		img, err := repo.scanImage(rows)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, img)
+	}
+	return images, rows.Err()
+}
+
+// CreateImage - gambar baru ditaruh di urutan terakhir
+func (repo *ProductRepository) CreateImage(actor string, img *models.ProductImage) error {
+	query := `INSERT INTO product_images (product_id, storage_key, thumbnail_key, content_type, size_bytes, width, height, position)
+		SELECT $1, $2, $3, $4, $5, $6, $7, COALESCE(MAX(position) + 1, 0) FROM product_images WHERE product_id = $1
+		RETURNING ` + imageColumns
+	tx, err := beginTx(repo.db, actor)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	created, err := repo.scanImage(tx.QueryRow(query, img.ProductID, img.Key, img.ThumbnailKey, img.ContentType, img.Size, img.Width, img.Height))
+	if isForeignKeyViolation(err) {
+		return ErrProductNotFound
+	}
+	if err != nil {
+		return err
+	}
+	*img = created
+	return tx.Commit()
+}
+
+// DeleteImage menghapus baris gambar dan mengembalikannya supaya file di storage bisa ikut dihapus
+func (repo *ProductRepository) DeleteImage(actor string, productID, imageID int) (*models.ProductImage, error) {
+	query := "DELETE FROM product_images WHERE id = $1 AND product_id = $2 RETURNING " + imageColumns
+	tx, err := beginTx(repo.db, actor)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	img, err := repo.scanImage(tx.QueryRow(query, imageID, productID))
+	if err == sql.ErrNoRows {
+		return nil, ErrImageNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &img, tx.Commit()
+}
@@ -0,0 +1,170 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"kasir-api/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// ImportProducts meng-upsert produk berdasarkan SKU dalam satu transaksi DB.
+// Setiap baris dibungkus SAVEPOINT supaya baris yang melanggar constraint bisa dilaporkan
+// tanpa membatalkan baris lain. Transaksi di-commit hanya kalau commit true dan
+// (allowPartial atau tidak ada baris yang gagal).
+func (repo *ProductRepository) ImportProducts(actor string, rows []models.ProductImportRow, allowPartial, commit bool) (*models.ImportResult, error) {
+	tx, err := beginTx(repo.db, actor)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result := &models.ImportResult{Errors: make([]models.ImportError, 0)}
+	for _, row := range rows {
+		if _, err := tx.Exec("SAVEPOINT import_row"); err != nil {
+			return nil, err
+		}
+
+		created, err := importProduct(tx, row)
+		if err != nil {
+			message, ok := importRowError(err)
+			if !ok {
+				return nil, err
+			}
+			if _, err := tx.Exec("ROLLBACK TO SAVEPOINT import_row"); err != nil {
+				return nil, err
+			}
+			result.Errors = append(result.Errors, models.ImportError{Line: row.Line, SKU: *row.Product.SKU, Message: message})
+			continue
+		}
+
+		if _, err := tx.Exec("RELEASE SAVEPOINT import_row"); err != nil {
+			return nil, err
+		}
+		if created {
+			result.Created++
+		} else {
+			result.Updated++
+		}
+	}
+
+	if commit && (allowPartial || len(result.Errors) == 0) {
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+		result.Committed = true
+	}
+	return result, nil
+}
+
+var errImportNameRequired = errors.New("name wajib diisi untuk produk baru")
+
+// importProduct membuat produk baru atau memperbarui kolom yang terisi di baris ini untuk SKU yang sudah ada.
+// Produk yang diarsipkan tidak ikut dicocokkan, SKU-nya dipakai produk baru. Perubahan stok dibukukan ke outlet default.
+func importProduct(tx *sql.Tx, row models.ProductImportRow) (bool, error) {
+	data := row.Product
+	outletID, err := resolveOutlet(tx, nil)
+	if err != nil {
+		return false, err
+	}
+	current, err := scanProduct(tx.QueryRow("SELECT "+productColumns+" FROM products WHERE sku = $1 AND deleted_at IS NULL FOR UPDATE", *data.SKU))
+	if err == sql.ErrNoRows {
+		if data.Name == "" {
+			return false, errImportNameRequired
+		}
+		var id int
+		err := tx.QueryRow(`INSERT INTO products (sku, name, price, stock, base_unit, cost, quantity_precision, plu, category_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`,
+			data.SKU, data.Name, data.Price, data.Stock, data.BaseUnit, data.Cost, data.QuantityPrecision, data.PLU, data.CategoryID).Scan(&id)
+		if err != nil {
+			return false, err
+		}
+		if err := insertPriceHistory(tx, id, 0, data.Price, "import", nil); err != nil {
+			return false, err
+		}
+		if err := syncDefaultOutletStock(tx, []int64{int64(id)}); err != nil {
+			return false, err
+		}
+		if data.Stock != 0 {
+			movements := []stockMovement{{outletID: outletID, productID: id, quantity: data.Stock, reason: movementImport}}
+			return true, insertStockMovements(tx, "import", nil, movements)
+		}
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	updated := current
+	if row.Filled["name"] {
+		updated.Name = data.Name
+	}
+	if row.Filled["price"] {
+		updated.Price = data.Price
+	}
+	if row.Filled["cost"] {
+		updated.Cost = data.Cost
+	}
+	if row.Filled["stock"] {
+		updated.Stock = data.Stock
+	}
+	if row.Filled["base_unit"] {
+		updated.BaseUnit = data.BaseUnit
+	}
+	if row.Filled["quantity_precision"] {
+		updated.QuantityPrecision = data.QuantityPrecision
+	}
+	if row.Filled["plu"] {
+		updated.PLU = data.PLU
+	}
+	if row.Filled["category_id"] {
+		updated.CategoryID = data.CategoryID
+	}
+
+	_, err = tx.Exec(`UPDATE products SET name = $1, price = $2, stock = $3, base_unit = $4, cost = $5,
+			quantity_precision = $6, plu = $7, category_id = $8, version = version + 1
+		WHERE id = $9`,
+		updated.Name, updated.Price, updated.Stock, updated.BaseUnit, updated.Cost,
+		updated.QuantityPrecision, updated.PLU, updated.CategoryID, current.ID)
+	if err != nil {
+		return false, err
+	}
+
+	if updated.Price != current.Price {
+		if err := insertPriceHistory(tx, current.ID, current.Price, updated.Price, "import", nil); err != nil {
+			return false, err
+		}
+	}
+	if delta := updated.Stock - current.Stock; delta != 0 {
+		if err := syncDefaultOutletStock(tx, []int64{int64(current.ID)}); err != nil {
+			return false, err
+		}
+		movements := []stockMovement{{outletID: outletID, productID: current.ID, quantity: delta, reason: movementImport}}
+		if err := insertStockMovements(tx, "import", nil, movements); err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// importRowError - error yang berasal dari data satu baris dilaporkan per baris,
+// error lain (koneksi, dsb.) menggagalkan seluruh impor
+func importRowError(err error) (string, bool) {
+	if errors.Is(err, errImportNameRequired) || errors.Is(err, ErrOutletStock) {
+		return err.Error(), true
+	}
+
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return "", false
+	}
+	switch pqErr.Code {
+	case "23505":
+		return productUniqueError(err).Error(), true
+	case "23503":
+		return ErrCategoryNotFound.Error(), true
+	case "23514", "22003":
+		return pqErr.Message, true
+	}
+	return "", false
+}
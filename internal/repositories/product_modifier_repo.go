@@ -0,0 +1,162 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"kasir-api/internal/models"
+
+	"github.com/lib/pq"
+)
+
+const modifierColumns = "id, product_id, name, price_delta"
+
+func scanModifier(row rowScanner) (models.ProductModifier, error) {
+	var m models.ProductModifier
+	err := row.Scan(&m.ID, &m.ProductID, &m.Name, &m.PriceDelta)
+	return m, err
+}
+
+func (repo *ProductRepository) attachModifiers(products []models.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(products))
+	index := make(map[int]int, len(products))
+	for i, p := range products {
+		ids[i] = int64(p.ID)
+		index[p.ID] = i
+	}
+
+	rows, err := repo.db.Query("SELECT "+modifierColumns+" FROM product_modifiers WHERE product_id = ANY($1) ORDER BY id", pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		m, err := scanModifier(rows)
+		if err != nil {
+			return err
+		}
+		i := index[m.ProductID]
+		products[i].Modifiers = append(products[i].Modifiers, m)
+	}
+	return rows.Err()
+}
+
+func (repo *ProductRepository) GetModifiers(productID int) ([]models.ProductModifier, error) {
+	rows, err := repo.db.Query("SELECT "+modifierColumns+" FROM product_modifiers WHERE product_id = $1 ORDER BY id", productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	modifiers := make([]models.ProductModifier, 0)
+	for rows.Next() {
+		m, err := scanModifier(rows)
+		if err != nil {
+			return nil, err
+		}
+		modifiers = append(modifiers, m)
+	}
+	return modifiers, rows.Err()
+}
+
+func (repo *ProductRepository) CreateModifier(actor string, modifier *models.ProductModifier) error {
+	err := queryRowAs(repo.db, actor, "INSERT INTO product_modifiers (product_id, name, price_delta) VALUES ($1, $2, $3) RETURNING id",
+		[]interface{}{modifier.ProductID, modifier.Name, modifier.PriceDelta}, &modifier.ID)
+	if isUniqueViolation(err) {
+		return ErrModifierExists
+	}
+	if isForeignKeyViolation(err) {
+		return ErrProductNotFound
+	}
+	return err
+}
+
+func (repo *ProductRepository) UpdateModifier(actor string, modifier *models.ProductModifier) error {
+	result, err := execAs(repo.db, actor, "UPDATE product_modifiers SET name = $1, price_delta = $2 WHERE id = $3 AND product_id = $4",
+		modifier.Name, modifier.PriceDelta, modifier.ID, modifier.ProductID)
+	if isUniqueViolation(err) {
+		return ErrModifierExists
+	}
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrModifierNotFound
+	}
+	return nil
+}
+
+// DeleteModifier - pilihan di pesanan yang masih terbuka ikut terhapus,
+// transaksi lama menyimpan nama & harga modifier sebagai snapshot
+func (repo *ProductRepository) DeleteModifier(actor string, productID, modifierID int) error {
+	result, err := execAs(repo.db, actor, "DELETE FROM product_modifiers WHERE id = $1 AND product_id = $2", modifierID, productID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrModifierNotFound
+	}
+	return nil
+}
+
+// lineModifiers memuat modifier pilihan untuk satu produk dengan harga saat ini.
+// Modifier milik produk lain atau yang sudah dihapus ditolak.
+func lineModifiers(tx *sql.Tx, productID int, ids []int) ([]models.LineModifier, error) {
+	modifiers := make([]models.LineModifier, 0, len(ids))
+	if len(ids) == 0 {
+		return modifiers, nil
+	}
+
+	wanted := make([]int64, len(ids))
+	for i, id := range ids {
+		wanted[i] = int64(id)
+	}
+	rows, err := tx.Query("SELECT id, name, price_delta FROM product_modifiers WHERE product_id = $1 AND id = ANY($2) ORDER BY id", productID, pq.Array(wanted))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	found := make(map[int]bool, len(ids))
+	for rows.Next() {
+		var id int
+		var m models.LineModifier
+		if err := rows.Scan(&id, &m.Name, &m.PriceDelta); err != nil {
+			return nil, err
+		}
+		m.ModifierID = &id
+		found[id] = true
+		modifiers = append(modifiers, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		if !found[id] {
+			return nil, fmt.Errorf("%w: modifier ID %d tidak tersedia untuk produk ID %d", ErrModifierNotFound, id, productID)
+		}
+	}
+	return modifiers, nil
+}
+
+// modifierDelta - total harga tambahan per satuan jual
+func modifierDelta(modifiers []models.LineModifier) int {
+	delta := 0
+	for _, m := range modifiers {
+		delta += m.PriceDelta
+	}
+	return delta
+}
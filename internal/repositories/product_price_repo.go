@@ -0,0 +1,178 @@
+package repositories
+
+import (
+	"database/sql"
+	"kasir-api/internal/models"
+)
+
+func insertPriceHistory(tx *sql.Tx, productID, oldPrice, newPrice int, source string, scheduleID *int) error {
+	_, err := tx.Exec(`INSERT INTO product_price_history (product_id, old_price, new_price, source, schedule_id)
+		VALUES ($1, $2, $3, $4, $5)`, productID, oldPrice, newPrice, source, scheduleID)
+	return err
+}
+
+// applyDuePrice menerapkan jadwal harga yang sudah jatuh tempo untuk satu produk.
+// Baris produk harus sudah dikunci (FOR UPDATE) oleh pemanggil. Mengembalikan harga yang berlaku.
+func applyDuePrice(tx *sql.Tx, productID, currentPrice int) (int, error) {
+	var scheduleID, price int
+	err := tx.QueryRow(`
+		SELECT id, price FROM product_price_schedules
+		WHERE product_id = $1 AND applied_at IS NULL AND cancelled_at IS NULL AND effective_from <= NOW()
+		ORDER BY effective_from DESC, id DESC
+		LIMIT 1`, productID).Scan(&scheduleID, &price)
+	if err == sql.ErrNoRows {
+		return currentPrice, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	// Jadwal lama yang terlewat ikut ditandai, hanya yang terbaru yang berlaku
+	_, err = tx.Exec(`UPDATE product_price_schedules SET applied_at = NOW()
+		WHERE product_id = $1 AND applied_at IS NULL AND cancelled_at IS NULL AND effective_from <= NOW()`, productID)
+	if err != nil {
+		return 0, err
+	}
+
+	if price == currentPrice {
+		return currentPrice, nil
+	}
+
+	_, err = tx.Exec("UPDATE products SET price = $1, version = version + 1 WHERE id = $2", price, productID)
+	if err != nil {
+		return 0, err
+	}
+	if err := insertPriceHistory(tx, productID, currentPrice, price, "schedule", &scheduleID); err != nil {
+		return 0, err
+	}
+
+	return price, nil
+}
+
+// ApplyDuePrices - terapkan semua jadwal harga yang sudah jatuh tempo, dipanggil berkala
+func (repo *ProductRepository) ApplyDuePrices() (int, error) {
+	rows, err := repo.db.Query(`
+		SELECT DISTINCT product_id FROM product_price_schedules
+		WHERE applied_at IS NULL AND cancelled_at IS NULL AND effective_from <= NOW()`)
+	if err != nil {
+		return 0, err
+	}
+	productIDs := make([]int, 0)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		productIDs = append(productIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	applied := 0
+	for _, id := range productIDs {
+		if err := repo.applyDuePriceFor(id); err != nil {
+			return applied, err
+		}
+		applied++
+	}
+	return applied, nil
+}
+
+func (repo *ProductRepository) applyDuePriceFor(productID int) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var price int
+	err = tx.QueryRow("SELECT price FROM products WHERE id = $1 FOR UPDATE", productID).Scan(&price)
+	if err != nil {
+		return err
+	}
+	if _, err := applyDuePrice(tx, productID, price); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (repo *ProductRepository) GetPriceHistory(productID int) ([]models.PriceHistory, error) {
+	rows, err := repo.db.Query(`
+		SELECT id, product_id, old_price, new_price, source, schedule_id, changed_at
+		FROM product_price_history
+		WHERE product_id = $1
+		ORDER BY changed_at DESC, id DESC`, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := make([]models.PriceHistory, 0)
+	for rows.Next() {
+		var h models.PriceHistory
+		err := rows.Scan(&h.ID, &h.ProductID, &h.OldPrice, &h.NewPrice, &h.Source, &h.ScheduleID, &h.ChangedAt)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, h)
+	}
+	return history, rows.Err()
+}
+
+func (repo *ProductRepository) GetPriceSchedules(productID int) ([]models.PriceSchedule, error) {
+	rows, err := repo.db.Query(`
+		SELECT id, product_id, price, effective_from, applied_at, cancelled_at, created_at
+		FROM product_price_schedules
+		WHERE product_id = $1
+		ORDER BY effective_from DESC, id DESC`, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := make([]models.PriceSchedule, 0)
+	for rows.Next() {
+		var ps models.PriceSchedule
+		err := rows.Scan(&ps.ID, &ps.ProductID, &ps.Price, &ps.EffectiveFrom, &ps.AppliedAt, &ps.CancelledAt, &ps.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, ps)
+	}
+	return schedules, rows.Err()
+}
+
+func (repo *ProductRepository) CreatePriceSchedule(actor string, schedule *models.PriceSchedule) error {
+	var exists bool
+	err := repo.db.QueryRow("SELECT EXISTS (SELECT 1 FROM products WHERE id = $1)", schedule.ProductID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrProductNotFound
+	}
+
+	query := `INSERT INTO product_price_schedules (product_id, price, effective_from)
+		VALUES ($1, $2, $3) RETURNING id, created_at`
+	return queryRowAs(repo.db, actor, query, []interface{}{schedule.ProductID, schedule.Price, schedule.EffectiveFrom}, &schedule.ID, &schedule.CreatedAt)
+}
+
+// CancelPriceSchedule - hanya jadwal yang belum diterapkan yang bisa dibatalkan
+func (repo *ProductRepository) CancelPriceSchedule(actor string, productID, scheduleID int) error {
+	result, err := execAs(repo.db, actor, `UPDATE product_price_schedules SET cancelled_at = NOW()
+		WHERE id = $1 AND product_id = $2 AND applied_at IS NULL AND cancelled_at IS NULL`, scheduleID, productID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrPriceScheduleNotFound
+	}
+	return nil
+}
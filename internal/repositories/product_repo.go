@@ -2,26 +2,66 @@ package repositories
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"kasir-api/internal/models"
+	"strings"
+
+	"github.com/lib/pq"
 )
 
 type ProductRepository struct {
 	db *sql.DB
+	// ImageURL mengubah key storage gambar menjadi URL publik
+	ImageURL func(key string) string
 }
 
 func NewProductRepository(db *sql.DB) *ProductRepository {
 	return &ProductRepository{db: db}
 }
 
-func (repo *ProductRepository) GetAll() ([]models.Product, error) {
-	query := "SELECT id, name, price, stock FROM products"
+// productColumns harus sama urutannya dengan scanProduct
+const productColumns = "id, sku, name, price, stock, version, deleted_at, options, base_unit, cost, quantity_precision, plu, is_bundle, category_id, kitchen_station"
+
+func scanProduct(row rowScanner) (models.Product, error) {
+	var p models.Product
+	var options []byte
+	err := row.Scan(&p.ID, &p.SKU, &p.Name, &p.Price, &p.Stock, &p.Version, &p.DeletedAt, &options, &p.BaseUnit, &p.Cost, &p.QuantityPrecision, &p.PLU, &p.IsBundle, &p.CategoryID, &p.KitchenStation)
+	if err != nil {
+		return p, err
+	}
+
+	p.Options = make([]models.ProductOption, 0)
+	if err := json.Unmarshal(options, &p.Options); err != nil {
+		return p, err
+	}
+	return p, nil
+}
+
+func (repo *ProductRepository) GetAll(filter models.ProductFilter) ([]models.Product, error) {
+	query := "SELECT " + productColumns + " FROM products"
+	conditions := []string{}
 	args := []interface{}{}
-	nameFilter := ""
-	if nameFilter != "" {
-		query += " WHERE p.name ILIKE $1"
-		args = append(args, "%"+nameFilter+"%")
+	if filter.Name != "" {
+		args = append(args, "%"+filter.Name+"%")
+		conditions = append(conditions, fmt.Sprintf("name ILIKE $%d", len(args)))
+	}
+	if !filter.IncludeArchived {
+		conditions = append(conditions, "deleted_at IS NULL")
+	}
+	if filter.CategoryID != nil {
+		args = append(args, *filter.CategoryID)
+		conditions = append(conditions, "category_id IN ("+categorySubtree(fmt.Sprintf("$%d", len(args)))+")")
+	}
+	if filter.IDs != nil {
+		args = append(args, pq.Array(filter.IDs))
+		conditions = append(conditions, fmt.Sprintf("id = ANY($%d)", len(args)))
 	}
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY id"
 
 	rows, err := repo.db.Query(query, args...)
 	if err != nil {
@@ -31,61 +71,229 @@ func (repo *ProductRepository) GetAll() ([]models.Product, error) {
 
 	products := make([]models.Product, 0)
 	for rows.Next() {
-		var p models.Product
-		err := rows.Scan(&p.ID, &p.Name, &p.Price, &p.Stock)
+		p, err := scanProduct(rows)
 		if err != nil {
 			return nil, err
 		}
 		products = append(products, p)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
+	if err := repo.attachRelations(products); err != nil {
+		return nil, err
+	}
 	return products, nil
 }
 
-func (repo *ProductRepository) Create(product *models.Product) error {
-	query := "INSERT INTO products (name, price, stock) VALUES ($1, $2, $3) RETURNING id"
-	err := repo.db.QueryRow(query, product.Name, product.Price, product.Stock).Scan(&product.ID)
-	return err
+// Create - stok awal produk dibukukan ke outlet default, harga awal dicatat ke riwayat harga
+func (repo *ProductRepository) Create(actor string, product *models.Product) error {
+	tx, err := beginTx(repo.db, actor)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO products (sku, name, price, stock, base_unit, cost, quantity_precision, plu, is_bundle, category_id, kitchen_station)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id, version`
+	err = tx.QueryRow(query, product.SKU, product.Name, product.Price, product.Stock, product.BaseUnit, product.Cost, product.QuantityPrecision, product.PLU, product.IsBundle, product.CategoryID, product.KitchenStation).
+		Scan(&product.ID, &product.Version)
+	if isUniqueViolation(err) {
+		return productUniqueError(err)
+	}
+	if isForeignKeyViolation(err) {
+		return ErrCategoryNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if err := insertPriceHistory(tx, product.ID, 0, product.Price, "create", nil); err != nil {
+		return err
+	}
+	if err := syncDefaultOutletStock(tx, []int64{int64(product.ID)}); err != nil {
+		return err
+	}
+	movements := []stockMovement{{productID: product.ID, quantity: product.Stock}}
+	if err := insertCatalogMovements(tx, "product", &product.ID, movements); err != nil {
+		return err
+	}
+	if product.Options == nil {
+		product.Options = make([]models.ProductOption, 0)
+	}
+	return tx.Commit()
+}
+
+// attachRelations mengisi varian, satuan, komponen paket, modifier, gambar dan stok tersedia untuk daftar produk,
+// masing-masing satu query
+func (repo *ProductRepository) attachRelations(products []models.Product) error {
+	if err := repo.attachVariants(products); err != nil {
+		return err
+	}
+	if err := repo.attachAvailability(products); err != nil {
+		return err
+	}
+	if err := repo.attachUnits(products); err != nil {
+		return err
+	}
+	if err := repo.attachBundleItems(products); err != nil {
+		return err
+	}
+	if err := repo.attachModifiers(products); err != nil {
+		return err
+	}
+	return repo.attachImages(products)
+}
+
+// attachAvailability mengisi stok tersedia (stok dikurangi reservasi aktif) produk dan variannya
+func (repo *ProductRepository) attachAvailability(products []models.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(products))
+	for i, p := range products {
+		ids[i] = int64(p.ID)
+	}
+
+	type key struct {
+		productID int
+		variantID int
+	}
+	reserved := make(map[key]models.Quantity)
+	rows, err := repo.db.Query(`SELECT product_id, COALESCE(variant_id, 0), SUM(quantity) FROM stock_reservations
+		WHERE product_id = ANY($1) AND `+activeReservation+` GROUP BY product_id, variant_id`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var k key
+		var quantity models.Quantity
+		if err := rows.Scan(&k.productID, &k.variantID, &quantity); err != nil {
+			return err
+		}
+		reserved[k] = quantity
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	available := func(stock, reserved models.Quantity) *models.Quantity {
+		v := stock - reserved
+		return &v
+	}
+	for i := range products {
+		p := &products[i]
+		p.Available = available(p.Stock, reserved[key{p.ID, 0}])
+		for j := range p.Variants {
+			v := &p.Variants[j]
+			v.Available = available(v.Stock, reserved[key{p.ID, v.ID}])
+		}
+	}
+	return nil
 }
 
 // GetByID - ambil produk by ID
 func (repo *ProductRepository) GetByID(id int) (*models.Product, error) {
-	query := "SELECT id, name, price, stock FROM products WHERE id = $1"
+	query := "SELECT " + productColumns + " FROM products WHERE id = $1"
 
-	var p models.Product
-	err := repo.db.QueryRow(query, id).Scan(&p.ID, &p.Name, &p.Price, &p.Stock)
+	p, err := scanProduct(repo.db.QueryRow(query, id))
 	if err == sql.ErrNoRows {
-		return nil, errors.New("produk tidak ditemukan")
+		return nil, ErrProductNotFound
 	}
 	if err != nil {
 		return nil, err
 	}
 
-	return &p, nil
+	products := []models.Product{p}
+	if err := repo.attachRelations(products); err != nil {
+		return nil, err
+	}
+	return &products[0], nil
 }
 
-func (repo *ProductRepository) Update(product *models.Product) error {
-	query := "UPDATE products SET name = $1, price = $2, stock = $3 WHERE id = $4"
-	result, err := repo.db.Exec(query, product.Name, product.Price, product.Stock, product.ID)
+// Update - product.Version berisi versi yang diharapkan (0 = tanpa cek versi),
+// setelah berhasil diisi dengan versi baru. Perubahan harga dicatat ke riwayat harga,
+// perubahan stok ke pergerakan stok outlet default.
+func (repo *ProductRepository) Update(actor string, product *models.Product) error {
+	tx, err := beginTx(repo.db, actor)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	rows, err := result.RowsAffected()
+	var oldPrice int
+	var oldStock models.Quantity
+	err = tx.QueryRow("SELECT price, stock FROM products WHERE id = $1 FOR UPDATE", product.ID).Scan(&oldPrice, &oldStock)
+	if err == sql.ErrNoRows {
+		return ErrProductNotFound
+	}
 	if err != nil {
 		return err
 	}
 
-	if rows == 0 {
-		return errors.New("produk tidak ditemukan")
+	query := `UPDATE products SET sku = $1, name = $2, price = $3, stock = $4, base_unit = $5, cost = $6,
+			quantity_precision = $7, plu = $8, is_bundle = $9, category_id = $10, kitchen_station = $11, version = version + 1
+		WHERE id = $12 AND deleted_at IS NULL AND ($13 = 0 OR version = $13)
+		RETURNING version`
+	err = tx.QueryRow(query, product.SKU, product.Name, product.Price, product.Stock, product.BaseUnit, product.Cost,
+		product.QuantityPrecision, product.PLU, product.IsBundle, product.CategoryID, product.KitchenStation, product.ID, product.Version).Scan(&product.Version)
+	if err == sql.ErrNoRows {
+		return repo.archiveConflict(product.ID, false)
+	}
+	if isUniqueViolation(err) {
+		return productUniqueError(err)
+	}
+	if isForeignKeyViolation(err) {
+		return ErrCategoryNotFound
+	}
+	if err != nil {
+		return err
 	}
 
-	return nil
+	if oldPrice != product.Price {
+		if err := insertPriceHistory(tx, product.ID, oldPrice, product.Price, "manual", nil); err != nil {
+			return err
+		}
+	}
+	// Selisih stok dari katalog dibukukan ke outlet default
+	if err := syncDefaultOutletStock(tx, []int64{int64(product.ID)}); err != nil {
+		return err
+	}
+	movements := []stockMovement{{productID: product.ID, quantity: product.Stock - oldStock}}
+	if err := insertCatalogMovements(tx, "product", &product.ID, movements); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Delete - soft delete (arsip), baris tetap ada supaya detail transaksi & laporan lama utuh.
+// version 0 berarti tanpa cek versi.
+func (repo *ProductRepository) Delete(actor string, id, version int) error {
+	query := `UPDATE products SET deleted_at = COALESCE(deleted_at, NOW()), version = version + 1
+		WHERE id = $1 AND ($2 = 0 OR version = $2)`
+	return repo.execVersioned(actor, query, id, version)
+}
+
+// Restore - kembalikan produk yang diarsipkan, produk yang masih aktif ditolak
+func (repo *ProductRepository) Restore(actor string, id, version int) error {
+	query := `UPDATE products SET deleted_at = NULL, version = version + 1
+		WHERE id = $1 AND deleted_at IS NOT NULL AND ($2 = 0 OR version = $2)`
+	err := repo.execVersioned(actor, query, id, version)
+	if errors.Is(err, ErrVersionMismatch) {
+		return repo.archiveConflict(id, true)
+	}
+	// SKU produk arsip bisa sudah dipakai produk aktif lain
+	if isUniqueViolation(err) {
+		return productUniqueError(err)
+	}
+	return err
 }
 
-func (repo *ProductRepository) Delete(id int) error {
-	query := "DELETE FROM products WHERE id = $1"
-	result, err := repo.db.Exec(query, id)
+func (repo *ProductRepository) execVersioned(actor, query string, id, version int) error {
+	result, err := execAs(repo.db, actor, query, id, version)
 	if err != nil {
 		return err
 	}
@@ -95,8 +303,50 @@ func (repo *ProductRepository) Delete(id int) error {
 	}
 
 	if rows == 0 {
-		return errors.New("produk tidak ditemukan")
+		return repo.missingOrStale(id)
 	}
 
-	return err
+	return nil
+}
+
+// missingOrStale membedakan produk yang tidak ada dengan versi yang sudah usang
+func (repo *ProductRepository) missingOrStale(id int) error {
+	var exists bool
+	err := repo.db.QueryRow("SELECT EXISTS (SELECT 1 FROM products WHERE id = $1)", id).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrProductNotFound
+	}
+	return ErrVersionMismatch
+}
+
+// archiveConflict menjelaskan UPDATE bersyarat status arsip yang tidak mengenai baris mana pun:
+// produk tidak ada, status arsipnya bukan wantArchived, atau versinya sudah usang
+func (repo *ProductRepository) archiveConflict(id int, wantArchived bool) error {
+	var archived bool
+	err := repo.db.QueryRow("SELECT deleted_at IS NOT NULL FROM products WHERE id = $1", id).Scan(&archived)
+	if err == sql.ErrNoRows {
+		return ErrProductNotFound
+	}
+	if err != nil {
+		return err
+	}
+	switch {
+	case archived && !wantArchived:
+		return ErrProductArchived
+	case !archived && wantArchived:
+		return ErrProductNotArchived
+	}
+	return ErrVersionMismatch
+}
+
+// productUniqueError memetakan pelanggaran unique index produk ke error yang sesuai
+func productUniqueError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Constraint == "idx_products_sku" {
+		return ErrSKUExists
+	}
+	return ErrPLUExists
 }
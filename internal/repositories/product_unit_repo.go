@@ -0,0 +1,154 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"kasir-api/internal/models"
+
+	"github.com/lib/pq"
+)
+
+const unitColumns = "id, product_id, name, factor, price, sellable, purchasable"
+
+func scanUnit(row rowScanner) (models.ProductUnit, error) {
+	var u models.ProductUnit
+	err := row.Scan(&u.ID, &u.ProductID, &u.Name, &u.Factor, &u.Price, &u.Sellable, &u.Purchasable)
+	return u, err
+}
+
+func (repo *ProductRepository) attachUnits(products []models.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(products))
+	index := make(map[int]int, len(products))
+	for i, p := range products {
+		ids[i] = int64(p.ID)
+		index[p.ID] = i
+	}
+
+	rows, err := repo.db.Query("SELECT "+unitColumns+" FROM product_units WHERE product_id = ANY($1) ORDER BY factor, id", pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		u, err := scanUnit(rows)
+		if err != nil {
+			return err
+		}
+		i := index[u.ProductID]
+		products[i].Units = append(products[i].Units, u)
+	}
+	return rows.Err()
+}
+
+func (repo *ProductRepository) GetUnits(productID int) ([]models.ProductUnit, error) {
+	rows, err := repo.db.Query("SELECT "+unitColumns+" FROM product_units WHERE product_id = $1 ORDER BY factor, id", productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	units := make([]models.ProductUnit, 0)
+	for rows.Next() {
+		u, err := scanUnit(rows)
+		if err != nil {
+			return nil, err
+		}
+		units = append(units, u)
+	}
+	return units, rows.Err()
+}
+
+func (repo *ProductRepository) CreateUnit(actor string, unit *models.ProductUnit) error {
+	query := `INSERT INTO product_units (product_id, name, factor, price, sellable, purchasable)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+	err := queryRowAs(repo.db, actor, query, []interface{}{unit.ProductID, unit.Name, unit.Factor, unit.Price, unit.Sellable, unit.Purchasable}, &unit.ID)
+	if isUniqueViolation(err) {
+		return ErrUnitExists
+	}
+	return err
+}
+
+func (repo *ProductRepository) UpdateUnit(actor string, unit *models.ProductUnit) error {
+	query := `UPDATE product_units SET name = $1, factor = $2, price = $3, sellable = $4, purchasable = $5
+		WHERE id = $6 AND product_id = $7`
+	result, err := execAs(repo.db, actor, query, unit.Name, unit.Factor, unit.Price, unit.Sellable, unit.Purchasable, unit.ID, unit.ProductID)
+	if isUniqueViolation(err) {
+		return ErrUnitExists
+	}
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrUnitNotFound
+	}
+	return nil
+}
+
+// DeleteUnit - transaksi lama menyimpan nama & faktor satuan sebagai snapshot, jadi aman dihapus
+func (repo *ProductRepository) DeleteUnit(actor string, productID, unitID int) error {
+	result, err := execAs(repo.db, actor, "DELETE FROM product_units WHERE id = $1 AND product_id = $2", unitID, productID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrUnitNotFound
+	}
+	return nil
+}
+
+// resolvedUnit - hasil konversi satuan jual/beli ke satuan dasar
+type resolvedUnit struct {
+	name   string
+	factor models.Quantity
+	price  *int
+}
+
+// resolveUnit mencari satuan untuk produk. Nama kosong atau sama dengan satuan dasar berarti faktor 1.
+// forSale memilih flag sellable, selain itu purchasable.
+func resolveUnit(tx *sql.Tx, productID int, baseUnit, name string, forSale bool) (*resolvedUnit, error) {
+	if name == "" || name == baseUnit {
+		return &resolvedUnit{name: baseUnit, factor: models.Units(1)}, nil
+	}
+
+	unit := &resolvedUnit{name: name}
+	var sellable, purchasable bool
+	err := tx.QueryRow("SELECT factor, price, sellable, purchasable FROM product_units WHERE product_id = $1 AND name = $2", productID, name).
+		Scan(&unit.factor, &unit.price, &sellable, &purchasable)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("satuan '%s' tidak tersedia untuk produk ID %d", name, productID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if forSale && !sellable {
+		return nil, fmt.Errorf("satuan '%s' tidak bisa dijual", name)
+	}
+	if !forSale && !purchasable {
+		return nil, fmt.Errorf("satuan '%s' tidak bisa dipakai untuk pembelian", name)
+	}
+	return unit, nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503"
+}
@@ -0,0 +1,214 @@
+package repositories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"kasir-api/internal/models"
+
+	"github.com/lib/pq"
+)
+
+const variantColumns = "id, product_id, sku, name, options, price, stock, version, deleted_at"
+
+func scanVariant(row rowScanner) (models.ProductVariant, error) {
+	var v models.ProductVariant
+	var options []byte
+	err := row.Scan(&v.ID, &v.ProductID, &v.SKU, &v.Name, &options, &v.Price, &v.Stock, &v.Version, &v.DeletedAt)
+	if err != nil {
+		return v, err
+	}
+
+	v.Options = map[string]string{}
+	if err := json.Unmarshal(options, &v.Options); err != nil {
+		return v, err
+	}
+	return v, nil
+}
+
+// attachVariants mengisi Variants (yang belum diarsipkan) untuk semua produk dalam satu query
+func (repo *ProductRepository) attachVariants(products []models.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(products))
+	index := make(map[int]int, len(products))
+	for i, p := range products {
+		ids[i] = int64(p.ID)
+		index[p.ID] = i
+	}
+
+	rows, err := repo.db.Query("SELECT "+variantColumns+" FROM product_variants WHERE product_id = ANY($1) AND deleted_at IS NULL ORDER BY id", pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		v, err := scanVariant(rows)
+		if err != nil {
+			return err
+		}
+		i := index[v.ProductID]
+		products[i].Variants = append(products[i].Variants, v)
+	}
+	return rows.Err()
+}
+
+func (repo *ProductRepository) GetVariants(productID int, includeArchived bool) ([]models.ProductVariant, error) {
+	query := "SELECT " + variantColumns + " FROM product_variants WHERE product_id = $1"
+	if !includeArchived {
+		query += " AND deleted_at IS NULL"
+	}
+	query += " ORDER BY id"
+
+	rows, err := repo.db.Query(query, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	variants := make([]models.ProductVariant, 0)
+	for rows.Next() {
+		v, err := scanVariant(rows)
+		if err != nil {
+			return nil, err
+		}
+		variants = append(variants, v)
+	}
+	return variants, rows.Err()
+}
+
+func (repo *ProductRepository) GetVariant(productID, variantID int) (*models.ProductVariant, error) {
+	query := "SELECT " + variantColumns + " FROM product_variants WHERE id = $1 AND product_id = $2"
+	v, err := scanVariant(repo.db.QueryRow(query, variantID, productID))
+	if err == sql.ErrNoRows {
+		return nil, ErrVariantNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// CreateVariant - stok awal varian dibukukan ke outlet default
+func (repo *ProductRepository) CreateVariant(actor string, variant *models.ProductVariant) error {
+	options, err := json.Marshal(variant.Options)
+	if err != nil {
+		return err
+	}
+
+	tx, err := beginTx(repo.db, actor)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO product_variants (product_id, sku, name, options, price, stock)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, version`
+	err = tx.QueryRow(query, variant.ProductID, variant.SKU, variant.Name, options, variant.Price, variant.Stock).
+		Scan(&variant.ID, &variant.Version)
+	if err != nil {
+		return err
+	}
+	if err := syncDefaultOutletStock(tx, []int64{int64(variant.ProductID)}); err != nil {
+		return err
+	}
+	movements := []stockMovement{{productID: variant.ProductID, variantID: &variant.ID, quantity: variant.Stock}}
+	if err := insertCatalogMovements(tx, "product", &variant.ProductID, movements); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpdateVariant - variant.Version berisi versi yang diharapkan (0 = tanpa cek versi)
+func (repo *ProductRepository) UpdateVariant(actor string, variant *models.ProductVariant) error {
+	options, err := json.Marshal(variant.Options)
+	if err != nil {
+		return err
+	}
+
+	tx, err := beginTx(repo.db, actor)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var oldStock models.Quantity
+	err = tx.QueryRow("SELECT stock FROM product_variants WHERE id = $1 AND product_id = $2 FOR UPDATE", variant.ID, variant.ProductID).Scan(&oldStock)
+	if err == sql.ErrNoRows {
+		return repo.variantMissingOrStale(variant.ProductID, variant.ID)
+	}
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE product_variants SET sku = $1, name = $2, options = $3, price = $4, stock = $5, version = version + 1
+		WHERE id = $6 AND product_id = $7 AND ($8 = 0 OR version = $8)
+		RETURNING version`
+	err = tx.QueryRow(query, variant.SKU, variant.Name, options, variant.Price, variant.Stock, variant.ID, variant.ProductID, variant.Version).
+		Scan(&variant.Version)
+	if err == sql.ErrNoRows {
+		return repo.variantMissingOrStale(variant.ProductID, variant.ID)
+	}
+	if err != nil {
+		return err
+	}
+	// Selisih stok dari katalog dibukukan ke outlet default
+	if err := syncDefaultOutletStock(tx, []int64{int64(variant.ProductID)}); err != nil {
+		return err
+	}
+	movements := []stockMovement{{productID: variant.ProductID, variantID: &variant.ID, quantity: variant.Stock - oldStock}}
+	if err := insertCatalogMovements(tx, "product", &variant.ProductID, movements); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteVariant - diarsipkan seperti produk, riwayat transaksi tetap merujuk ke varian ini
+func (repo *ProductRepository) DeleteVariant(actor string, productID, variantID, version int) error {
+	query := `UPDATE product_variants SET deleted_at = COALESCE(deleted_at, NOW()), version = version + 1
+		WHERE id = $1 AND product_id = $2 AND ($3 = 0 OR version = $3)`
+	result, err := execAs(repo.db, actor, query, variantID, productID, version)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return repo.variantMissingOrStale(productID, variantID)
+	}
+	return nil
+}
+
+func (repo *ProductRepository) variantMissingOrStale(productID, variantID int) error {
+	var exists bool
+	err := repo.db.QueryRow("SELECT EXISTS (SELECT 1 FROM product_variants WHERE id = $1 AND product_id = $2)", variantID, productID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrVariantNotFound
+	}
+	return ErrVersionMismatch
+}
+
+// SetOptions mengganti sumbu opsi varian produk
+func (repo *ProductRepository) SetOptions(actor string, productID, version int, options []models.ProductOption) (int, error) {
+	raw, err := json.Marshal(options)
+	if err != nil {
+		return 0, err
+	}
+
+	var newVersion int
+	query := `UPDATE products SET options = $1, version = version + 1
+		WHERE id = $2 AND ($3 = 0 OR version = $3)
+		RETURNING version`
+	err = queryRowAs(repo.db, actor, query, []interface{}{raw, productID, version}, &newVersion)
+	if err == sql.ErrNoRows {
+		return 0, repo.missingOrStale(productID)
+	}
+	return newVersion, err
+}
@@ -0,0 +1,193 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"kasir-api/internal/models"
+	"strings"
+)
+
+type PurchaseRepository struct {
+	db *sql.DB
+}
+
+func NewPurchaseRepository(db *sql.DB) *PurchaseRepository {
+	return &PurchaseRepository{db: db}
+}
+
+// CreateReceipt mencatat penerimaan barang: stok bertambah dalam satuan dasar
+// dan harga pokok produk diperbarui dari harga beli terakhir.
+func (repo *PurchaseRepository) CreateReceipt(actor string, receipt *models.PurchaseReceipt) error {
+	tx, err := beginTx(repo.db, actor)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	outletID, err := resolveOutlet(tx, receipt.OutletID)
+	if err != nil {
+		return err
+	}
+	receipt.OutletID = &outletID
+
+	totalCost := 0
+	movements := make([]stockMovement, 0, len(receipt.Lines))
+	for i := range receipt.Lines {
+		line := &receipt.Lines[i]
+
+		var productName, baseUnit string
+		err := tx.QueryRow("SELECT name, base_unit FROM products WHERE id = $1 FOR UPDATE", line.ProductID).Scan(&productName, &baseUnit)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("produk dengan ID %d tidak ditemukan", line.ProductID)
+		}
+		if err != nil {
+			return err
+		}
+
+		if line.VariantID != nil {
+			var variantID int
+			err := tx.QueryRow("SELECT id FROM product_variants WHERE id = $1 AND product_id = $2 FOR UPDATE", *line.VariantID, line.ProductID).Scan(&variantID)
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("varian dengan ID %d tidak ditemukan untuk produk '%s'", *line.VariantID, productName)
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		unit, err := resolveUnit(tx, line.ProductID, baseUnit, line.Unit, false)
+		if err != nil {
+			return err
+		}
+		line.Unit = unit.name
+		line.UnitFactor = unit.factor
+		line.BaseQuantity = line.Quantity.Mul(unit.factor)
+		line.Subtotal = line.Quantity.Rupiah(line.UnitCost)
+		totalCost += line.Subtotal
+
+		if err := addStock(tx, outletID, line.ProductID, line.VariantID, line.BaseQuantity); err != nil {
+			return err
+		}
+		movements = append(movements, stockMovement{outletID: outletID, productID: line.ProductID, variantID: line.VariantID, quantity: line.BaseQuantity, reason: movementPurchase})
+		if line.UnitCost > 0 {
+			cost := models.ProportionRupiah(line.UnitCost, models.Units(1), unit.factor)
+			_, err := tx.Exec("UPDATE products SET cost = $1 WHERE id = $2", cost, line.ProductID)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	receipt.TotalCost = totalCost
+	err = tx.QueryRow("INSERT INTO purchase_receipts (supplier, outlet_id, note, total_cost) VALUES ($1, $2, $3, $4) RETURNING id, received_at",
+		receipt.Supplier, outletID, receipt.Note, totalCost).Scan(&receipt.ID, &receipt.ReceivedAt)
+	if err != nil {
+		return err
+	}
+
+	// Baris dengan nomor lot / tanggal kedaluwarsa dicatat sebagai lot baru,
+	// movements[i] selalu milik receipt.Lines[i]
+	for i := range receipt.Lines {
+		line := &receipt.Lines[i]
+		if line.LotNumber == "" && line.ExpiresAt == nil {
+			continue
+		}
+		var lotID int
+		err := tx.QueryRow(`INSERT INTO stock_lots (outlet_id, product_id, variant_id, lot_number, expires_at, quantity, received_quantity, receipt_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $6, $7) RETURNING id`,
+			outletID, line.ProductID, line.VariantID, line.LotNumber, line.ExpiresAt, line.BaseQuantity, receipt.ID).Scan(&lotID)
+		if err != nil {
+			return err
+		}
+		line.LotID = &lotID
+		movements[i].lotID = &lotID
+	}
+
+	if err := insertStockMovements(tx, "purchase", &receipt.ID, movements); err != nil {
+		return err
+	}
+
+	if len(receipt.Lines) > 0 {
+		query := "INSERT INTO purchase_receipt_lines (receipt_id, product_id, variant_id, unit, unit_factor, quantity, base_quantity, unit_cost, subtotal, lot_id) VALUES "
+		var values []interface{}
+		var placeholders []string
+
+		const columns = 10
+		for i, l := range receipt.Lines {
+			placeholders = append(placeholders, placeholderRow(i*columns, columns))
+			values = append(values, receipt.ID, l.ProductID, l.VariantID, l.Unit, l.UnitFactor, l.Quantity, l.BaseQuantity, l.UnitCost, l.Subtotal, l.LotID)
+		}
+
+		rows, err := tx.Query(query+strings.Join(placeholders, ",")+" RETURNING id", values...)
+		if err != nil {
+			return err
+		}
+		for i := 0; rows.Next(); i++ {
+			if err := rows.Scan(&receipt.Lines[i].ID); err != nil {
+				rows.Close()
+				return err
+			}
+			receipt.Lines[i].ReceiptID = receipt.ID
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (repo *PurchaseRepository) GetAll() ([]models.PurchaseReceipt, error) {
+	rows, err := repo.db.Query("SELECT id, supplier, outlet_id, note, total_cost, received_at FROM purchase_receipts ORDER BY received_at DESC, id DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	receipts := make([]models.PurchaseReceipt, 0)
+	for rows.Next() {
+		var r models.PurchaseReceipt
+		if err := rows.Scan(&r.ID, &r.Supplier, &r.OutletID, &r.Note, &r.TotalCost, &r.ReceivedAt); err != nil {
+			return nil, err
+		}
+		receipts = append(receipts, r)
+	}
+	return receipts, rows.Err()
+}
+
+func (repo *PurchaseRepository) GetByID(id int) (*models.PurchaseReceipt, error) {
+	var r models.PurchaseReceipt
+	err := repo.db.QueryRow("SELECT id, supplier, outlet_id, note, total_cost, received_at FROM purchase_receipts WHERE id = $1", id).
+		Scan(&r.ID, &r.Supplier, &r.OutletID, &r.Note, &r.TotalCost, &r.ReceivedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrPurchaseNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.db.Query(`
+		SELECT rl.id, rl.receipt_id, rl.product_id, rl.variant_id, rl.unit, rl.unit_factor, rl.quantity, rl.base_quantity, rl.unit_cost, rl.subtotal,
+			rl.lot_id, COALESCE(sl.lot_number, ''), TO_CHAR(sl.expires_at, 'YYYY-MM-DD')
+		FROM purchase_receipt_lines rl
+		LEFT JOIN stock_lots sl ON rl.lot_id = sl.id
+		WHERE rl.receipt_id = $1
+		ORDER BY rl.id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	r.Lines = make([]models.PurchaseReceiptLine, 0)
+	for rows.Next() {
+		var l models.PurchaseReceiptLine
+		err := rows.Scan(&l.ID, &l.ReceiptID, &l.ProductID, &l.VariantID, &l.Unit, &l.UnitFactor, &l.Quantity, &l.BaseQuantity, &l.UnitCost, &l.Subtotal,
+			&l.LotID, &l.LotNumber, &l.ExpiresAt)
+		if err != nil {
+			return nil, err
+		}
+		r.Lines = append(r.Lines, l)
+	}
+	return &r, rows.Err()
+}
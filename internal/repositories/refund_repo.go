@@ -0,0 +1,389 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"kasir-api/internal/models"
+	"sort"
+	"time"
+)
+
+// movementRefund - stok kembali karena barang diretur
+const movementRefund = "refund"
+
+type refundDetail struct {
+	models.TransactionDetail
+	refundedAmount int
+}
+
+// Refund meretur sebagian atau seluruh transaksi dalam satu transaksi DB: stok dikembalikan,
+// bagian potongan poin tidak ikut diuangkan, poin yang dipakai dikembalikan dan poin yang
+// didapat ditarik secara proporsional. Retur terakhir mengambil sisa pembulatan.
+func (repo *TransactionRepository) Refund(actor string, transactionID int, req models.RefundRequest) (*models.Refund, error) {
+	tx, err := beginTx(repo.db, actor)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Kunci header supaya dua retur untuk transaksi yang sama tidak berjalan bersamaan
+	t, err := scanTransaction(tx.QueryRow("SELECT "+transactionColumns+" FROM transactions WHERE id = $1 FOR UPDATE", transactionID))
+	if err == sql.ErrNoRows {
+		return nil, ErrTransactionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Pelanggan dikunci sebelum stok, urutannya sama dengan checkout supaya tidak saling tunggu.
+	// Pelanggan yang sudah diarsipkan tetap dikunci, poinnya tetap disesuaikan.
+	if t.CustomerID != nil {
+		if _, err := tx.Exec("SELECT 1 FROM customers WHERE id = $1 FOR UPDATE", *t.CustomerID); err != nil {
+			return nil, err
+		}
+	}
+
+	details, err := lockRefundDetails(tx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	lines, err := refundLines(details, req.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	refund := &models.Refund{TransactionID: transactionID, Reason: req.Reason, Lines: lines}
+	gross, grossTotal := 0, 0
+	for _, l := range lines {
+		gross += l.Amount
+	}
+	for _, d := range details {
+		grossTotal += d.Subtotal
+	}
+
+	// Apakah setelah retur ini seluruh transaksi sudah kembali?
+	complete := true
+	for _, d := range details {
+		remaining := d.Quantity - d.RefundedQuantity
+		for _, l := range lines {
+			if l.DetailID == d.ID {
+				remaining -= l.Quantity
+			}
+		}
+		if remaining > 0 {
+			complete = false
+		}
+	}
+
+	if complete {
+		var discount, returned, reversed int
+		err := tx.QueryRow("SELECT COALESCE(SUM(points_discount), 0), COALESCE(SUM(points_returned), 0), COALESCE(SUM(points_reversed), 0) FROM refunds WHERE transaction_id = $1", transactionID).
+			Scan(&discount, &returned, &reversed)
+		if err != nil {
+			return nil, err
+		}
+		refund.PointsDiscount = t.PointsDiscount - discount
+		refund.PointsReturned = t.PointsRedeemed - returned
+		refund.PointsReversed = t.PointsEarned - reversed
+	} else {
+		refund.PointsDiscount = proportion(t.PointsDiscount, gross, grossTotal)
+		refund.PointsReturned = proportion(t.PointsRedeemed, gross, grossTotal)
+		refund.PointsReversed = proportion(t.PointsEarned, gross, grossTotal)
+	}
+	refund.Amount = gross - refund.PointsDiscount
+
+	err = tx.QueryRow(`INSERT INTO refunds (transaction_id, amount, points_discount, points_returned, points_reversed, reason)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at`,
+		transactionID, refund.Amount, refund.PointsDiscount, refund.PointsReturned, refund.PointsReversed, refund.Reason).
+		Scan(&refund.ID, &refund.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.StoreCredit && refund.Amount > 0 {
+		account := &models.StoredValueAccount{Type: models.StoreCredit, CustomerID: t.CustomerID}
+		entry := models.StoredValueEntry{Amount: refund.Amount, RefundID: &refund.ID, Note: fmt.Sprintf("retur transaksi #%d", transactionID)}
+		if err := createStoredValueAccount(tx, account, entry); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec("UPDATE refunds SET store_credit_account_id = $1 WHERE id = $2", account.ID, refund.ID); err != nil {
+			return nil, err
+		}
+		refund.StoreCredit = account
+	}
+
+	for i := range refund.Lines {
+		l := &refund.Lines[i]
+		err := tx.QueryRow("INSERT INTO refund_lines (refund_id, detail_id, quantity, amount) VALUES ($1, $2, $3, $4) RETURNING id",
+			refund.ID, l.DetailID, l.Quantity, l.Amount).Scan(&l.ID)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec("UPDATE transaction_details SET refunded_quantity = refunded_quantity + $1 WHERE id = $2", l.Quantity, l.DetailID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := restockRefund(tx, t.OutletID, transactionID, refund.ID, details, lines); err != nil {
+		return nil, err
+	}
+
+	if t.CustomerID != nil {
+		loyalty, err := getLoyaltySettings(tx)
+		if err != nil {
+			return nil, err
+		}
+		reverse := models.LoyaltyEntry{CustomerID: *t.CustomerID, Points: refund.PointsReversed, Type: models.PointsReverse, TransactionID: &transactionID, RefundID: &refund.ID}
+		if err := deductPoints(tx, reverse); err != nil {
+			return nil, err
+		}
+		ret := models.LoyaltyEntry{CustomerID: *t.CustomerID, Points: refund.PointsReturned, Type: models.PointsReturn, ExpiresAt: pointsExpiry(loyalty), TransactionID: &transactionID, RefundID: &refund.ID}
+		if err := addPoints(tx, ret); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := insertOutboxEvent(tx, models.EventTransactionRefunded, refund); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return refund, nil
+}
+
+// lockRefundDetails mengunci detail transaksi beserta nominal yang sudah pernah diretur
+func lockRefundDetails(tx *sql.Tx, transactionID int) ([]refundDetail, error) {
+	rows, err := tx.Query(`
+		SELECT d.id, d.product_id, d.product_name, d.variant_id, d.unit_factor, d.quantity, d.subtotal, d.refunded_quantity,
+			COALESCE((SELECT SUM(rl.amount) FROM refund_lines rl WHERE rl.detail_id = d.id), 0)
+		FROM transaction_details d
+		WHERE d.transaction_id = $1
+		ORDER BY d.id
+		FOR UPDATE OF d`, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var details []refundDetail
+	for rows.Next() {
+		var d refundDetail
+		err := rows.Scan(&d.ID, &d.ProductID, &d.ProductName, &d.VariantID, &d.UnitFactor, &d.Quantity, &d.Subtotal, &d.RefundedQuantity, &d.refundedAmount)
+		if err != nil {
+			return nil, err
+		}
+		details = append(details, d)
+	}
+	return details, rows.Err()
+}
+
+// refundLines memvalidasi item retur terhadap sisa tiap detail dan menghitung nominalnya.
+// Items kosong berarti semua sisa diretur. Detail yang diretur habis mengambil sisa nominalnya
+// supaya total retur tidak pernah melebihi subtotal karena pembulatan.
+func refundLines(details []refundDetail, items []models.RefundItem) ([]models.RefundLine, error) {
+	if len(items) == 0 {
+		for _, d := range details {
+			if remaining := d.Quantity - d.RefundedQuantity; remaining > 0 {
+				items = append(items, models.RefundItem{DetailID: d.ID, Quantity: remaining})
+			}
+		}
+		if len(items) == 0 {
+			return nil, fmt.Errorf("%w: transaksi sudah diretur seluruhnya", ErrRefundInvalid)
+		}
+	}
+
+	byID := make(map[int]refundDetail, len(details))
+	for _, d := range details {
+		byID[d.ID] = d
+	}
+
+	lines := make([]models.RefundLine, 0, len(items))
+	for _, item := range items {
+		d, ok := byID[item.DetailID]
+		if !ok {
+			return nil, fmt.Errorf("%w: detail %d bukan bagian dari transaksi ini", ErrRefundInvalid, item.DetailID)
+		}
+		remaining := d.Quantity - d.RefundedQuantity
+		if item.Quantity > remaining {
+			return nil, fmt.Errorf("%w: '%s' hanya bisa diretur %s lagi", ErrRefundInvalid, d.ProductName, formatQuantity(remaining))
+		}
+
+		amount := models.ProportionRupiah(d.Subtotal, item.Quantity, d.Quantity)
+		if item.Quantity == remaining {
+			amount = d.Subtotal - d.refundedAmount
+		}
+		lines = append(lines, models.RefundLine{DetailID: d.ID, ProductName: d.ProductName, Quantity: item.Quantity, Amount: amount})
+	}
+	return lines, nil
+}
+
+// restockRefund mengembalikan stok berdasarkan pergerakan stok penjualan aslinya, jadi paket
+// mengembalikan komponen yang benar-benar terpakai waktu itu. Stok kembali sebagai stok tanpa lot
+// di outlet tempat transaksinya terjadi.
+func restockRefund(tx *sql.Tx, outletID, transactionID, refundID int, details []refundDetail, lines []models.RefundLine) error {
+	type stockKey struct {
+		productID       int
+		variantID       int // 0 = tanpa varian
+		bundleProductID int // 0 = penjualan langsung
+	}
+	keyOf := func(productID int, variantID, bundleProductID *int) stockKey {
+		k := stockKey{productID: productID}
+		if variantID != nil {
+			k.variantID = *variantID
+		}
+		if bundleProductID != nil {
+			k.bundleProductID = *bundleProductID
+		}
+		return k
+	}
+
+	rows, err := tx.Query(`SELECT product_id, variant_id, bundle_product_id, -SUM(quantity)
+		FROM stock_movements
+		WHERE reference_type = 'transaction' AND reference_id = $1 AND reason IN ($2, $3)
+		GROUP BY product_id, variant_id, bundle_product_id`, transactionID, movementSale, movementBundleComponent)
+	if err != nil {
+		return err
+	}
+	sold := make(map[stockKey]models.Quantity)
+	for rows.Next() {
+		var productID int
+		var variantID, bundleProductID *int
+		var quantity models.Quantity
+		if err := rows.Scan(&productID, &variantID, &bundleProductID, &quantity); err != nil {
+			rows.Close()
+			return err
+		}
+		sold[keyOf(productID, variantID, bundleProductID)] = quantity
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Satu produk bisa muncul di beberapa baris, jadi porsi retur dihitung per produk/varian
+	type lineKey struct{ productID, variantID int }
+	soldBase := make(map[lineKey]models.Quantity)
+	refundBase := make(map[lineKey]models.Quantity)
+	byID := make(map[int]refundDetail, len(details))
+	for _, d := range details {
+		k := lineKey{d.ProductID, keyOf(d.ProductID, d.VariantID, nil).variantID}
+		soldBase[k] += d.Quantity.Mul(d.UnitFactor)
+		byID[d.ID] = d
+	}
+	for _, l := range lines {
+		d := byID[l.DetailID]
+		refundBase[lineKey{d.ProductID, keyOf(d.ProductID, d.VariantID, nil).variantID}] += l.Quantity.Mul(d.UnitFactor)
+	}
+
+	restock := make(map[stockKey]models.Quantity)
+	for k, quantity := range sold {
+		owner := lineKey{k.productID, k.variantID}
+		if k.bundleProductID != 0 {
+			owner = lineKey{k.bundleProductID, 0}
+		}
+		if refundBase[owner] == 0 || soldBase[owner] == 0 {
+			continue
+		}
+		restock[k] = quantity.Proportion(refundBase[owner], soldBase[owner])
+	}
+
+	// Urut berdasarkan produk supaya urutan kunci baris konsisten dengan checkout
+	keys := make([]stockKey, 0, len(restock))
+	for k := range restock {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].productID != keys[j].productID {
+			return keys[i].productID < keys[j].productID
+		}
+		if keys[i].variantID != keys[j].variantID {
+			return keys[i].variantID < keys[j].variantID
+		}
+		return keys[i].bundleProductID < keys[j].bundleProductID
+	})
+
+	movements := make([]stockMovement, 0, len(keys))
+	for _, k := range keys {
+		if restock[k] <= 0 {
+			continue
+		}
+		m := stockMovement{outletID: outletID, productID: k.productID, quantity: restock[k], reason: movementRefund}
+		if k.variantID != 0 {
+			variantID := k.variantID
+			m.variantID = &variantID
+		}
+		if k.bundleProductID != 0 {
+			bundleProductID := k.bundleProductID
+			m.bundleProductID = &bundleProductID
+		}
+		if err := addStock(tx, m.outletID, m.productID, m.variantID, m.quantity); err != nil {
+			return err
+		}
+		movements = append(movements, m)
+	}
+	return insertStockMovements(tx, "refund", &refundID, movements)
+}
+
+// GetRefunds - semua retur untuk satu transaksi, terlama dulu
+func (repo *TransactionRepository) GetRefunds(transactionID int) ([]models.Refund, error) {
+	rows, err := repo.db.Query(`SELECT r.id, r.transaction_id, r.amount, r.points_discount, r.points_returned, r.points_reversed, r.reason, r.created_at,
+			a.id, a.code, a.type, a.customer_id, a.balance, a.created_at
+		FROM refunds r
+		LEFT JOIN stored_value_accounts a ON a.id = r.store_credit_account_id
+		WHERE r.transaction_id = $1 ORDER BY r.id`, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	refunds := make([]models.Refund, 0)
+	index := make(map[int]int)
+	for rows.Next() {
+		var r models.Refund
+		var credit struct {
+			id, balance *int
+			code, kind  *string
+			customerID  *int
+			createdAt   *time.Time
+		}
+		err := rows.Scan(&r.ID, &r.TransactionID, &r.Amount, &r.PointsDiscount, &r.PointsReturned, &r.PointsReversed, &r.Reason, &r.CreatedAt,
+			&credit.id, &credit.code, &credit.kind, &credit.customerID, &credit.balance, &credit.createdAt)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if credit.id != nil {
+			r.StoreCredit = &models.StoredValueAccount{ID: *credit.id, Code: *credit.code, Type: *credit.kind, CustomerID: credit.customerID, Balance: *credit.balance, CreatedAt: *credit.createdAt}
+		}
+		r.Lines = make([]models.RefundLine, 0)
+		index[r.ID] = len(refunds)
+		refunds = append(refunds, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(refunds) == 0 {
+		return refunds, nil
+	}
+
+	rows, err = repo.db.Query(`SELECT rl.id, rl.refund_id, rl.detail_id, d.product_name, rl.quantity, rl.amount
+		FROM refund_lines rl
+		JOIN refunds r ON r.id = rl.refund_id
+		JOIN transaction_details d ON d.id = rl.detail_id
+		WHERE r.transaction_id = $1
+		ORDER BY rl.id`, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var l models.RefundLine
+		var refundID int
+		if err := rows.Scan(&l.ID, &refundID, &l.DetailID, &l.ProductName, &l.Quantity, &l.Amount); err != nil {
+			return nil, err
+		}
+		i := index[refundID]
+		refunds[i].Lines = append(refunds[i].Lines, l)
+	}
+	return refunds, rows.Err()
+}
@@ -0,0 +1,181 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"kasir-api/internal/models"
+	"time"
+)
+
+// activeReservation - baris stock_reservations yang masih menahan stok. Reservasi yang lewat
+// waktunya langsung tidak dihitung walaupun sweeper belum sempat menandainya expired.
+const activeReservation = "released_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())"
+
+// stockNeed - kebutuhan stok satu produk/varian dalam satuan dasar
+type stockNeed struct {
+	productID int
+	variantID *int
+	name      string
+	stock     models.Quantity
+	reserved  models.Quantity
+	quantity  models.Quantity
+}
+
+func (n stockNeed) available() models.Quantity {
+	return n.stock - n.reserved
+}
+
+// reservedQuantity - total stok yang sedang ditahan untuk satu produk/varian di satu outlet.
+// Pemanggil sudah mengunci baris produk/varian, jadi angka ini tidak berubah sampai commit.
+func reservedQuantity(tx *sql.Tx, outletID, productID int, variantID *int) (models.Quantity, error) {
+	var reserved models.Quantity
+	err := tx.QueryRow("SELECT COALESCE(SUM(quantity), 0) FROM stock_reservations WHERE outlet_id = $1 AND product_id = $2 AND variant_id IS NOT DISTINCT FROM $3 AND "+activeReservation,
+		outletID, productID, variantID).Scan(&reserved)
+	return reserved, err
+}
+
+// loadStock mengisi stok outlet dan jumlah yang ditahan untuk produk/varian baris ini, atau untuk tiap komponen paket
+func (l *checkoutLine) loadStock(tx *sql.Tx) error {
+	var err error
+	if !l.isBundle {
+		if l.stock, err = outletStock(tx, l.outletID, l.productID, l.variantID); err != nil {
+			return err
+		}
+		l.reserved, err = reservedQuantity(tx, l.outletID, l.productID, l.variantID)
+		return err
+	}
+	for i := range l.components {
+		c := &l.components[i]
+		if c.stock, err = outletStock(tx, l.outletID, c.productID, c.variantID); err != nil {
+			return err
+		}
+		if c.reserved, err = reservedQuantity(tx, l.outletID, c.productID, c.variantID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// needs menguraikan baseQuantity menjadi kebutuhan stok per produk/varian (komponen untuk paket)
+func (l *checkoutLine) needs(baseQuantity models.Quantity) []stockNeed {
+	if !l.isBundle {
+		return []stockNeed{{productID: l.productID, variantID: l.variantID, name: l.displayName(), stock: l.stock, reserved: l.reserved, quantity: baseQuantity}}
+	}
+	needs := make([]stockNeed, len(l.components))
+	for i, c := range l.components {
+		needs[i] = stockNeed{
+			productID: c.productID,
+			variantID: c.variantID,
+			name:      c.name,
+			stock:     c.stock,
+			reserved:  c.reserved,
+			quantity:  baseQuantity.Mul(c.quantity),
+		}
+	}
+	return needs
+}
+
+// reservationOwner - pemilik baris stock_reservations: baris keranjang (tanpa batas waktu)
+// atau reservasi pesanan yang berlaku sampai expiresAt
+type reservationOwner struct {
+	outletID      int
+	cartID        *int
+	cartLineID    *int
+	reservationID *int
+	expiresAt     *time.Time
+}
+
+// reserveItem mengunci produk item dengan urutan yang sama seperti checkout lalu menahan stoknya.
+// Reservasi lama milik owner yang sama harus sudah dilepas supaya tidak ikut terhitung.
+func reserveItem(tx *sql.Tx, owner reservationOwner, item models.CheckoutItem) error {
+	line, quantity, err := lockCartLine(tx, owner.outletID, item)
+	if err != nil {
+		return err
+	}
+	if err := line.loadStock(tx); err != nil {
+		return err
+	}
+
+	baseQuantity := quantity.Mul(line.unitFactor)
+	for _, n := range line.needs(baseQuantity) {
+		if n.available() < n.quantity {
+			return fmt.Errorf("%w: stok '%s' tersedia %s (ditahan %s)", ErrStockUnavailable, n.name, formatQuantity(max(n.available(), 0)), formatQuantity(n.reserved))
+		}
+		_, err := tx.Exec(`INSERT INTO stock_reservations (outlet_id, product_id, variant_id, quantity, cart_id, cart_line_id, reservation_id, expires_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+			owner.outletID, n.productID, n.variantID, n.quantity, owner.cartID, owner.cartLineID, owner.reservationID, owner.expiresAt)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func reserveCartLine(tx *sql.Tx, outletID, cartID, lineID int, item models.CheckoutItem) error {
+	return reserveItem(tx, reservationOwner{outletID: outletID, cartID: &cartID, cartLineID: &lineID}, item)
+}
+
+// lockCartLine memvalidasi baris keranjang dengan aturan yang sama seperti checkout
+// (produk aktif, varian wajib, satuan jual, presisi jumlah) dan mengembalikan jumlahnya
+func lockCartLine(tx *sql.Tx, outletID int, item models.CheckoutItem) (*checkoutLine, models.Quantity, error) {
+	line, err := lockCheckoutLine(tx, outletID, item)
+	if err != nil {
+		return nil, 0, err
+	}
+	quantity, _, err := line.price(item)
+	if err != nil {
+		return nil, 0, err
+	}
+	return line, quantity, nil
+}
+
+func releaseCartReservations(tx *sql.Tx, cartID int) error {
+	_, err := tx.Exec("DELETE FROM stock_reservations WHERE cart_id = $1", cartID)
+	return err
+}
+
+// reservedNote - keterangan tambahan di pesan stok tidak cukup kalau sebagian stok sedang ditahan
+func reservedNote(reserved models.Quantity) string {
+	if reserved <= 0 {
+		return ""
+	}
+	return ", ditahan " + formatQuantity(reserved)
+}
+
+// lockReservation mengunci reservasi pesanan dan memastikan masih aktif dan belum lewat waktunya,
+// mengembalikan outlet tempat stoknya ditahan
+func lockReservation(tx *sql.Tx, id int) (int, error) {
+	var status string
+	var expired bool
+	var outletID int
+	err := tx.QueryRow("SELECT status, expires_at <= NOW(), outlet_id FROM reservations WHERE id = $1 FOR UPDATE", id).Scan(&status, &expired, &outletID)
+	if err == sql.ErrNoRows {
+		return 0, ErrReservationNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	if status != models.ReservationActive {
+		return 0, fmt.Errorf("%w: reservasi %d berstatus %s", ErrReservationClosed, id, status)
+	}
+	if expired {
+		return 0, fmt.Errorf("%w: reservasi %d sudah lewat batas waktu", ErrReservationClosed, id)
+	}
+	return outletID, nil
+}
+
+// releaseReservationStock melepas stok yang ditahan reservasi, barisnya tetap disimpan sebagai riwayat
+func releaseReservationStock(tx *sql.Tx, id int) error {
+	_, err := tx.Exec("UPDATE stock_reservations SET released_at = NOW() WHERE reservation_id = $1 AND released_at IS NULL", id)
+	return err
+}
+
+// closeReservation melepas stok yang ditahan reservasi dan mengganti statusnya.
+// Reservasi harus sudah dikunci oleh pemanggil.
+func closeReservation(tx *sql.Tx, id int, status string, transactionID *int) error {
+	if err := releaseReservationStock(tx, id); err != nil {
+		return err
+	}
+	_, err := tx.Exec("UPDATE reservations SET status = $1, transaction_id = $2, updated_at = NOW() WHERE id = $3", status, transactionID, id)
+	return err
+}
@@ -0,0 +1,246 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"kasir-api/internal/models"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type ReservationRepository struct {
+	db *sql.DB
+}
+
+func NewReservationRepository(db *sql.DB) *ReservationRepository {
+	return &ReservationRepository{db: db}
+}
+
+const reservationColumns = "id, reference, outlet_id, status, expires_at, transaction_id, created_at, updated_at"
+
+func scanReservation(row rowScanner) (models.Reservation, error) {
+	var r models.Reservation
+	err := row.Scan(&r.ID, &r.Reference, &r.OutletID, &r.Status, &r.ExpiresAt, &r.TransactionID, &r.CreatedAt, &r.UpdatedAt)
+	return r, err
+}
+
+// GetAll - reservasi terbaru dulu, maksimal 200 baris
+func (repo *ReservationRepository) GetAll(filter models.ReservationFilter) ([]models.Reservation, error) {
+	query := "SELECT " + reservationColumns + " FROM reservations"
+	conditions := []string{}
+	args := []interface{}{}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+	if filter.Reference != "" {
+		args = append(args, filter.Reference)
+		conditions = append(conditions, fmt.Sprintf("reference = $%d", len(args)))
+	}
+	if filter.OutletID != nil {
+		args = append(args, *filter.OutletID)
+		conditions = append(conditions, fmt.Sprintf("outlet_id = $%d", len(args)))
+	}
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY id DESC LIMIT 200"
+
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reservations := make([]models.Reservation, 0)
+	for rows.Next() {
+		r, err := scanReservation(rows)
+		if err != nil {
+			return nil, err
+		}
+		reservations = append(reservations, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := repo.attachLines(reservations); err != nil {
+		return nil, err
+	}
+	return reservations, nil
+}
+
+func (repo *ReservationRepository) GetByID(id int) (*models.Reservation, error) {
+	r, err := scanReservation(repo.db.QueryRow("SELECT "+reservationColumns+" FROM reservations WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, ErrReservationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	reservations := []models.Reservation{r}
+	if err := repo.attachLines(reservations); err != nil {
+		return nil, err
+	}
+	return &reservations[0], nil
+}
+
+// attachLines - stok yang ditahan per produk/varian, baris dari item yang sama produknya digabung
+func (repo *ReservationRepository) attachLines(reservations []models.Reservation) error {
+	if len(reservations) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(reservations))
+	index := make(map[int]int, len(reservations))
+	for i := range reservations {
+		ids[i] = int64(reservations[i].ID)
+		index[reservations[i].ID] = i
+		reservations[i].Lines = make([]models.ReservationLine, 0)
+	}
+
+	rows, err := repo.db.Query(`
+		SELECT r.reservation_id, r.product_id, p.name, r.variant_id, COALESCE(v.name, ''), SUM(r.quantity)
+		FROM stock_reservations r
+		JOIN products p ON p.id = r.product_id
+		LEFT JOIN product_variants v ON v.id = r.variant_id
+		WHERE r.reservation_id = ANY($1)
+		GROUP BY r.reservation_id, r.product_id, p.name, r.variant_id, v.name
+		ORDER BY MIN(r.id)`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var reservationID int
+		var l models.ReservationLine
+		if err := rows.Scan(&reservationID, &l.ProductID, &l.ProductName, &l.VariantID, &l.VariantName, &l.Quantity); err != nil {
+			return err
+		}
+		i := index[reservationID]
+		reservations[i].Lines = append(reservations[i].Lines, l)
+	}
+	return rows.Err()
+}
+
+// Create menahan stok semua item sekaligus, gagal semua kalau satu item saja stoknya tidak cukup.
+// Produk dikunci FOR UPDATE dengan urutan yang sama seperti checkout.
+func (repo *ReservationRepository) Create(actor string, req models.ReservationRequest, ttl time.Duration) (int, error) {
+	tx, err := beginTx(repo.db, actor)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	outletID, err := resolveSalesOutlet(tx, req.OutletID)
+	if err != nil {
+		return 0, err
+	}
+
+	var id int
+	var expiresAt time.Time
+	err = tx.QueryRow("INSERT INTO reservations (reference, outlet_id, expires_at) VALUES ($1, $2, NOW() + $3 * INTERVAL '1 second') RETURNING id, expires_at",
+		req.Reference, outletID, int(ttl.Seconds())).Scan(&id, &expiresAt)
+	if isUniqueViolation(err) {
+		return 0, ErrReferenceExists
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	owner := reservationOwner{outletID: outletID, reservationID: &id, expiresAt: &expiresAt}
+	for _, item := range req.Items {
+		if err := reserveItem(tx, owner, item); err != nil {
+			return 0, err
+		}
+	}
+
+	return id, tx.Commit()
+}
+
+// Extend memperpanjang reservasi aktif menjadi ttl dari sekarang
+func (repo *ReservationRepository) Extend(actor string, id int, ttl time.Duration) error {
+	tx, err := beginTx(repo.db, actor)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := lockReservation(tx, id); err != nil {
+		return err
+	}
+
+	var expiresAt time.Time
+	err = tx.QueryRow("UPDATE reservations SET expires_at = NOW() + $1 * INTERVAL '1 second', updated_at = NOW() WHERE id = $2 RETURNING expires_at",
+		int(ttl.Seconds()), id).Scan(&expiresAt)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE stock_reservations SET expires_at = $1 WHERE reservation_id = $2 AND released_at IS NULL", expiresAt, id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Release - pesanan dibatalkan sebelum dibayar, stoknya langsung tersedia lagi
+func (repo *ReservationRepository) Release(actor string, id int) error {
+	tx, err := beginTx(repo.db, actor)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := lockReservation(tx, id); err != nil {
+		return err
+	}
+	if err := closeReservation(tx, id, models.ReservationReleased, nil); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ExpireDue menandai reservasi yang lewat waktunya sebagai expired. Stoknya sebenarnya sudah
+// tidak dihitung sejak expires_at lewat, sweeper ini hanya merapikan status dan released_at.
+// Reservasi yang sedang dikunci checkout ditunggu, lalu dilewati karena statusnya sudah consumed.
+func (repo *ReservationRepository) ExpireDue() (int, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`UPDATE reservations SET status = $1, updated_at = NOW()
+		WHERE status = $2 AND expires_at <= NOW() RETURNING id`, models.ReservationExpired, models.ReservationActive)
+	if err != nil {
+		return 0, err
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	_, err = tx.Exec("UPDATE stock_reservations SET released_at = expires_at WHERE reservation_id = ANY($1) AND released_at IS NULL", pq.Array(ids))
+	if err != nil {
+		return 0, err
+	}
+
+	return len(ids), tx.Commit()
+}
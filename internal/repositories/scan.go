@@ -0,0 +1,28 @@
+package repositories
+
+import (
+	"fmt"
+	"strings"
+)
+
+// rowScanner - *sql.Row dan *sql.Rows, supaya fungsi scan bisa dipakai untuk keduanya
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// nullString - string kosong disimpan sebagai NULL
+func nullString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// placeholderRow membuat "($n+1, $n+2, ...)" untuk bulk insert multi-row
+func placeholderRow(offset, columns int) string {
+	parts := make([]string, columns)
+	for i := range parts {
+		parts[i] = fmt.Sprintf("$%d", offset+i+1)
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
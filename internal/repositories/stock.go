@@ -0,0 +1,239 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"kasir-api/internal/models"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Alasan pergerakan stok di tabel stock_movements
+const (
+	movementSale            = "sale"
+	movementBundleComponent = "bundle_component"
+	movementPurchase        = "purchase"
+	// movementImport - stok diatur lewat impor katalog
+	movementImport = "import"
+	// movementAdjustment - koreksi stok outlet dari hasil hitung fisik
+	movementAdjustment = "adjustment"
+)
+
+// stockMovement - pergerakan stok yang dikumpulkan selama transaksi DB,
+// ditulis sekaligus setelah dokumen referensinya (transaksi, penerimaan) punya ID
+type stockMovement struct {
+	outletID        int
+	productID       int
+	variantID       *int
+	quantity        models.Quantity
+	reason          string
+	bundleProductID *int
+	lotID           *int
+}
+
+// deductStock mengurangi stok produk (atau varian kalau variantID diisi) di satu outlet.
+// Kolom stock di products/product_variants adalah total semua outlet dan ikut diperbarui.
+// Baris yang bersangkutan harus sudah dikunci FOR UPDATE oleh pemanggil.
+func deductStock(tx *sql.Tx, outletID, productID int, variantID *int, quantity models.Quantity) error {
+	_, err := tx.Exec(`INSERT INTO outlet_stock (outlet_id, product_id, variant_id, quantity) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (outlet_id, product_id, COALESCE(variant_id, 0)) DO UPDATE SET quantity = outlet_stock.quantity + EXCLUDED.quantity`,
+		outletID, productID, variantID, -quantity)
+	if err != nil {
+		return err
+	}
+
+	if variantID != nil {
+		_, err := tx.Exec("UPDATE product_variants SET stock = stock - $1, version = version + 1 WHERE id = $2", quantity, *variantID)
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE products SET stock = stock - $1, version = version + 1 WHERE id = $2", quantity, productID)
+	return err
+}
+
+// addStock menambah stok produk, atau stok varian kalau variantID diisi, di satu outlet
+func addStock(tx *sql.Tx, outletID, productID int, variantID *int, quantity models.Quantity) error {
+	return deductStock(tx, outletID, productID, variantID, -quantity)
+}
+
+// outletStock - stok satu produk/varian di satu outlet, baris yang belum ada berarti 0
+func outletStock(tx *sql.Tx, outletID, productID int, variantID *int) (models.Quantity, error) {
+	var stock models.Quantity
+	err := tx.QueryRow("SELECT COALESCE(SUM(quantity), 0) FROM outlet_stock WHERE outlet_id = $1 AND product_id = $2 AND variant_id IS NOT DISTINCT FROM $3",
+		outletID, productID, variantID).Scan(&stock)
+	return stock, err
+}
+
+// syncDefaultOutletStock membukukan perubahan stok dari katalog (create/update produk & varian,
+// batch, impor) ke outlet utama: stok outlet utama = total - stok outlet lain.
+// Ditolak kalau total baru lebih kecil dari stok yang ada di outlet lain.
+func syncDefaultOutletStock(tx *sql.Tx, productIDs []int64) error {
+	if len(productIDs) == 0 {
+		return nil
+	}
+
+	_, err := tx.Exec(`
+		INSERT INTO outlet_stock (outlet_id, product_id, variant_id, quantity)
+		SELECT o.id, p.id, NULL, p.stock - COALESCE((SELECT SUM(s.quantity) FROM outlet_stock s
+			WHERE s.product_id = p.id AND s.variant_id IS NULL AND s.outlet_id <> o.id), 0)
+		FROM products p CROSS JOIN outlets o
+		WHERE o.is_default AND p.id = ANY($1)
+		UNION ALL
+		SELECT o.id, v.product_id, v.id, v.stock - COALESCE((SELECT SUM(s.quantity) FROM outlet_stock s
+			WHERE s.variant_id = v.id AND s.outlet_id <> o.id), 0)
+		FROM product_variants v CROSS JOIN outlets o
+		WHERE o.is_default AND v.product_id = ANY($1)
+		ON CONFLICT (outlet_id, product_id, COALESCE(variant_id, 0)) DO UPDATE SET quantity = EXCLUDED.quantity`, pq.Array(productIDs))
+	if err != nil {
+		return err
+	}
+
+	var name string
+	err = tx.QueryRow(`
+		SELECT p.name || COALESCE(' (' || v.name || ')', '')
+		FROM outlet_stock s
+		JOIN outlets o ON o.id = s.outlet_id AND o.is_default
+		JOIN products p ON p.id = s.product_id
+		LEFT JOIN product_variants v ON v.id = s.variant_id
+		WHERE s.product_id = ANY($1) AND s.quantity < 0
+		LIMIT 1`, pq.Array(productIDs)).Scan(&name)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: stok '%s' lebih kecil dari stok di outlet lain, koreksi lewat stok per outlet", ErrOutletStock, name)
+}
+
+// insertCatalogMovements mencatat perubahan stok lewat katalog (create/PUT/PATCH/batch produk & varian)
+// sebagai koreksi di outlet default, tempat syncDefaultOutletStock membukukan selisihnya.
+// Dari movements hanya productID, variantID dan quantity (selisih) yang dipakai, selisih nol dilewati.
+func insertCatalogMovements(tx *sql.Tx, referenceType string, referenceID *int, movements []stockMovement) error {
+	var changed []stockMovement
+	for _, m := range movements {
+		if m.quantity != 0 {
+			changed = append(changed, m)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	outletID, err := resolveOutlet(tx, nil)
+	if err != nil {
+		return err
+	}
+	for i := range changed {
+		changed[i].outletID = outletID
+		changed[i].reason = movementAdjustment
+	}
+	return insertStockMovements(tx, referenceType, referenceID, changed)
+}
+
+// insertStockMovements menulis semua pergerakan stok untuk satu dokumen dengan satu query,
+// referenceID nil untuk pergerakan tanpa dokumen (mis. impor katalog)
+func insertStockMovements(tx *sql.Tx, referenceType string, referenceID *int, movements []stockMovement) error {
+	if len(movements) == 0 {
+		return nil
+	}
+
+	query := "INSERT INTO stock_movements (outlet_id, product_id, variant_id, quantity, reason, reference_type, reference_id, bundle_product_id, lot_id) VALUES "
+	var values []interface{}
+	var placeholders []string
+
+	const columns = 9
+	for i, m := range movements {
+		placeholders = append(placeholders, placeholderRow(i*columns, columns))
+		values = append(values, m.outletID, m.productID, m.variantID, m.quantity, m.reason, referenceType, referenceID, m.bundleProductID, m.lotID)
+	}
+
+	_, err := tx.Exec(query+strings.Join(placeholders, ","), values...)
+	return err
+}
+
+type lockedLot struct {
+	id        int
+	number    string
+	expiresAt string
+	quantity  models.Quantity
+	expired   bool
+}
+
+// deductStockFEFO mengurangi stok lalu sisa lot, lot yang paling cepat kedaluwarsa dipakai dulu.
+// Kekurangan yang tidak tertutup lot diambil dari stok tanpa lot. Kalau blockExpired, lot yang
+// sudah lewat tanggal dilewati dan penjualan ditolak bila stok layak jual tidak cukup.
+// Hanya lot di outlet m.outletID yang dipakai, stock adalah stok outlet tersebut.
+// Pemanggil sudah mengunci baris produk/varian dan memastikan stock >= quantity.
+// Mengembalikan satu pergerakan per lot berdasarkan template m.
+func deductStockFEFO(tx *sql.Tx, m stockMovement, name string, stock, quantity models.Quantity, blockExpired bool) ([]stockMovement, error) {
+	rows, err := tx.Query(`
+		SELECT id, lot_number, COALESCE(TO_CHAR(expires_at, 'YYYY-MM-DD'), ''), quantity, COALESCE(expires_at < CURRENT_DATE, FALSE)
+		FROM stock_lots
+		WHERE outlet_id = $1 AND product_id = $2 AND variant_id IS NOT DISTINCT FROM $3 AND quantity > 0
+		ORDER BY expires_at NULLS LAST, id
+		FOR UPDATE`, m.outletID, m.productID, m.variantID)
+	if err != nil {
+		return nil, err
+	}
+	var lots []lockedLot
+	for rows.Next() {
+		var l lockedLot
+		if err := rows.Scan(&l.id, &l.number, &l.expiresAt, &l.quantity, &l.expired); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		lots = append(lots, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if blockExpired {
+		usable := stock
+		var firstExpired *lockedLot
+		for i := range lots {
+			if lots[i].expired {
+				usable -= lots[i].quantity
+				if firstExpired == nil {
+					firstExpired = &lots[i]
+				}
+			}
+		}
+		if firstExpired != nil && usable < quantity {
+			return nil, fmt.Errorf("%w: stok layak jual '%s' tinggal %s (lot '%s' kedaluwarsa %s)",
+				ErrLotExpired, name, formatQuantity(max(usable, 0)), firstExpired.number, firstExpired.expiresAt)
+		}
+	}
+
+	if err := deductStock(tx, m.outletID, m.productID, m.variantID, quantity); err != nil {
+		return nil, err
+	}
+
+	movements := make([]stockMovement, 0, 1)
+	remaining := quantity
+	for _, l := range lots {
+		if remaining <= 0 {
+			break
+		}
+		if blockExpired && l.expired {
+			continue
+		}
+		take := min(l.quantity, remaining)
+		if _, err := tx.Exec("UPDATE stock_lots SET quantity = quantity - $1 WHERE id = $2", take, l.id); err != nil {
+			return nil, err
+		}
+		lotMovement := m
+		lotMovement.lotID = &l.id
+		lotMovement.quantity = -take
+		movements = append(movements, lotMovement)
+		remaining -= take
+	}
+	if remaining > 0 {
+		m.quantity = -remaining
+		movements = append(movements, m)
+	}
+	return movements, nil
+}
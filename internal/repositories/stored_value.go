@@ -0,0 +1,162 @@
+package repositories
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"fmt"
+	"kasir-api/internal/models"
+	"sort"
+)
+
+// storedValueAlphabet - tanpa 0/O dan 1/I supaya kode mudah dibaca dari kartu
+const storedValueAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// newStoredValueCode membuat kode acak XXXX-XXXX-XXXX (60 bit)
+func newStoredValueCode() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	code := make([]byte, 0, 14)
+	for i, c := range b {
+		if i > 0 && i%4 == 0 {
+			code = append(code, '-')
+		}
+		code = append(code, storedValueAlphabet[int(c)%len(storedValueAlphabet)])
+	}
+	return string(code), nil
+}
+
+const storedValueColumns = "id, code, type, customer_id, balance, created_at"
+
+func scanStoredValueAccount(row rowScanner) (models.StoredValueAccount, error) {
+	var a models.StoredValueAccount
+	err := row.Scan(&a.ID, &a.Code, &a.Type, &a.CustomerID, &a.Balance, &a.CreatedAt)
+	return a, err
+}
+
+// createStoredValueAccount membuat akun dengan saldo awal entry.Amount. Kode kosong dibuat acak.
+func createStoredValueAccount(tx *sql.Tx, account *models.StoredValueAccount, entry models.StoredValueEntry) error {
+	if account.Code == "" {
+		code, err := newStoredValueCode()
+		if err != nil {
+			return err
+		}
+		account.Code = code
+	}
+
+	err := tx.QueryRow(`INSERT INTO stored_value_accounts (code, type, customer_id) VALUES ($1, $2, $3)
+		RETURNING `+storedValueColumns, account.Code, account.Type, account.CustomerID).
+		Scan(&account.ID, &account.Code, &account.Type, &account.CustomerID, &account.Balance, &account.CreatedAt)
+	if isUniqueViolation(err) {
+		return ErrStoredValueCodeExists
+	}
+	if isForeignKeyViolation(err) {
+		return ErrCustomerNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	entry.Type = models.StoredValueIssue
+	return postStoredValue(tx, account, entry)
+}
+
+// lockStoredValueAccount mengunci akun FOR UPDATE supaya saldo yang sama tidak terpakai dua kali
+func lockStoredValueAccount(tx *sql.Tx, code string) (*models.StoredValueAccount, error) {
+	a, err := scanStoredValueAccount(tx.QueryRow("SELECT "+storedValueColumns+" FROM stored_value_accounts WHERE code = $1 FOR UPDATE", code))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", ErrStoredValueNotFound, code)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// postStoredValue menulis entri buku besar dan memperbarui saldo akun yang sudah dikunci.
+// entry.Amount bertanda: positif untuk issue/top up, negatif untuk penukaran.
+func postStoredValue(tx *sql.Tx, account *models.StoredValueAccount, entry models.StoredValueEntry) error {
+	balance := account.Balance + entry.Amount
+	if balance < 0 {
+		return fmt.Errorf("%w: saldo %s tinggal Rp%d", ErrInsufficientBalance, account.Code, account.Balance)
+	}
+
+	if _, err := tx.Exec("UPDATE stored_value_accounts SET balance = $1 WHERE id = $2", balance, account.ID); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`INSERT INTO stored_value_ledger (account_id, amount, type, balance_after, transaction_id, refund_id, note)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`, account.ID, entry.Amount, entry.Type, balance, entry.TransactionID, entry.RefundID, entry.Note)
+	if err != nil {
+		return err
+	}
+	account.Balance = balance
+	return nil
+}
+
+// preparePayments memvalidasi pembayaran terhadap total yang harus dibayar dan mengunci akun
+// saldo tersimpan (urut kode supaya checkout bersamaan tidak deadlock). Kosong berarti tunai semua.
+func preparePayments(tx *sql.Tx, payments []models.CheckoutPayment, total int) ([]models.TransactionPayment, []*models.StoredValueAccount, error) {
+	if len(payments) == 0 {
+		if total == 0 {
+			return []models.TransactionPayment{}, nil, nil
+		}
+		return []models.TransactionPayment{{Method: models.PaymentCash, Amount: total}}, make([]*models.StoredValueAccount, 1), nil
+	}
+
+	sum := 0
+	for _, p := range payments {
+		sum += p.Amount
+	}
+	if sum != total {
+		return nil, nil, fmt.Errorf("%w: jumlah pembayaran Rp%d, total yang harus dibayar Rp%d", ErrPaymentMismatch, sum, total)
+	}
+
+	order := make([]int, len(payments))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool { return payments[order[i]].Code < payments[order[j]].Code })
+
+	result := make([]models.TransactionPayment, len(payments))
+	accounts := make([]*models.StoredValueAccount, len(payments))
+	for _, i := range order {
+		p := payments[i]
+		result[i] = models.TransactionPayment{Method: p.Method, Amount: p.Amount}
+		if p.Method != models.PaymentStoredValue {
+			continue
+		}
+
+		account, err := lockStoredValueAccount(tx, p.Code)
+		if err != nil {
+			return nil, nil, err
+		}
+		if account.Balance < p.Amount {
+			return nil, nil, fmt.Errorf("%w: saldo %s tinggal Rp%d", ErrInsufficientBalance, account.Code, account.Balance)
+		}
+		accounts[i] = account
+		result[i].AccountID = &account.ID
+		result[i].Code = account.Code
+	}
+	return result, accounts, nil
+}
+
+// insertPayments menulis rincian pembayaran dan memotong saldo akun yang dipakai
+func insertPayments(tx *sql.Tx, transactionID int, payments []models.TransactionPayment, accounts []*models.StoredValueAccount) error {
+	for i := range payments {
+		p := &payments[i]
+		err := tx.QueryRow("INSERT INTO transaction_payments (transaction_id, method, account_id, amount) VALUES ($1, $2, $3, $4) RETURNING id",
+			transactionID, p.Method, p.AccountID, p.Amount).Scan(&p.ID)
+		if err != nil {
+			return err
+		}
+		if accounts[i] == nil {
+			continue
+		}
+		entry := models.StoredValueEntry{Amount: -p.Amount, Type: models.StoredValueRedeem, TransactionID: &transactionID}
+		if err := postStoredValue(tx, accounts[i], entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
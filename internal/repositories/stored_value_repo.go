@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"database/sql"
+	"kasir-api/internal/models"
+)
+
+type StoredValueRepository struct {
+	db *sql.DB
+}
+
+func NewStoredValueRepository(db *sql.DB) *StoredValueRepository {
+	return &StoredValueRepository{db: db}
+}
+
+// GetAll - customerID nil berarti semua akun
+func (repo *StoredValueRepository) GetAll(customerID *int) ([]models.StoredValueAccount, error) {
+	query := "SELECT " + storedValueColumns + " FROM stored_value_accounts"
+	args := []interface{}{}
+	if customerID != nil {
+		query += " WHERE customer_id = $1"
+		args = append(args, *customerID)
+	}
+	query += " ORDER BY id"
+
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	accounts := make([]models.StoredValueAccount, 0)
+	for rows.Next() {
+		a, err := scanStoredValueAccount(rows)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, a)
+	}
+	return accounts, rows.Err()
+}
+
+// GetByCode - akun beserta seluruh buku besarnya
+func (repo *StoredValueRepository) GetByCode(code string) (*models.StoredValueAccount, error) {
+	a, err := scanStoredValueAccount(repo.db.QueryRow("SELECT "+storedValueColumns+" FROM stored_value_accounts WHERE code = $1", code))
+	if err == sql.ErrNoRows {
+		return nil, ErrStoredValueNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.db.Query(`SELECT id, account_id, amount, type, balance_after, transaction_id, refund_id, note, created_at
+		FROM stored_value_ledger WHERE account_id = $1 ORDER BY id`, a.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	a.Ledger = make([]models.StoredValueEntry, 0)
+	for rows.Next() {
+		var e models.StoredValueEntry
+		err := rows.Scan(&e.ID, &e.AccountID, &e.Amount, &e.Type, &e.BalanceAfter, &e.TransactionID, &e.RefundID, &e.Note, &e.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		a.Ledger = append(a.Ledger, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// Issue membuat kartu hadiah atau store credit baru dengan saldo awal
+func (repo *StoredValueRepository) Issue(actor string, req models.IssueStoredValueRequest) (*models.StoredValueAccount, error) {
+	tx, err := beginTx(repo.db, actor)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	account := &models.StoredValueAccount{Code: req.Code, Type: req.Type, CustomerID: req.CustomerID}
+	entry := models.StoredValueEntry{Amount: req.Amount, Note: req.Note}
+	if err := createStoredValueAccount(tx, account, entry); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// Post menjalankan top up (amount positif) atau penukaran manual (amount negatif) pada akun yang dikunci
+func (repo *StoredValueRepository) Post(actor string, code string, entry models.StoredValueEntry) (*models.StoredValueAccount, error) {
+	tx, err := beginTx(repo.db, actor)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	account, err := lockStoredValueAccount(tx, code)
+	if err != nil {
+		return nil, err
+	}
+	if err := postStoredValue(tx, account, entry); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
@@ -0,0 +1,126 @@
+package repositories
+
+import (
+	"database/sql"
+	"kasir-api/internal/models"
+)
+
+type TableRepository struct {
+	db *sql.DB
+}
+
+func NewTableRepository(db *sql.DB) *TableRepository {
+	return &TableRepository{db: db}
+}
+
+// tableColumns harus sama urutannya dengan scanTable, open_cart_id diambil dari pesanan yang masih berjalan
+const tableColumns = `id, name, seats, version, deleted_at, created_at,
+	(SELECT MIN(c.id) FROM carts c WHERE c.table_id = dining_tables.id AND c.status IN ('open', 'held'))`
+
+func scanTable(row rowScanner) (models.DiningTable, error) {
+	var t models.DiningTable
+	err := row.Scan(&t.ID, &t.Name, &t.Seats, &t.Version, &t.DeletedAt, &t.CreatedAt, &t.OpenCartID)
+	return t, err
+}
+
+func (repo *TableRepository) GetAll(includeArchived bool) ([]models.DiningTable, error) {
+	query := "SELECT " + tableColumns + " FROM dining_tables"
+	if !includeArchived {
+		query += " WHERE deleted_at IS NULL"
+	}
+	query += " ORDER BY name, id"
+
+	rows, err := repo.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tables := make([]models.DiningTable, 0)
+	for rows.Next() {
+		t, err := scanTable(rows)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, t)
+	}
+	return tables, rows.Err()
+}
+
+func (repo *TableRepository) GetByID(id int) (*models.DiningTable, error) {
+	t, err := scanTable(repo.db.QueryRow("SELECT "+tableColumns+" FROM dining_tables WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, ErrTableNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (repo *TableRepository) Create(actor string, table *models.DiningTable) error {
+	err := queryRowAs(repo.db, actor, "INSERT INTO dining_tables (name, seats) VALUES ($1, $2) RETURNING id, version, created_at",
+		[]interface{}{table.Name, table.Seats}, &table.ID, &table.Version, &table.CreatedAt)
+	if isUniqueViolation(err) {
+		return ErrTableExists
+	}
+	return err
+}
+
+// Update - table.Version berisi versi yang diharapkan (0 = tanpa cek versi)
+func (repo *TableRepository) Update(actor string, table *models.DiningTable) error {
+	query := `UPDATE dining_tables SET name = $1, seats = $2, version = version + 1
+		WHERE id = $3 AND ($4 = 0 OR version = $4)
+		RETURNING version`
+	err := queryRowAs(repo.db, actor, query, []interface{}{table.Name, table.Seats, table.ID, table.Version}, &table.Version)
+	if err == sql.ErrNoRows {
+		return repo.missingOrStale(table.ID)
+	}
+	if isUniqueViolation(err) {
+		return ErrTableExists
+	}
+	return err
+}
+
+// Delete - soft delete, transaksi lama tetap terhubung ke meja
+func (repo *TableRepository) Delete(actor string, id, version int) error {
+	query := `UPDATE dining_tables SET deleted_at = COALESCE(deleted_at, NOW()), version = version + 1
+		WHERE id = $1 AND ($2 = 0 OR version = $2)`
+	result, err := execAs(repo.db, actor, query, id, version)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return repo.missingOrStale(id)
+	}
+	return nil
+}
+
+func (repo *TableRepository) missingOrStale(id int) error {
+	var exists bool
+	err := repo.db.QueryRow("SELECT EXISTS (SELECT 1 FROM dining_tables WHERE id = $1)", id).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrTableNotFound
+	}
+	return ErrVersionMismatch
+}
+
+// requireTable memastikan meja ada dan belum diarsipkan
+func requireTable(tx *sql.Tx, id int) error {
+	var exists bool
+	err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM dining_tables WHERE id = $1 AND deleted_at IS NULL)", id).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrTableNotFound
+	}
+	return nil
+}
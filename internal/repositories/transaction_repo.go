@@ -2,104 +2,468 @@ package repositories
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"kasir-api/internal/models"
 	"strings"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 type TransactionRepository struct {
 	db *sql.DB
+	// BlockExpiredLots - tolak penjualan dari lot yang sudah lewat tanggal kedaluwarsa
+	BlockExpiredLots bool
+	// LowStockThreshold - stok outlet di bawah angka ini memicu event product.stock_low, 0 = nonaktif
+	LowStockThreshold models.Quantity
 }
 
 func NewTransactionRepository(db *sql.DB) *TransactionRepository {
 	return &TransactionRepository{db: db}
 }
 
-func (repo *TransactionRepository) CreateTransaction(items []models.CheckoutItem) (*models.Transaction, error) {
-	tx, err := repo.db.Begin()
+func (repo *TransactionRepository) CreateTransaction(actor string, req models.CheckoutRequest) (*models.Transaction, error) {
+	tx, err := beginTx(repo.db, actor)
 	if err != nil {
 		return nil, err
 	}
 	defer tx.Rollback()
 
+	transaction, err := repo.createTransaction(tx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+// createTransaction menjalankan seluruh checkout di dalam tx milik pemanggil,
+// supaya dokumen lain (mis. keranjang) bisa ditutup dalam transaksi DB yang sama
+func (repo *TransactionRepository) createTransaction(tx *sql.Tx, req models.CheckoutRequest) (*models.Transaction, error) {
+	// Reservasi pesanan dikunci paling awal dan stoknya dilepas sebelum produk dikunci,
+	// supaya stok yang ditahan untuk pesanan ini bisa dipakai oleh checkout-nya sendiri.
+	// Checkout reservasi selalu di outlet tempat stoknya ditahan.
+	outletRequest := req.OutletID
+	if req.ReservationID != nil {
+		reservationOutlet, err := lockReservation(tx, *req.ReservationID)
+		if err != nil {
+			return nil, err
+		}
+		if outletRequest != nil && *outletRequest != reservationOutlet {
+			return nil, fmt.Errorf("%w: reservasi %d milik outlet %d", ErrReservationClosed, *req.ReservationID, reservationOutlet)
+		}
+		outletRequest = &reservationOutlet
+		if err := releaseReservationStock(tx, *req.ReservationID); err != nil {
+			return nil, err
+		}
+	}
+	outletID, err := resolveSalesOutlet(tx, outletRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pelanggan dikunci dulu supaya saldo poinnya tidak berubah selama checkout
+	var pointsBalance int
+	if req.CustomerID != nil {
+		pointsBalance, err = lockCustomer(tx, *req.CustomerID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if req.TableID != nil {
+		if err := requireTable(tx, *req.TableID); err != nil {
+			return nil, err
+		}
+	}
+	loyalty, err := getLoyaltySettings(tx)
+	if err != nil {
+		return nil, err
+	}
+
 	totalAmount := 0
 	details := make([]models.TransactionDetail, 0)
+	movements := make([]stockMovement, 0)
+	lineCategories := make([]*int, 0, len(req.Items))
 
-	for _, item := range items {
-		var productPrice, stock int
-		var productName string
+	for _, item := range req.Items {
+		// 1. Kunci baris produk/varian dengan FOR UPDATE (mencegah race condition)
+		line, err := lockCheckoutLine(tx, outletID, item)
+		if err != nil {
+			return nil, err
+		}
+		if err := line.loadStock(tx); err != nil {
+			return nil, err
+		}
 
-		// 1. Tambahkan FOR UPDATE untuk mengunci baris produk (mencegah race condition)
-		err := tx.QueryRow("SELECT name, price, stock FROM products WHERE id = $1 FOR UPDATE", item.ProductID).Scan(&productName, &productPrice, &stock)
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("produk dengan ID %d tidak ditemukan", item.ProductID)
+		quantity, subtotal, err := line.price(item)
+		if err != nil {
+			return nil, err
 		}
+		// Harga tambahan modifier dihitung per satuan jual, sama seperti harga produknya
+		modifiers, err := lineModifiers(tx, item.ProductID, item.ModifierIDs)
 		if err != nil {
 			return nil, err
 		}
-
-		// 2. Validasi stok sebelum lanjut
-		if stock < item.Quantity {
-			return nil, fmt.Errorf("stok produk '%s' tidak cukup (sisa: %d)", productName, stock)
+		unitPrice := line.unitPrice
+		if delta := modifierDelta(modifiers); delta != 0 {
+			unitPrice += delta
+			if unitPrice < 0 {
+				return nil, fmt.Errorf("harga '%s' dengan modifier tidak boleh negatif", line.displayName())
+			}
+			subtotal += quantity.Rupiah(delta)
 		}
-
-		subtotal := productPrice * item.Quantity
 		totalAmount += subtotal
 
-		// 3. Update stok
-		_, err = tx.Exec("UPDATE products SET stock = stock - $1 WHERE id = $2", item.Quantity, item.ProductID)
+		// 2 & 3. Validasi lalu kurangi stok (dalam satuan dasar, mis. 1 dus = 40 pcs).
+		// Untuk paket, stok semua komponennya yang dikurangi.
+		baseQuantity := quantity.Mul(line.unitFactor)
+		lineMovements, err := line.deduct(tx, baseQuantity, repo.BlockExpiredLots)
 		if err != nil {
 			return nil, err
 		}
+		movements = append(movements, lineMovements...)
+		lineCategories = append(lineCategories, line.categoryID)
 
 		details = append(details, models.TransactionDetail{
 			ProductID:   item.ProductID,
-			ProductName: productName,
-			Quantity:    item.Quantity,
+			ProductName: line.productName,
+			VariantID:   line.variantID,
+			VariantName: line.variantName,
+			SKU:         line.sku,
+			Unit:        line.unit,
+			UnitFactor:  line.unitFactor,
+			UnitPrice:   unitPrice,
+			Quantity:    quantity,
 			Subtotal:    subtotal,
+			Modifiers:   modifiers,
 		})
 	}
 
+	// Poin yang ditukar menjadi potongan, poin baru dihitung dari yang benar-benar dibayar
+	pointsDiscount := 0
+	if req.RedeemPoints > 0 {
+		pointsDiscount, err = redeemDiscount(req, loyalty, pointsBalance, totalAmount)
+		if err != nil {
+			return nil, err
+		}
+	}
+	subtotals := make([]int, len(details))
+	for i, d := range details {
+		subtotals[i] = d.Subtotal
+	}
+	paid := allocate(pointsDiscount, subtotals)
+	for i := range paid {
+		paid[i] = subtotals[i] - paid[i]
+	}
+	pointsEarned := 0
+	if req.CustomerID != nil {
+		pointsEarned, err = earnedPoints(tx, loyalty, lineCategories, paid)
+		if err != nil {
+			return nil, err
+		}
+	}
+	totalAmount -= pointsDiscount
+
+	// Akun saldo tersimpan dikunci setelah produk, sama untuk semua checkout
+	payments, accounts, err := preparePayments(tx, req.Payments, totalAmount)
+	if err != nil {
+		return nil, err
+	}
+
 	// 4. Simpan Header Transaksi
 	var transactionID int
-	err = tx.QueryRow("INSERT INTO transactions (total_amount) VALUES ($1) RETURNING id", totalAmount).Scan(&transactionID)
+	var createdAt time.Time
+	err = tx.QueryRow(`INSERT INTO transactions (total_amount, outlet_id, customer_id, table_id, points_redeemed, points_discount, points_earned)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, created_at`, totalAmount, outletID, req.CustomerID, req.TableID, req.RedeemPoints, pointsDiscount, pointsEarned).
+		Scan(&transactionID, &createdAt)
 	if err != nil {
 		return nil, err
 	}
+	if req.ReservationID != nil {
+		if err := closeReservation(tx, *req.ReservationID, models.ReservationConsumed, &transactionID); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.CustomerID != nil {
+		redeem := models.LoyaltyEntry{CustomerID: *req.CustomerID, Points: req.RedeemPoints, Type: models.PointsRedeem, TransactionID: &transactionID}
+		if err := deductPoints(tx, redeem); err != nil {
+			return nil, err
+		}
+		earn := models.LoyaltyEntry{
+			CustomerID:    *req.CustomerID,
+			Points:        pointsEarned,
+			Type:          models.PointsEarn,
+			ExpiresAt:     pointsExpiry(loyalty),
+			TransactionID: &transactionID,
+			Note:          fmt.Sprintf("belanja Rp%d", totalAmount),
+		}
+		if err := addPoints(tx, earn); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := insertPayments(tx, transactionID, payments, accounts); err != nil {
+		return nil, err
+	}
+
+	if err := insertStockMovements(tx, "transaction", &transactionID, movements); err != nil {
+		return nil, err
+	}
 
 	// 5. BULK INSERT Details (Satu Query untuk semua detail)
 	if len(details) > 0 {
-		query := "INSERT INTO transaction_details (transaction_id, product_id, quantity, subtotal) VALUES "
+		// Nama produk, harga satuan & kategori disimpan sebagai snapshot, bukan dibaca ulang dari tabel products
+		query := "INSERT INTO transaction_details (transaction_id, product_id, product_name, variant_id, variant_name, sku, unit, unit_factor, unit_price, quantity, subtotal, modifiers, category_id) VALUES "
 		var values []interface{}
 		var placeholders []string
 
+		const columns = 13
 		for i, d := range details {
-			n := i * 4
-			placeholders = append(placeholders, fmt.Sprintf("($%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4))
-			values = append(values, transactionID, d.ProductID, d.Quantity, d.Subtotal)
+			modifiers, err := json.Marshal(d.Modifiers)
+			if err != nil {
+				return nil, err
+			}
+			placeholders = append(placeholders, placeholderRow(i*columns, columns))
+			values = append(values, transactionID, d.ProductID, d.ProductName, d.VariantID, nullString(d.VariantName), d.SKU, d.Unit, d.UnitFactor, d.UnitPrice, d.Quantity, d.Subtotal, modifiers, lineCategories[i])
 		}
 
-		query += strings.Join(placeholders, ",")
-		_, err = tx.Exec(query, values...)
+		query += strings.Join(placeholders, ",") + " RETURNING id"
+		rows, err := tx.Query(query, values...)
 		if err != nil {
 			return nil, err
 		}
+		for i := 0; rows.Next(); i++ {
+			if err := rows.Scan(&details[i].ID); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			details[i].TransactionID = transactionID
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
 	}
 
-	if err := tx.Commit(); err != nil {
+	transaction := &models.Transaction{
+		ID:             transactionID,
+		TotalAmount:    totalAmount,
+		OutletID:       outletID,
+		CustomerID:     req.CustomerID,
+		TableID:        req.TableID,
+		PointsRedeemed: req.RedeemPoints,
+		PointsDiscount: pointsDiscount,
+		PointsEarned:   pointsEarned,
+		CreatedAt:      createdAt,
+		Details:        details,
+		Payments:       payments,
+	}
+
+	// Event webhook lewat outbox, baru terkirim setelah checkout ini commit
+	if err := insertOutboxEvent(tx, models.EventTransactionCreated, transaction); err != nil {
 		return nil, err
 	}
+	if err := insertStockLowEvents(tx, repo.LowStockThreshold, transactionID, movements); err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+// redeemDiscount memvalidasi penukaran poin dan mengembalikan potongan rupiahnya
+func redeemDiscount(req models.CheckoutRequest, loyalty models.LoyaltySettings, balance, totalAmount int) (int, error) {
+	if req.CustomerID == nil {
+		return 0, redeemError("penukaran poin butuh customer_id")
+	}
+	if req.RedeemPoints < loyalty.MinRedeemPoints {
+		return 0, redeemError("minimal penukaran %d poin", loyalty.MinRedeemPoints)
+	}
+	if req.RedeemPoints > balance {
+		return 0, redeemError("saldo poin tinggal %d", balance)
+	}
+	discount := req.RedeemPoints * loyalty.PointValue
+	if discount > totalAmount {
+		return 0, redeemError("potongan Rp%d melebihi total belanja Rp%d, maksimal %d poin", discount, totalAmount, totalAmount/loyalty.PointValue)
+	}
+	return discount, nil
+}
+
+// earnedPoints menghitung poin dari nominal yang dibayar per baris dengan tarif kategori dari database
+func earnedPoints(tx *sql.Tx, loyalty models.LoyaltySettings, categories []*int, paid []int) (int, error) {
+	var categoryIDs []int64
+	for _, c := range categories {
+		if c != nil {
+			categoryIDs = append(categoryIDs, int64(*c))
+		}
+	}
+	rates, err := earnRates(tx, categoryIDs)
+	if err != nil {
+		return 0, err
+	}
+	return pointsByRate(loyalty.RupiahPerPoint, rates, categories, paid), nil
+}
+
+// pointsByRate mengelompokkan baris per tarif (tarif kategori terdekat dari rates, atau tarif umum)
+// lalu tiap kelompok dibulatkan ke bawah. Tarif 0 berarti baris tidak menghasilkan poin.
+func pointsByRate(rupiahPerPoint int, rates map[int]int, categories []*int, paid []int) int {
+	amountByRate := make(map[int]int)
+	for i, c := range categories {
+		rate := rupiahPerPoint
+		if c != nil {
+			if r, ok := rates[*c]; ok {
+				rate = r
+			}
+		}
+		if rate > 0 {
+			amountByRate[rate] += paid[i]
+		}
+	}
+
+	points := 0
+	for rate, amount := range amountByRate {
+		points += amount / rate
+	}
+	return points
+}
+
+// GetByID - ambil transaksi beserta detailnya, nama & harga dari snapshot saat checkout
+func (repo *TransactionRepository) GetByID(id int) (*models.Transaction, error) {
+	t, err := scanTransaction(repo.db.QueryRow("SELECT "+transactionColumns+" FROM transactions WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, ErrTransactionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	transactions := []models.Transaction{t}
+	if err := attachTransactionLines(repo.db, transactions); err != nil {
+		return nil, err
+	}
+	return &transactions[0], nil
+}
+
+// transactionColumns harus sama urutannya dengan scanTransaction
+const transactionColumns = "id, total_amount, outlet_id, customer_id, table_id, points_redeemed, points_discount, points_earned, created_at"
+
+func scanTransaction(row rowScanner) (models.Transaction, error) {
+	var t models.Transaction
+	err := row.Scan(&t.ID, &t.TotalAmount, &t.OutletID, &t.CustomerID, &t.TableID, &t.PointsRedeemed, &t.PointsDiscount, &t.PointsEarned, &t.CreatedAt)
+	return t, err
+}
+
+// attachTransactionLines mengisi detail dan pembayaran semua transaksi, masing-masing satu query
+func attachTransactionLines(db *sql.DB, transactions []models.Transaction) error {
+	if err := attachTransactionDetails(db, transactions); err != nil {
+		return err
+	}
+	return attachTransactionPayments(db, transactions)
+}
+
+func attachTransactionPayments(db *sql.DB, transactions []models.Transaction) error {
+	if len(transactions) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(transactions))
+	index := make(map[int]int, len(transactions))
+	for i := range transactions {
+		ids[i] = int64(transactions[i].ID)
+		index[transactions[i].ID] = i
+		transactions[i].Payments = make([]models.TransactionPayment, 0)
+	}
+
+	rows, err := db.Query(`
+		SELECT p.id, p.transaction_id, p.method, p.account_id, COALESCE(a.code, ''), p.amount
+		FROM transaction_payments p
+		LEFT JOIN stored_value_accounts a ON a.id = p.account_id
+		WHERE p.transaction_id = ANY($1)
+		ORDER BY p.id`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p models.TransactionPayment
+		var transactionID int
+		if err := rows.Scan(&p.ID, &transactionID, &p.Method, &p.AccountID, &p.Code, &p.Amount); err != nil {
+			return err
+		}
+		i := index[transactionID]
+		transactions[i].Payments = append(transactions[i].Payments, p)
+	}
+	return rows.Err()
+}
+
+// attachTransactionDetails mengisi detail semua transaksi dengan satu query
+func attachTransactionDetails(db *sql.DB, transactions []models.Transaction) error {
+	if len(transactions) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(transactions))
+	index := make(map[int]int, len(transactions))
+	for i := range transactions {
+		ids[i] = int64(transactions[i].ID)
+		index[transactions[i].ID] = i
+		transactions[i].Details = make([]models.TransactionDetail, 0)
+	}
+
+	rows, err := db.Query(`
+		SELECT id, transaction_id, product_id, product_name, variant_id, COALESCE(variant_name, ''), sku,
+			COALESCE(unit, ''), unit_factor, unit_price, quantity, subtotal, refunded_quantity, modifiers
+		FROM transaction_details
+		WHERE transaction_id = ANY($1)
+		ORDER BY id`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var d models.TransactionDetail
+		var modifiers []byte
+		err := rows.Scan(&d.ID, &d.TransactionID, &d.ProductID, &d.ProductName, &d.VariantID, &d.VariantName, &d.SKU,
+			&d.Unit, &d.UnitFactor, &d.UnitPrice, &d.Quantity, &d.Subtotal, &d.RefundedQuantity, &modifiers)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(modifiers, &d.Modifiers); err != nil {
+			return err
+		}
+		i := index[d.TransactionID]
+		transactions[i].Details = append(transactions[i].Details, d)
+	}
+	return rows.Err()
+}
 
-	return &models.Transaction{
-		ID:          transactionID,
-		TotalAmount: totalAmount,
-		Details:     details,
-	}, nil
+// Penjualan bersih per detail transaksi: jumlah dan subtotal dikurangi bagian yang sudah diretur.
+// Dipakai laporan per produk/kategori, returnya ikut mengurangi periode penjualan aslinya.
+const (
+	detailNetQuantity = "(td.quantity - td.refunded_quantity) * td.unit_factor"
+	detailNetRevenue  = "(td.subtotal - COALESCE((SELECT SUM(rl.amount) FROM refund_lines rl WHERE rl.detail_id = td.id), 0))"
+)
+
+// periodRefunds - uang retur yang dikeluarkan dalam periode (menurut tanggal retur), outletID nil berarti semua outlet
+func (repo *TransactionRepository) periodRefunds(startDate, endDate time.Time, outletID *int) (int, error) {
+	var total int
+	err := repo.db.QueryRow(`
+		SELECT COALESCE(SUM(r.amount), 0)
+		FROM refunds r
+		JOIN transactions t ON r.transaction_id = t.id
+		WHERE r.created_at >= $1 AND r.created_at <= $2 AND ($3::int IS NULL OR t.outlet_id = $3)`,
+		startDate, endDate, outletID).Scan(&total)
+	return total, err
 }
 
-func (repo *TransactionRepository) GetReportByDateRange(startDate, endDate time.Time) (*models.SalesReport, error) {
-	report := &models.SalesReport{}
+// GetReportByDateRange - outletID nil berarti gabungan semua outlet.
+// TotalRevenue adalah omzet kotor, NetRevenue sudah dikurangi retur yang dibayarkan di periode yang sama.
+func (repo *TransactionRepository) GetReportByDateRange(startDate, endDate time.Time, outletID *int) (*models.SalesReport, error) {
+	report := &models.SalesReport{OutletID: outletID}
 
 	// 1. Hitung Total Revenue dan Total Transaksi
 	// created_at di database biasanya TIMESTAMP dengan timezone, jadi perlu hati-hati.
@@ -110,36 +474,36 @@ func (repo *TransactionRepository) GetReportByDateRange(startDate, endDate time.
 			COALESCE(SUM(total_amount), 0), 
 			COUNT(id)
 		FROM transactions 
-		WHERE created_at >= $1 AND created_at <= $2
+		WHERE created_at >= $1 AND created_at <= $2 AND ($3::int IS NULL OR outlet_id = $3)
 	`
-	err := repo.db.QueryRow(querySummary, startDate, endDate).Scan(&report.TotalRevenue, &report.TotalTransaksi)
+	err := repo.db.QueryRow(querySummary, startDate, endDate, outletID).Scan(&report.TotalRevenue, &report.TotalTransaksi)
 	if err != nil {
 		return nil, err
 	}
+	if report.TotalRefunds, err = repo.periodRefunds(startDate, endDate, outletID); err != nil {
+		return nil, err
+	}
+	report.NetRevenue = report.TotalRevenue - report.TotalRefunds
 
 	// 2. Cari Produk Terlaris
-	// Join transaction_details dengan transactions untuk filter tanggal, kemudian group by product_id
-	// Sebenarnya transaction_details tidak punya tanggal, jadi harus JOIN ke transactions.
-	// Tapi kita butuh nama produk juga, ada di products table.
-	// Atau jika nama produk disimpan di transaction_details (seperti di struct Model TransactionDetail ada ProductName?), 
-    // Tapi di struct TransactionDetail tidak ada field ProductName yang mapped ke DB row secara eksplisit.
-    // Di func CreateTransaction: `INSERT INTO transaction_details ...` tidak menyimpan nama produk.
-    // Jadi harus JOIN ke tabel products untuk dapat nama.
-
+	// Join transaction_details dengan transactions untuk filter tanggal, kemudian group by product_id.
+	// Penjualan varian tercatat dengan product_id induknya, jadi otomatis digabung ke produk induk.
+	// Jumlah terjual dihitung dalam satuan dasar (quantity x unit_factor), dikurangi yang sudah diretur.
+	// Nama produk diambil dari snapshot di transaction_details (nama terbaru yang pernah terjual),
+	// jadi rename/arsip produk tidak mengubah laporan lama.
 	queryBestSeller := `
-		SELECT 
-			p.name, 
-			COALESCE(SUM(td.quantity), 0) as qty_terjual
+		SELECT
+			(ARRAY_AGG(td.product_name ORDER BY td.id DESC))[1],
+			COALESCE(SUM(` + detailNetQuantity + `), 0) as qty_terjual
 		FROM transaction_details td
 		JOIN transactions t ON td.transaction_id = t.id
-		JOIN products p ON td.product_id = p.id
-		WHERE t.created_at >= $1 AND t.created_at <= $2
-		GROUP BY p.id, p.name
+		WHERE t.created_at >= $1 AND t.created_at <= $2 AND ($3::int IS NULL OR t.outlet_id = $3)
+		GROUP BY td.product_id
 		ORDER BY qty_terjual DESC
 		LIMIT 1
 	`
-	
-	err = repo.db.QueryRow(queryBestSeller, startDate, endDate).Scan(&report.ProdukTerlaris.Nama, &report.ProdukTerlaris.QtyTerjual)
+
+	err = repo.db.QueryRow(queryBestSeller, startDate, endDate, outletID).Scan(&report.ProdukTerlaris.Nama, &report.ProdukTerlaris.QtyTerjual)
 	if err == sql.ErrNoRows {
 		// Tidak ada transaksi, biarkan kosong atau set default
 		report.ProdukTerlaris = models.ProductSales{Nama: "-", QtyTerjual: 0}
@@ -149,3 +513,367 @@ func (repo *TransactionRepository) GetReportByDateRange(startDate, endDate time.
 
 	return report, nil
 }
+
+// GetMovementReport - penjualan paket dan rekap pergerakan stok per produk.
+// Komponen yang terjual lewat paket dipisah dari penjualan langsung supaya jelas asal pengurangan stoknya.
+// outletID nil berarti gabungan semua outlet.
+func (repo *TransactionRepository) GetMovementReport(startDate, endDate time.Time, outletID *int) (*models.MovementReport, error) {
+	report := &models.MovementReport{
+		OutletID: outletID,
+		Bundles:  make([]models.BundleSales, 0),
+		Products: make([]models.MovementSummary, 0),
+	}
+
+	// Penjualan paket bersih dari retur
+	queryBundles := `
+		SELECT td.product_id, (ARRAY_AGG(td.product_name ORDER BY td.id DESC))[1],
+			SUM(` + detailNetQuantity + `), SUM(` + detailNetRevenue + `)
+		FROM transaction_details td
+		JOIN transactions t ON td.transaction_id = t.id
+		JOIN products p ON td.product_id = p.id
+		WHERE p.is_bundle AND t.created_at >= $1 AND t.created_at <= $2 AND ($3::int IS NULL OR t.outlet_id = $3)
+		GROUP BY td.product_id
+		ORDER BY 3 DESC
+	`
+	rows, err := repo.db.Query(queryBundles, startDate, endDate, outletID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var b models.BundleSales
+		if err := rows.Scan(&b.ProductID, &b.ProductName, &b.QtySold, &b.Revenue); err != nil {
+			return nil, err
+		}
+		report.Bundles = append(report.Bundles, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// quantity di stock_movements bertanda, penjualan disimpan negatif jadi dibalik di sini
+	queryProducts := `
+		SELECT m.product_id, p.name,
+			COALESCE(-SUM(m.quantity) FILTER (WHERE m.reason = $4), 0),
+			COALESCE(-SUM(m.quantity) FILTER (WHERE m.reason = $5), 0),
+			COALESCE(SUM(m.quantity) FILTER (WHERE m.reason = $6), 0),
+			COALESCE(SUM(m.quantity) FILTER (WHERE m.reason = $7), 0),
+			COALESCE(-SUM(m.quantity) FILTER (WHERE m.reason = $8), 0),
+			SUM(m.quantity)
+		FROM stock_movements m
+		JOIN products p ON m.product_id = p.id
+		WHERE m.created_at >= $1 AND m.created_at <= $2 AND ($3::int IS NULL OR m.outlet_id = $3)
+		GROUP BY m.product_id, p.name
+		ORDER BY m.product_id
+	`
+	rows, err = repo.db.Query(queryProducts, startDate, endDate, outletID, movementSale, movementBundleComponent, movementPurchase,
+		movementTransferIn, movementTransferOut)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var m models.MovementSummary
+		if err := rows.Scan(&m.ProductID, &m.ProductName, &m.SoldDirect, &m.SoldInBundles, &m.Purchased,
+			&m.TransferredIn, &m.TransferredOut, &m.NetChange); err != nil {
+			return nil, err
+		}
+		report.Products = append(report.Products, m)
+	}
+	return report, rows.Err()
+}
+
+// GetConsolidatedReport - omzet dan jumlah transaksi per outlet, retur dihitung menurut tanggal retur.
+// Outlet aktif tanpa penjualan tetap ditampilkan, outlet yang sudah diarsipkan hanya kalau punya
+// transaksi atau retur di periode ini.
+func (repo *TransactionRepository) GetConsolidatedReport(startDate, endDate time.Time) (*models.ConsolidatedReport, error) {
+	rows, err := repo.db.Query(`
+		SELECT o.id, o.code, o.name, COALESCE(s.revenue, 0), COALESCE(s.transactions, 0), COALESCE(r.refunds, 0)
+		FROM outlets o
+		LEFT JOIN (
+			SELECT outlet_id, SUM(total_amount) AS revenue, COUNT(*) AS transactions
+			FROM transactions
+			WHERE created_at >= $1 AND created_at <= $2
+			GROUP BY outlet_id
+		) s ON s.outlet_id = o.id
+		LEFT JOIN (
+			SELECT t.outlet_id, SUM(rf.amount) AS refunds
+			FROM refunds rf JOIN transactions t ON rf.transaction_id = t.id
+			WHERE rf.created_at >= $1 AND rf.created_at <= $2
+			GROUP BY t.outlet_id
+		) r ON r.outlet_id = o.id
+		WHERE o.deleted_at IS NULL OR s.outlet_id IS NOT NULL OR r.outlet_id IS NOT NULL
+		ORDER BY o.is_default DESC, o.code, o.id`, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	report := &models.ConsolidatedReport{Outlets: make([]models.OutletSales, 0)}
+	for rows.Next() {
+		var s models.OutletSales
+		if err := rows.Scan(&s.OutletID, &s.Code, &s.Name, &s.TotalRevenue, &s.TotalTransaksi, &s.TotalRefunds); err != nil {
+			return nil, err
+		}
+		s.NetRevenue = s.TotalRevenue - s.TotalRefunds
+		report.TotalRevenue += s.TotalRevenue
+		report.TotalRefunds += s.TotalRefunds
+		report.NetRevenue += s.NetRevenue
+		report.TotalTransaksi += s.TotalTransaksi
+		report.Outlets = append(report.Outlets, s)
+	}
+	return report, rows.Err()
+}
+
+// checkoutLine - baris produk (dan varian) yang sudah dikunci untuk satu item checkout
+type checkoutLine struct {
+	// outletID - outlet tempat stok dikurangi, stock dan reserved adalah angka outlet ini
+	outletID    int
+	productID   int
+	productName string
+	variantID   *int
+	variantName string
+	sku         *string
+	baseUnit    string
+	unit        string
+	unitFactor  models.Quantity
+	precision   int
+	// unitPrice - harga per satuan jual (unit), stock dalam satuan dasar
+	unitPrice  int
+	stock      models.Quantity
+	reserved   models.Quantity
+	isBundle   bool
+	categoryID *int
+	components []bundleComponent
+}
+
+// bundleComponent - komponen paket yang sudah dikunci, quantity per satu paket
+type bundleComponent struct {
+	productID int
+	variantID *int
+	name      string
+	quantity  models.Quantity
+	stock     models.Quantity
+	reserved  models.Quantity
+}
+
+func (l *checkoutLine) displayName() string {
+	if l.variantName == "" {
+		return l.productName
+	}
+	return l.productName + " (" + l.variantName + ")"
+}
+
+// lockCheckoutLine mengunci produk/varian (atau komponen paket) item. Stok outlet-nya dibaca
+// terpisah lewat loadStock setelah semua baris terkunci.
+func lockCheckoutLine(tx *sql.Tx, outletID int, item models.CheckoutItem) (*checkoutLine, error) {
+	line := &checkoutLine{outletID: outletID, productID: item.ProductID}
+	var archived bool
+
+	err := tx.QueryRow("SELECT name, sku, price, base_unit, quantity_precision, is_bundle, category_id, deleted_at IS NOT NULL FROM products WHERE id = $1 FOR UPDATE", item.ProductID).
+		Scan(&line.productName, &line.sku, &line.unitPrice, &line.baseUnit, &line.precision, &line.isBundle, &line.categoryID, &archived)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: produk dengan ID %d", ErrProductNotFound, item.ProductID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if archived {
+		return nil, fmt.Errorf("%w: produk '%s' tidak bisa dijual", ErrProductArchived, line.productName)
+	}
+
+	// Harga terjadwal yang sudah jatuh tempo langsung berlaku untuk transaksi ini
+	line.unitPrice, err = applyDuePrice(tx, item.ProductID, line.unitPrice)
+	if err != nil {
+		return nil, err
+	}
+
+	if line.isBundle {
+		if item.VariantID != nil {
+			return nil, fmt.Errorf("paket '%s' tidak punya varian", line.productName)
+		}
+		if err := line.lockComponents(tx); err != nil {
+			return nil, err
+		}
+		return line, applySaleUnit(tx, line, item.Unit)
+	}
+
+	if item.VariantID == nil {
+		var hasVariants bool
+		err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM product_variants WHERE product_id = $1 AND deleted_at IS NULL)", item.ProductID).Scan(&hasVariants)
+		if err != nil {
+			return nil, err
+		}
+		if hasVariants {
+			return nil, fmt.Errorf("produk '%s' punya varian, variant_id wajib diisi", line.productName)
+		}
+		return line, applySaleUnit(tx, line, item.Unit)
+	}
+
+	// Varian punya harga, stok dan SKU sendiri
+	var variantSKU *string
+	err = tx.QueryRow("SELECT name, sku, price, deleted_at IS NOT NULL FROM product_variants WHERE id = $1 AND product_id = $2 FOR UPDATE", *item.VariantID, item.ProductID).
+		Scan(&line.variantName, &variantSKU, &line.unitPrice, &archived)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: varian dengan ID %d untuk produk '%s'", ErrVariantNotFound, *item.VariantID, line.productName)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if archived {
+		return nil, fmt.Errorf("%w: varian '%s' tidak bisa dijual", ErrVariantArchived, line.displayName())
+	}
+	line.variantID = item.VariantID
+	if variantSKU != nil {
+		line.sku = variantSKU
+	}
+
+	return line, applySaleUnit(tx, line, item.Unit)
+}
+
+// lockComponents mengunci semua komponen paket, urut berdasarkan ID supaya tidak deadlock
+func (l *checkoutLine) lockComponents(tx *sql.Tx) error {
+	rows, err := tx.Query(`
+		SELECT component_id, component_variant_id, quantity
+		FROM product_bundle_items
+		WHERE bundle_id = $1
+		ORDER BY component_id, component_variant_id NULLS FIRST`, l.productID)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var c bundleComponent
+		if err := rows.Scan(&c.productID, &c.variantID, &c.quantity); err != nil {
+			rows.Close()
+			return err
+		}
+		l.components = append(l.components, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(l.components) == 0 {
+		return fmt.Errorf("paket '%s' belum punya komponen", l.productName)
+	}
+
+	for i := range l.components {
+		c := &l.components[i]
+		var archived bool
+		err := tx.QueryRow("SELECT name, deleted_at IS NOT NULL FROM products WHERE id = $1 FOR UPDATE", c.productID).
+			Scan(&c.name, &archived)
+		if err != nil {
+			return err
+		}
+
+		if c.variantID != nil {
+			var variantName string
+			var variantArchived bool
+			err := tx.QueryRow("SELECT name, deleted_at IS NOT NULL FROM product_variants WHERE id = $1 FOR UPDATE", *c.variantID).
+				Scan(&variantName, &variantArchived)
+			if err != nil {
+				return err
+			}
+			c.name += " (" + variantName + ")"
+			archived = archived || variantArchived
+		}
+
+		if archived {
+			return fmt.Errorf("%w: komponen '%s' di paket '%s'", ErrProductArchived, c.name, l.productName)
+		}
+	}
+	return nil
+}
+
+// deduct memvalidasi dan mengurangi stok (FEFO per lot) untuk baseQuantity satuan dasar,
+// mengembalikan pergerakan stok yang perlu dicatat
+func (l *checkoutLine) deduct(tx *sql.Tx, baseQuantity models.Quantity, blockExpired bool) ([]stockMovement, error) {
+	if !l.isBundle {
+		if l.stock-l.reserved < baseQuantity {
+			return nil, fmt.Errorf("stok produk '%s' tidak cukup (sisa: %s %s%s)", l.displayName(), formatQuantity(l.stock), l.baseUnit, reservedNote(l.reserved))
+		}
+		m := stockMovement{outletID: l.outletID, productID: l.productID, variantID: l.variantID, reason: movementSale}
+		return deductStockFEFO(tx, m, l.displayName(), l.stock, baseQuantity, blockExpired)
+	}
+
+	// Cek semua komponen dulu supaya paket ditolak utuh kalau ada yang kurang
+	needs := make([]models.Quantity, len(l.components))
+	for i, c := range l.components {
+		needs[i] = baseQuantity.Mul(c.quantity)
+		if c.stock-c.reserved < needs[i] {
+			return nil, fmt.Errorf("stok komponen '%s' untuk paket '%s' tidak cukup (sisa: %s%s)", c.name, l.productName, formatQuantity(c.stock), reservedNote(c.reserved))
+		}
+	}
+
+	movements := make([]stockMovement, 0, len(l.components))
+	for i, c := range l.components {
+		m := stockMovement{
+			outletID:        l.outletID,
+			productID:       c.productID,
+			variantID:       c.variantID,
+			reason:          movementBundleComponent,
+			bundleProductID: &l.productID,
+		}
+		componentMovements, err := deductStockFEFO(tx, m, c.name, c.stock, needs[i], blockExpired)
+		if err != nil {
+			return nil, err
+		}
+		movements = append(movements, componentMovements...)
+	}
+	return movements, nil
+}
+
+// applySaleUnit mengonversi harga satuan dasar ke satuan jual.
+// Harga khusus satuan dipakai kalau ada, selain itu harga dasar x faktor.
+func applySaleUnit(tx *sql.Tx, line *checkoutLine, unitName string) error {
+	unit, err := resolveUnit(tx, line.productID, line.baseUnit, unitName, true)
+	if err != nil {
+		return err
+	}
+
+	line.unit = unit.name
+	line.unitFactor = unit.factor
+	if unit.price != nil {
+		line.unitPrice = *unit.price
+	} else {
+		line.unitPrice = unit.factor.Rupiah(line.unitPrice)
+	}
+	return nil
+}
+
+// price menghitung jumlah dan subtotal (dibulatkan ke rupiah) untuk satu item.
+// Untuk barcode harga, subtotal mengikuti harga di label dan jumlah dihitung balik dari harga satuan.
+func (l *checkoutLine) price(item models.CheckoutItem) (models.Quantity, int, error) {
+	if item.LabelPrice > 0 {
+		if l.unitPrice <= 0 {
+			return 0, 0, fmt.Errorf("%w: harga produk '%s' belum diatur, barcode harga tidak bisa dipakai", ErrValidation, l.displayName())
+		}
+		// Harga label di bawah setengah seperseribu harga satuan dibulatkan ke jumlah 0
+		quantity := models.QuantityRatio(item.LabelPrice, l.unitPrice)
+		if quantity <= 0 {
+			return 0, 0, fmt.Errorf("%w: harga label Rp%d terlalu kecil untuk harga satuan '%s' Rp%d", ErrValidation, item.LabelPrice, l.displayName(), l.unitPrice)
+		}
+		return quantity, item.LabelPrice, nil
+	}
+
+	if !item.Quantity.HasPrecision(l.precision) {
+		return 0, 0, fmt.Errorf("%w: jumlah produk '%s' maksimal %d angka desimal", ErrValidation, l.displayName(), l.precision)
+	}
+	return item.Quantity, item.Quantity.Rupiah(l.unitPrice), nil
+}
+
+// GetProductIDByPLU - cari produk dari kode PLU barcode timbangan
+func (repo *TransactionRepository) GetProductIDByPLU(plu string) (int, error) {
+	var id int
+	err := repo.db.QueryRow("SELECT id FROM products WHERE plu = $1", plu).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("%w: tidak ada produk dengan PLU %s", ErrProductNotFound, plu)
+	}
+	return id, err
+}
+
+func formatQuantity(q models.Quantity) string {
+	return q.String()
+}
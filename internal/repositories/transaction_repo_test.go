@@ -0,0 +1,30 @@
+package repositories
+
+import "testing"
+
+func TestPointsByRate(t *testing.T) {
+	food, drink, promo, other := 1, 2, 3, 4
+	rates := map[int]int{food: 500, promo: 0, drink: 2000}
+
+	tests := []struct {
+		name           string
+		rupiahPerPoint int
+		categories     []*int
+		paid           []int
+		want           int
+	}{
+		{"default rate", 1000, []*int{nil, nil}, []int{1500, 700}, 2},
+		{"category rate", 1000, []*int{&food}, []int{1200}, 2},
+		// 999 + 1500 dibulatkan per kelompok tarif, bukan per baris
+		{"grouped before rounding", 1000, []*int{nil, &other}, []int{999, 1500}, 2},
+		{"mixed rates", 1000, []*int{nil, &food, &food, &drink}, []int{1500, 300, 300, 3999}, 3},
+		{"zero category rate earns nothing", 1000, []*int{&promo, nil}, []int{50000, 1000}, 1},
+		{"zero default rate", 0, []*int{nil, &food}, []int{50000, 1000}, 2},
+		{"no lines", 1000, nil, nil, 0},
+	}
+	for _, tt := range tests {
+		if got := pointsByRate(tt.rupiahPerPoint, rates, tt.categories, tt.paid); got != tt.want {
+			t.Errorf("%s: pointsByRate = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,517 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"kasir-api/internal/models"
+	"sort"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Alasan pergerakan stok transfer, dicatat di outlet asal dan outlet tujuan
+const (
+	movementTransferOut = "transfer_out"
+	movementTransferIn  = "transfer_in"
+)
+
+type TransferRepository struct {
+	db *sql.DB
+}
+
+func NewTransferRepository(db *sql.DB) *TransferRepository {
+	return &TransferRepository{db: db}
+}
+
+// transferColumns harus sama urutannya dengan scanTransfer
+const transferColumns = `t.id, t.source_outlet_id, src.name, t.destination_outlet_id, dst.name, t.status, t.note, t.version,
+	t.created_at, t.dispatched_at, t.received_at`
+
+const transferFrom = ` FROM stock_transfers t
+	JOIN outlets src ON src.id = t.source_outlet_id
+	JOIN outlets dst ON dst.id = t.destination_outlet_id`
+
+func scanTransfer(row rowScanner) (models.StockTransfer, error) {
+	var t models.StockTransfer
+	err := row.Scan(&t.ID, &t.SourceOutletID, &t.SourceOutletName, &t.DestinationOutletID, &t.DestinationName, &t.Status, &t.Note, &t.Version,
+		&t.CreatedAt, &t.DispatchedAt, &t.ReceivedAt)
+	return t, err
+}
+
+// GetAll - transfer terbaru dulu, maksimal 200 baris
+func (repo *TransferRepository) GetAll(filter models.TransferFilter) ([]models.StockTransfer, error) {
+	query := "SELECT " + transferColumns + transferFrom
+	conditions := []string{}
+	args := []interface{}{}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("t.status = $%d", len(args)))
+	}
+	if filter.OutletID != nil {
+		args = append(args, *filter.OutletID)
+		conditions = append(conditions, fmt.Sprintf("$%d IN (t.source_outlet_id, t.destination_outlet_id)", len(args)))
+	}
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY t.id DESC LIMIT 200"
+
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transfers := make([]models.StockTransfer, 0)
+	for rows.Next() {
+		t, err := scanTransfer(rows)
+		if err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := repo.attachLines(transfers); err != nil {
+		return nil, err
+	}
+	return transfers, nil
+}
+
+func (repo *TransferRepository) GetByID(id int) (*models.StockTransfer, error) {
+	t, err := scanTransfer(repo.db.QueryRow("SELECT "+transferColumns+transferFrom+" WHERE t.id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, ErrTransferNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	transfers := []models.StockTransfer{t}
+	if err := repo.attachLines(transfers); err != nil {
+		return nil, err
+	}
+	return &transfers[0], nil
+}
+
+func (repo *TransferRepository) attachLines(transfers []models.StockTransfer) error {
+	if len(transfers) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(transfers))
+	index := make(map[int]int, len(transfers))
+	for i := range transfers {
+		ids[i] = int64(transfers[i].ID)
+		index[transfers[i].ID] = i
+		transfers[i].Lines = make([]models.StockTransferLine, 0)
+	}
+
+	rows, err := repo.db.Query(`
+		SELECT l.id, l.transfer_id, l.product_id, p.name, l.variant_id, COALESCE(v.name, ''), p.base_unit,
+			l.quantity, l.received_quantity, l.note
+		FROM stock_transfer_lines l
+		JOIN products p ON p.id = l.product_id
+		LEFT JOIN product_variants v ON v.id = l.variant_id
+		WHERE l.transfer_id = ANY($1)
+		ORDER BY l.id`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var l models.StockTransferLine
+		err := rows.Scan(&l.ID, &l.TransferID, &l.ProductID, &l.ProductName, &l.VariantID, &l.VariantName, &l.BaseUnit,
+			&l.Quantity, &l.ReceivedQuantity, &l.Note)
+		if err != nil {
+			return err
+		}
+		if l.ReceivedQuantity != nil {
+			d := *l.ReceivedQuantity - l.Quantity
+			l.Discrepancy = &d
+		}
+		i := index[l.TransferID]
+		transfers[i].Lines = append(transfers[i].Lines, l)
+	}
+	return rows.Err()
+}
+
+// Create menyimpan transfer sebagai draft, stok belum berpindah
+func (repo *TransferRepository) Create(actor string, transfer *models.StockTransfer) error {
+	tx, err := beginTx(repo.db, actor)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := checkTransferOutlets(tx, transfer); err != nil {
+		return err
+	}
+	err = tx.QueryRow(`INSERT INTO stock_transfers (source_outlet_id, destination_outlet_id, note) VALUES ($1, $2, $3)
+		RETURNING id`, transfer.SourceOutletID, transfer.DestinationOutletID, transfer.Note).Scan(&transfer.ID)
+	if err != nil {
+		return err
+	}
+	if err := insertTransferLines(tx, transfer.ID, transfer.Lines); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Update mengganti header dan semua baris transfer yang masih draft.
+// transfer.Version berisi versi yang diharapkan (0 = tanpa cek versi).
+func (repo *TransferRepository) Update(actor string, transfer *models.StockTransfer) error {
+	tx, err := beginTx(repo.db, actor)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	state, err := lockTransfer(tx, transfer.ID, transfer.Version)
+	if err != nil {
+		return err
+	}
+	if err := state.requireStatus("diubah", models.TransferDraft); err != nil {
+		return err
+	}
+	if err := checkTransferOutlets(tx, transfer); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE stock_transfers SET source_outlet_id = $1, destination_outlet_id = $2, note = $3, version = version + 1 WHERE id = $4",
+		transfer.SourceOutletID, transfer.DestinationOutletID, transfer.Note, transfer.ID)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM stock_transfer_lines WHERE transfer_id = $1", transfer.ID); err != nil {
+		return err
+	}
+	if err := insertTransferLines(tx, transfer.ID, transfer.Lines); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Cancel membatalkan transfer yang belum dikirim
+func (repo *TransferRepository) Cancel(actor string, id, version int) error {
+	tx, err := beginTx(repo.db, actor)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	state, err := lockTransfer(tx, id, version)
+	if err != nil {
+		return err
+	}
+	if err := state.requireStatus("dibatalkan", models.TransferDraft); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE stock_transfers SET status = $1, version = version + 1 WHERE id = $2", models.TransferCancelled, id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Dispatch mengirim transfer: stok outlet asal berkurang (lot yang paling cepat kedaluwarsa dipakai dulu)
+// dan dicatat sebagai transfer_out. Stok yang sedang ditahan reservasi tidak ikut dikirim.
+func (repo *TransferRepository) Dispatch(actor string, id, version int) error {
+	tx, err := beginTx(repo.db, actor)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	state, err := lockTransfer(tx, id, version)
+	if err != nil {
+		return err
+	}
+	if err := state.requireStatus("dikirim", models.TransferDraft); err != nil {
+		return err
+	}
+	if err := requireOutlet(tx, state.sourceOutletID); err != nil {
+		return err
+	}
+	if err := requireOutlet(tx, state.destinationOutletID); err != nil {
+		return err
+	}
+
+	lines, err := transferLines(tx, id)
+	if err != nil {
+		return err
+	}
+	// Urut berdasarkan produk supaya urutan kunci baris sama di semua proses yang mengubah stok
+	sort.Slice(lines, func(i, j int) bool {
+		if lines[i].productID != lines[j].productID {
+			return lines[i].productID < lines[j].productID
+		}
+		return variantKey(lines[i].variantID) < variantKey(lines[j].variantID)
+	})
+
+	movements := make([]stockMovement, 0, len(lines))
+	for _, l := range lines {
+		name, err := lockTransferProduct(tx, l.productID, l.variantID)
+		if err != nil {
+			return err
+		}
+		stock, err := outletStock(tx, state.sourceOutletID, l.productID, l.variantID)
+		if err != nil {
+			return err
+		}
+		reserved, err := reservedQuantity(tx, state.sourceOutletID, l.productID, l.variantID)
+		if err != nil {
+			return err
+		}
+		if stock-reserved < l.quantity {
+			return fmt.Errorf("%w: stok '%s' di outlet asal tinggal %s%s", ErrOutletStock, name, formatQuantity(max(stock-reserved, 0)), reservedNote(reserved))
+		}
+
+		m := stockMovement{outletID: state.sourceOutletID, productID: l.productID, variantID: l.variantID, reason: movementTransferOut}
+		lineMovements, err := deductStockFEFO(tx, m, name, stock, l.quantity, false)
+		if err != nil {
+			return err
+		}
+		movements = append(movements, lineMovements...)
+	}
+	if err := insertStockMovements(tx, "transfer", &id, movements); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE stock_transfers SET status = $1, dispatched_at = NOW(), version = version + 1 WHERE id = $2", models.TransferInTransit, id)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Receive menerima transfer di outlet tujuan. Baris yang tidak disebut di receipt dianggap diterima utuh,
+// selisih jumlah diterima dengan jumlah kirim disimpan di baris transfer. Barang masuk sebagai stok tanpa lot.
+func (repo *TransferRepository) Receive(actor string, id, version int, receipt models.TransferReceipt) error {
+	tx, err := beginTx(repo.db, actor)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	state, err := lockTransfer(tx, id, version)
+	if err != nil {
+		return err
+	}
+	if err := state.requireStatus("diterima", models.TransferInTransit); err != nil {
+		return err
+	}
+
+	lines, err := transferLines(tx, id)
+	if err != nil {
+		return err
+	}
+	byID := make(map[int]*transferLine, len(lines))
+	for i := range lines {
+		lines[i].received = lines[i].quantity
+		byID[lines[i].id] = &lines[i]
+	}
+	for _, r := range receipt.Lines {
+		l, ok := byID[r.LineID]
+		if !ok {
+			return fmt.Errorf("%w: baris %d bukan milik transfer %d", ErrTransferLineNotFound, r.LineID, id)
+		}
+		l.received = r.ReceivedQuantity
+		l.note = r.Note
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		if lines[i].productID != lines[j].productID {
+			return lines[i].productID < lines[j].productID
+		}
+		return variantKey(lines[i].variantID) < variantKey(lines[j].variantID)
+	})
+
+	movements := make([]stockMovement, 0, len(lines))
+	for _, l := range lines {
+		if _, err := tx.Exec("UPDATE stock_transfer_lines SET received_quantity = $1, note = $2 WHERE id = $3", l.received, l.note, l.id); err != nil {
+			return err
+		}
+		if l.received <= 0 {
+			continue
+		}
+		if _, err := lockTransferProduct(tx, l.productID, l.variantID); err != nil {
+			return err
+		}
+		if err := addStock(tx, state.destinationOutletID, l.productID, l.variantID, l.received); err != nil {
+			return err
+		}
+		movements = append(movements, stockMovement{
+			outletID:  state.destinationOutletID,
+			productID: l.productID,
+			variantID: l.variantID,
+			quantity:  l.received,
+			reason:    movementTransferIn,
+		})
+	}
+	if err := insertStockMovements(tx, "transfer", &id, movements); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE stock_transfers SET status = $1, received_at = NOW(), version = version + 1 WHERE id = $2", models.TransferReceived, id)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// transferState - header transfer yang sudah dikunci
+type transferState struct {
+	status              string
+	sourceOutletID      int
+	destinationOutletID int
+}
+
+// lockTransfer mengunci transfer dan memeriksa versinya (0 = tanpa cek versi)
+func lockTransfer(tx *sql.Tx, id, version int) (*transferState, error) {
+	var s transferState
+	var current int
+	err := tx.QueryRow("SELECT status, source_outlet_id, destination_outlet_id, version FROM stock_transfers WHERE id = $1 FOR UPDATE", id).
+		Scan(&s.status, &s.sourceOutletID, &s.destinationOutletID, &current)
+	if err == sql.ErrNoRows {
+		return nil, ErrTransferNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if version != 0 && version != current {
+		return nil, ErrVersionMismatch
+	}
+	return &s, nil
+}
+
+// requireStatus - ErrTransferClosed kalau status transfer bukan salah satu dari allowed
+func (s *transferState) requireStatus(action string, allowed ...string) error {
+	for _, a := range allowed {
+		if s.status == a {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: transfer berstatus %s tidak bisa %s", ErrTransferClosed, s.status, action)
+}
+
+// checkTransferOutlets memastikan outlet asal dan tujuan masih aktif
+func checkTransferOutlets(tx *sql.Tx, transfer *models.StockTransfer) error {
+	if err := requireOutlet(tx, transfer.SourceOutletID); err != nil {
+		return fmt.Errorf("outlet asal: %w", err)
+	}
+	if err := requireOutlet(tx, transfer.DestinationOutletID); err != nil {
+		return fmt.Errorf("outlet tujuan: %w", err)
+	}
+	return nil
+}
+
+// insertTransferLines memvalidasi produk tiap baris (aktif, bukan paket, varian wajib untuk produk bervarian)
+// lalu menyimpannya. Stok belum diperiksa, itu dilakukan saat dikirim.
+func insertTransferLines(tx *sql.Tx, transferID int, lines []models.StockTransferLine) error {
+	for i := range lines {
+		l := &lines[i]
+		var name string
+		var isBundle, archived, hasVariants bool
+		err := tx.QueryRow(`SELECT name, is_bundle, deleted_at IS NOT NULL,
+				EXISTS (SELECT 1 FROM product_variants WHERE product_id = products.id AND deleted_at IS NULL)
+			FROM products WHERE id = $1`, l.ProductID).Scan(&name, &isBundle, &archived, &hasVariants)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("%w: produk dengan ID %d", ErrProductNotFound, l.ProductID)
+		}
+		if err != nil {
+			return err
+		}
+		if archived {
+			return fmt.Errorf("%w: produk '%s' sudah diarsipkan", ErrTransferInvalid, name)
+		}
+		if isBundle {
+			return fmt.Errorf("%w: paket '%s' tidak punya stok sendiri, transfer komponennya", ErrTransferInvalid, name)
+		}
+		if l.VariantID == nil && hasVariants {
+			return fmt.Errorf("%w: produk '%s' punya varian, variant_id wajib diisi", ErrTransferInvalid, name)
+		}
+		if l.VariantID != nil {
+			var variantArchived bool
+			err := tx.QueryRow("SELECT deleted_at IS NOT NULL FROM product_variants WHERE id = $1 AND product_id = $2", *l.VariantID, l.ProductID).Scan(&variantArchived)
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("%w: varian dengan ID %d untuk produk '%s'", ErrVariantNotFound, *l.VariantID, name)
+			}
+			if err != nil {
+				return err
+			}
+			if variantArchived {
+				return fmt.Errorf("%w: varian %d produk '%s' sudah diarsipkan", ErrTransferInvalid, *l.VariantID, name)
+			}
+		}
+
+		err = tx.QueryRow("INSERT INTO stock_transfer_lines (transfer_id, product_id, variant_id, quantity, note) VALUES ($1, $2, $3, $4, $5) RETURNING id",
+			transferID, l.ProductID, l.VariantID, l.Quantity, l.Note).Scan(&l.ID)
+		if err != nil {
+			return err
+		}
+		l.TransferID = transferID
+	}
+	return nil
+}
+
+type transferLine struct {
+	id        int
+	productID int
+	variantID *int
+	quantity  models.Quantity
+	received  models.Quantity
+	note      string
+}
+
+func transferLines(tx *sql.Tx, transferID int) ([]transferLine, error) {
+	rows, err := tx.Query("SELECT id, product_id, variant_id, quantity, note FROM stock_transfer_lines WHERE transfer_id = $1 ORDER BY id", transferID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lines []transferLine
+	for rows.Next() {
+		var l transferLine
+		if err := rows.Scan(&l.id, &l.productID, &l.variantID, &l.quantity, &l.note); err != nil {
+			return nil, err
+		}
+		lines = append(lines, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("%w: transfer belum punya baris", ErrTransferInvalid)
+	}
+	return lines, nil
+}
+
+// lockTransferProduct mengunci baris produk (dan varian) sebelum stoknya diubah, mengembalikan nama tampilannya
+func lockTransferProduct(tx *sql.Tx, productID int, variantID *int) (string, error) {
+	var name string
+	if err := tx.QueryRow("SELECT name FROM products WHERE id = $1 FOR UPDATE", productID).Scan(&name); err != nil {
+		return "", err
+	}
+	if variantID != nil {
+		var variantName string
+		if err := tx.QueryRow("SELECT name FROM product_variants WHERE id = $1 FOR UPDATE", *variantID).Scan(&variantName); err != nil {
+			return "", err
+		}
+		name += " (" + variantName + ")"
+	}
+	return name, nil
+}
+
+func variantKey(variantID *int) int {
+	if variantID == nil {
+		return 0
+	}
+	return *variantID
+}
@@ -0,0 +1,357 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"kasir-api/internal/models"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type WebhookRepository struct {
+	db *sql.DB
+}
+
+func NewWebhookRepository(db *sql.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+// webhookColumns harus sama urutannya dengan scanWebhook, secret sengaja tidak ikut dibaca
+const webhookColumns = "id, url, events, description, active, version, deleted_at, created_at"
+
+func scanWebhook(row rowScanner) (models.WebhookSubscription, error) {
+	var s models.WebhookSubscription
+	var events []string
+	err := row.Scan(&s.ID, &s.URL, pq.Array(&events), &s.Description, &s.Active, &s.Version, &s.DeletedAt, &s.CreatedAt)
+	s.Events = events
+	return s, err
+}
+
+func (repo *WebhookRepository) GetAll(includeArchived bool) ([]models.WebhookSubscription, error) {
+	query := "SELECT " + webhookColumns + " FROM webhook_subscriptions"
+	if !includeArchived {
+		query += " WHERE deleted_at IS NULL"
+	}
+	query += " ORDER BY id"
+
+	rows, err := repo.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subscriptions := make([]models.WebhookSubscription, 0)
+	for rows.Next() {
+		s, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, s)
+	}
+	return subscriptions, rows.Err()
+}
+
+func (repo *WebhookRepository) GetByID(id int) (*models.WebhookSubscription, error) {
+	s, err := scanWebhook(repo.db.QueryRow("SELECT "+webhookColumns+" FROM webhook_subscriptions WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (repo *WebhookRepository) Create(actor string, s *models.WebhookSubscription) error {
+	query := `INSERT INTO webhook_subscriptions (url, events, secret, description, active) VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, version, created_at`
+	return queryRowAs(repo.db, actor, query, []interface{}{s.URL, pq.Array(s.Events), s.Secret, s.Description, s.Active},
+		&s.ID, &s.Version, &s.CreatedAt)
+}
+
+// Update - s.Version berisi versi yang diharapkan (0 = tanpa cek versi), s.Secret kosong berarti secret lama dipakai terus
+func (repo *WebhookRepository) Update(actor string, s *models.WebhookSubscription) error {
+	query := `UPDATE webhook_subscriptions SET url = $1, events = $2, secret = COALESCE(NULLIF($3, ''), secret),
+			description = $4, active = $5, version = version + 1
+		WHERE id = $6 AND deleted_at IS NULL AND ($7 = 0 OR version = $7)
+		RETURNING version, created_at`
+	err := queryRowAs(repo.db, actor, query, []interface{}{s.URL, pq.Array(s.Events), s.Secret, s.Description, s.Active, s.ID, s.Version},
+		&s.Version, &s.CreatedAt)
+	if err == sql.ErrNoRows {
+		return repo.missingOrStale(s.ID)
+	}
+	return err
+}
+
+// Delete - soft delete supaya log pengiriman tetap bisa dibaca. Pengiriman yang masih antre ditandai gagal.
+func (repo *WebhookRepository) Delete(actor string, id, version int) error {
+	tx, err := beginTx(repo.db, actor)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE webhook_subscriptions SET deleted_at = NOW(), version = version + 1
+		WHERE id = $1 AND deleted_at IS NULL AND ($2 = 0 OR version = $2)`
+	result, err := tx.Exec(query, id, version)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return repo.missingOrStale(id)
+	}
+
+	_, err = tx.Exec("UPDATE webhook_deliveries SET status = $1, last_error = $2 WHERE subscription_id = $3 AND status = $4",
+		models.DeliveryFailed, "langganan dihapus sebelum terkirim", id, models.DeliveryPending)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// missingOrStale - langganan yang sudah dihapus dianggap tidak ada
+func (repo *WebhookRepository) missingOrStale(id int) error {
+	var exists bool
+	err := repo.db.QueryRow("SELECT EXISTS (SELECT 1 FROM webhook_subscriptions WHERE id = $1 AND deleted_at IS NULL)", id).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrWebhookNotFound
+	}
+	return ErrVersionMismatch
+}
+
+const deliveryColumns = `d.id, d.subscription_id, d.event_id, e.event_type, d.status, d.attempts,
+	d.next_attempt_at, d.last_status_code, d.last_error, d.created_at, d.delivered_at`
+
+func scanDelivery(row rowScanner) (models.WebhookDelivery, error) {
+	var d models.WebhookDelivery
+	var nextAttemptAt time.Time
+	err := row.Scan(&d.ID, &d.SubscriptionID, &d.EventID, &d.EventType, &d.Status, &d.AttemptCount,
+		&nextAttemptAt, &d.LastStatusCode, &d.LastError, &d.CreatedAt, &d.DeliveredAt)
+	// Jadwal berikutnya hanya berarti selama masih antre
+	if d.Status == models.DeliveryPending {
+		d.NextAttemptAt = &nextAttemptAt
+	}
+	return d, err
+}
+
+// GetDeliveries - log pengiriman satu langganan, terbaru dulu
+func (repo *WebhookRepository) GetDeliveries(filter models.DeliveryFilter) ([]models.WebhookDelivery, error) {
+	query := "SELECT " + deliveryColumns + " FROM webhook_deliveries d JOIN outbox_events e ON e.id = d.event_id WHERE d.subscription_id = $1"
+	args := []interface{}{filter.SubscriptionID}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		query += fmt.Sprintf(" AND d.status = $%d", len(args))
+	}
+	if filter.EventType != "" {
+		args = append(args, filter.EventType)
+		query += fmt.Sprintf(" AND e.event_type = $%d", len(args))
+	}
+	args = append(args, filter.Limit, filter.Offset)
+	query += fmt.Sprintf(" ORDER BY d.id DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := make([]models.WebhookDelivery, 0)
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// GetDelivery - detail pengiriman beserta isi event dan semua percobaannya
+func (repo *WebhookRepository) GetDelivery(subscriptionID int, deliveryID int64) (*models.WebhookDelivery, error) {
+	query := "SELECT " + deliveryColumns + " FROM webhook_deliveries d JOIN outbox_events e ON e.id = d.event_id WHERE d.id = $1 AND d.subscription_id = $2"
+	d, err := scanDelivery(repo.db.QueryRow(query, deliveryID, subscriptionID))
+	if err == sql.ErrNoRows {
+		return nil, ErrDeliveryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	event := &models.WebhookEvent{ID: d.EventID, Type: d.EventType}
+	var payload []byte
+	if err := repo.db.QueryRow("SELECT created_at, payload FROM outbox_events WHERE id = $1", d.EventID).Scan(&event.CreatedAt, &payload); err != nil {
+		return nil, err
+	}
+	event.Data = payload
+	d.Event = event
+
+	rows, err := repo.db.Query(`SELECT attempt, status_code, error, duration_ms, attempted_at
+		FROM webhook_delivery_attempts WHERE delivery_id = $1 ORDER BY id`, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	d.Attempts = make([]models.WebhookDeliveryAttempt, 0)
+	for rows.Next() {
+		var a models.WebhookDeliveryAttempt
+		if err := rows.Scan(&a.Attempt, &a.StatusCode, &a.Error, &a.DurationMS, &a.AttemptedAt); err != nil {
+			return nil, err
+		}
+		d.Attempts = append(d.Attempts, a)
+	}
+	return &d, rows.Err()
+}
+
+// RetryDelivery menjadwalkan ulang pengiriman yang gagal (atau mengirim ulang yang sudah berhasil)
+// sekarang juga, dengan jatah percobaan penuh lagi
+func (repo *WebhookRepository) RetryDelivery(subscriptionID int, deliveryID int64) error {
+	result, err := repo.db.Exec(`UPDATE webhook_deliveries SET status = $1, attempts = 0, next_attempt_at = NOW(), delivered_at = NULL
+		WHERE id = $2 AND subscription_id = $3 AND status <> $1`, models.DeliveryPending, deliveryID, subscriptionID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows > 0 {
+		return nil
+	}
+
+	var exists bool
+	err = repo.db.QueryRow("SELECT EXISTS (SELECT 1 FROM webhook_deliveries WHERE id = $1 AND subscription_id = $2)", deliveryID, subscriptionID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrDeliveryNotFound
+	}
+	return ErrDeliveryPending
+}
+
+// FanOutEvents menyebar event outbox yang belum diproses ke pengiriman untuk setiap langganan aktif
+// yang berlangganan jenis event-nya. Event tanpa pelanggan tetap ditandai selesai.
+// SKIP LOCKED supaya beberapa instance server tidak memproses event yang sama.
+func (repo *WebhookRepository) FanOutEvents(limit int) (int64, error) {
+	result, err := repo.db.Exec(`
+		WITH claimed AS (
+			SELECT id, event_type FROM outbox_events
+			WHERE dispatched_at IS NULL
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		), fanned AS (
+			INSERT INTO webhook_deliveries (subscription_id, event_id)
+			SELECT s.id, c.id FROM claimed c
+			JOIN webhook_subscriptions s ON c.event_type = ANY(s.events) AND s.active AND s.deleted_at IS NULL
+			ON CONFLICT (subscription_id, event_id) DO NOTHING
+		)
+		UPDATE outbox_events SET dispatched_at = NOW() WHERE id IN (SELECT id FROM claimed)`, limit)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// ClaimDueDeliveries mengambil pengiriman yang sudah jatuh tempo dan menaikkan hitungan percobaannya.
+// next_attempt_at digeser sejauh lease supaya tidak diambil dispatcher lain selama sedang dikirim;
+// kalau proses mati di tengah jalan, pengiriman otomatis diambil lagi setelah lease habis.
+// Langganan yang dinonaktifkan atau dihapus tidak dikirimi.
+func (repo *WebhookRepository) ClaimDueDeliveries(limit int, lease time.Duration) ([]models.PendingDelivery, error) {
+	rows, err := repo.db.Query(`
+		UPDATE webhook_deliveries d SET attempts = d.attempts + 1, next_attempt_at = NOW() + make_interval(secs => $2)
+		FROM outbox_events e, webhook_subscriptions s
+		WHERE d.id IN (
+				SELECT due.id FROM webhook_deliveries due
+				JOIN webhook_subscriptions ds ON ds.id = due.subscription_id
+				WHERE due.status = $3 AND due.next_attempt_at <= NOW() AND ds.active AND ds.deleted_at IS NULL
+				ORDER BY due.next_attempt_at, due.id
+				LIMIT $1
+				FOR UPDATE OF due SKIP LOCKED
+			)
+			AND e.id = d.event_id AND s.id = d.subscription_id
+		RETURNING d.id, d.attempts, s.url, s.secret, e.id, e.event_type, e.created_at, e.payload`,
+		limit, int(lease.Seconds()), models.DeliveryPending)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []models.PendingDelivery
+	for rows.Next() {
+		var d models.PendingDelivery
+		var payload []byte
+		err := rows.Scan(&d.ID, &d.Attempt, &d.URL, &d.Secret, &d.Event.ID, &d.Event.Type, &d.Event.CreatedAt, &payload)
+		if err != nil {
+			return nil, err
+		}
+		d.Event.Data = payload
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// RecordAttempt mencatat hasil satu percobaan kirim dan status pengiriman sesudahnya.
+// nextAttemptAt hanya dipakai kalau status masih pending.
+func (repo *WebhookRepository) RecordAttempt(deliveryID int64, attempt models.WebhookDeliveryAttempt, status string, nextAttemptAt time.Time) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`INSERT INTO webhook_delivery_attempts (delivery_id, attempt, status_code, error, duration_ms)
+		VALUES ($1, $2, $3, $4, $5)`,
+		deliveryID, attempt.Attempt, attempt.StatusCode, attempt.Error, attempt.DurationMS)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`UPDATE webhook_deliveries SET status = $1, last_status_code = $2, last_error = $3,
+			next_attempt_at = CASE WHEN $1 = 'pending' THEN $4 ELSE next_attempt_at END,
+			delivered_at = CASE WHEN $1 = 'succeeded' THEN NOW() END
+		WHERE id = $5`, status, attempt.StatusCode, attempt.Error, nextAttemptAt, deliveryID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// PurgeHistory menghapus pengiriman yang sudah selesai (berhasil atau gagal) sebelum before beserta
+// log percobaannya, lalu event outbox yang sudah disebar sebelum before dan tidak punya pengiriman lagi.
+// Pengiriman yang masih pending tidak disentuh, begitu juga event-nya.
+func (repo *WebhookRepository) PurgeHistory(before time.Time) (int64, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Percobaan ikut terhapus lewat ON DELETE CASCADE
+	_, err = tx.Exec(`DELETE FROM webhook_deliveries
+		WHERE status <> $1 AND COALESCE(delivered_at, next_attempt_at) < $2`, models.DeliveryPending, before)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := tx.Exec(`DELETE FROM outbox_events e
+		WHERE e.dispatched_at < $1
+			AND NOT EXISTS (SELECT 1 FROM webhook_deliveries d WHERE d.event_id = e.id)`, before)
+	if err != nil {
+		return 0, err
+	}
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return purged, tx.Commit()
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"fmt"
+	"kasir-api/internal/models"
+	"kasir-api/internal/repositories"
+	"time"
+)
+
+// Aksi yang dicatat trigger audit
+var auditActions = map[string]bool{"create": true, "update": true, "delete": true, "restore": true, "checkout": true}
+
+type AuditService struct {
+	repo *repositories.AuditRepository
+}
+
+func NewAuditService(repo *repositories.AuditRepository) *AuditService {
+	return &AuditService{repo: repo}
+}
+
+// GetAll - filter.End berupa tanggal, dibulatkan ke akhir hari seperti laporan
+func (s *AuditService) GetAll(filter models.AuditFilter) ([]models.AuditEntry, error) {
+	if filter.Action != "" && !auditActions[filter.Action] {
+		return nil, fmt.Errorf("%w: aksi '%s' tidak dikenal (create, update, delete, restore, checkout)", ErrValidation, filter.Action)
+	}
+	if filter.EntityID != "" && filter.Entity == "" {
+		return nil, fmt.Errorf("%w: entity_id harus disertai entity", ErrValidation)
+	}
+	if filter.End != nil {
+		end := time.Date(filter.End.Year(), filter.End.Month(), filter.End.Day(), 23, 59, 59, 999999999, filter.End.Location())
+		filter.End = &end
+	}
+	if filter.Start != nil && filter.End != nil && filter.End.Before(*filter.Start) {
+		return nil, fmt.Errorf("%w: end_date sebelum start_date", ErrValidation)
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultHistoryLimit
+	}
+	if filter.Limit > maxHistoryLimit {
+		return nil, fmt.Errorf("%w: limit maksimal %d", ErrValidation, maxHistoryLimit)
+	}
+	if filter.Offset < 0 {
+		return nil, fmt.Errorf("%w: offset tidak boleh negatif", ErrValidation)
+	}
+	return s.repo.GetAll(filter)
+}
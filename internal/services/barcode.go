@@ -0,0 +1,57 @@
+package services
+
+import (
+	"fmt"
+	"kasir-api/internal/models"
+	"strconv"
+)
+
+// scaleBarcode - isi barcode timbangan EAN-13 berawalan 2x.
+// Format: PP IIIII VVVVV C (awalan, kode PLU, nilai, check digit).
+// Awalan 20-24 berisi berat dalam gram, awalan 25-29 berisi harga dalam rupiah.
+type scaleBarcode struct {
+	PLU string
+	// Weight dalam kg (satuan dasar produk timbangan), nol untuk barcode harga
+	Weight models.Quantity
+	// Price dalam rupiah, nol untuk barcode berat
+	Price int
+}
+
+func parseScaleBarcode(code string) (*scaleBarcode, error) {
+	if len(code) != 13 {
+		return nil, fmt.Errorf("%w: barcode timbangan harus 13 digit", ErrValidation)
+	}
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return nil, fmt.Errorf("%w: barcode hanya boleh berisi angka", ErrValidation)
+		}
+	}
+	if code[0] != '2' {
+		return nil, fmt.Errorf("%w: barcode %s bukan barcode timbangan (awalan 2x)", ErrValidation, code)
+	}
+	if ean13CheckDigit(code[:12]) != code[12] {
+		return nil, fmt.Errorf("%w: check digit barcode %s tidak valid", ErrValidation, code)
+	}
+
+	value, _ := strconv.Atoi(code[7:12])
+	barcode := &scaleBarcode{PLU: code[2:7]}
+	if code[1] <= '4' { // gram = perseribu kg
+		barcode.Weight = models.Quantity(value)
+	} else {
+		barcode.Price = value
+	}
+	return barcode, nil
+}
+
+// ean13CheckDigit - bobot 1 dan 3 bergantian dari kiri untuk 12 digit pertama
+func ean13CheckDigit(digits string) byte {
+	sum := 0
+	for i, c := range digits {
+		d := int(c - '0')
+		if i%2 == 1 {
+			d *= 3
+		}
+		sum += d
+	}
+	return byte('0' + (10-sum%10)%10)
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"errors"
+	"kasir-api/internal/models"
+	"testing"
+)
+
+func TestParseScaleBarcode(t *testing.T) {
+	tests := []struct {
+		code    string
+		want    scaleBarcode
+		wantErr bool
+	}{
+		// awalan 20-24: berat dalam gram
+		{code: "2012345015005", want: scaleBarcode{PLU: "12345", Weight: models.Quantity(1500)}},
+		{code: "2212345002504", want: scaleBarcode{PLU: "12345", Weight: models.Quantity(250)}},
+		// awalan 25-29: harga dalam rupiah
+		{code: "2500042125009", want: scaleBarcode{PLU: "00042", Price: 12500}},
+		{code: "201234501500", wantErr: true},
+		{code: "20123450150055", wantErr: true},
+		{code: "20123450150x5", wantErr: true},
+		{code: "4006381333931", wantErr: true},
+		{code: "2012345015004", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseScaleBarcode(tt.code)
+		if tt.wantErr {
+			if !errors.Is(err, ErrValidation) {
+				t.Errorf("parseScaleBarcode(%q) error = %v, want ErrValidation", tt.code, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseScaleBarcode(%q) error = %v", tt.code, err)
+			continue
+		}
+		if *got != tt.want {
+			t.Errorf("parseScaleBarcode(%q) = %+v, want %+v", tt.code, *got, tt.want)
+		}
+	}
+}
+
+func TestEAN13CheckDigit(t *testing.T) {
+	tests := []struct {
+		digits string
+		want   byte
+	}{
+		{"400638133393", '1'},
+		{"590123412345", '7'},
+		{"201234501500", '5'},
+		{"000000000000", '0'},
+	}
+	for _, tt := range tests {
+		if got := ean13CheckDigit(tt.digits); got != tt.want {
+			t.Errorf("ean13CheckDigit(%q) = %c, want %c", tt.digits, got, tt.want)
+		}
+	}
+}
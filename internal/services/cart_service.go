@@ -0,0 +1,159 @@
+package services
+
+import (
+	"fmt"
+	"kasir-api/internal/models"
+	"kasir-api/internal/repositories"
+	"strings"
+)
+
+type CartService struct {
+	repo *repositories.CartRepository
+}
+
+func NewCartService(repo *repositories.CartRepository) *CartService {
+	return &CartService{repo: repo}
+}
+
+var cartStatuses = map[string]bool{
+	models.CartOpen:      true,
+	models.CartHeld:      true,
+	models.CartConverted: true,
+	models.CartCancelled: true,
+}
+
+// GetAll - statuses kosong berarti keranjang yang masih aktif, outletID nil berarti semua outlet
+func (s *CartService) GetAll(statuses []string, outletID *int) ([]models.Cart, error) {
+	for _, status := range statuses {
+		if !cartStatuses[status] {
+			return nil, fmt.Errorf("%w: status '%s' tidak dikenal (open, held, converted, cancelled)", ErrValidation, status)
+		}
+	}
+	return s.repo.GetAll(statuses, outletID)
+}
+
+func (s *CartService) GetByID(id int) (*models.Cart, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *CartService) Create(actor string, cart *models.Cart) (*models.Cart, error) {
+	if err := validateCartHeader(cart); err != nil {
+		return nil, err
+	}
+	for i := range cart.Lines {
+		if err := validateCartLine(&cart.Lines[i]); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.repo.Create(actor, cart); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(cart.ID)
+}
+
+func (s *CartService) Update(actor string, cart *models.Cart) (*models.Cart, error) {
+	if err := validateCartHeader(cart); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(actor, cart); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(cart.ID)
+}
+
+func (s *CartService) AddLine(actor string, cartID, version int, line *models.CartLine) (*models.Cart, error) {
+	if err := validateCartLine(line); err != nil {
+		return nil, err
+	}
+	if err := s.repo.AddLine(actor, cartID, version, line); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(cartID)
+}
+
+// UpdateLine - jumlah, catatan dan modifier bisa diubah selama dapur belum mulai membuat,
+// ganti produk berarti hapus lalu tambah baris
+func (s *CartService) UpdateLine(actor string, cartID, version int, line *models.CartLine) (*models.Cart, error) {
+	if line.Quantity <= 0 {
+		return nil, fmt.Errorf("%w: jumlah harus lebih dari 0", ErrValidation)
+	}
+	if err := validateModifierIDs(line.ModifierIDs); err != nil {
+		return nil, err
+	}
+	line.Note = strings.TrimSpace(line.Note)
+	if err := s.repo.UpdateLine(actor, cartID, version, line); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(cartID)
+}
+
+func (s *CartService) DeleteLine(actor string, cartID, version, lineID int) (*models.Cart, error) {
+	if err := s.repo.DeleteLine(actor, cartID, version, lineID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(cartID)
+}
+
+// Hold memarkir keranjang supaya kasir bisa melayani pelanggan lain
+func (s *CartService) Hold(actor string, id, version int) (*models.Cart, error) {
+	return s.setStatus(actor, id, version, models.CartHeld)
+}
+
+func (s *CartService) Resume(actor string, id, version int) (*models.Cart, error) {
+	return s.setStatus(actor, id, version, models.CartOpen)
+}
+
+func (s *CartService) Cancel(actor string, id, version int) (*models.Cart, error) {
+	return s.setStatus(actor, id, version, models.CartCancelled)
+}
+
+func (s *CartService) setStatus(actor string, id, version int, status string) (*models.Cart, error) {
+	if err := s.repo.SetStatus(actor, id, version, status); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(id)
+}
+
+// Checkout menutup keranjang menjadi transaksi dengan aturan yang sama seperti /api/checkout
+func (s *CartService) Checkout(actor string, id, version int, req models.CartCheckoutRequest) (*models.Transaction, error) {
+	if req.RedeemPoints < 0 {
+		return nil, fmt.Errorf("%w: redeem_points tidak boleh negatif", ErrValidation)
+	}
+	if err := validatePayments(req.Payments); err != nil {
+		return nil, err
+	}
+	return s.repo.Checkout(actor, id, version, req)
+}
+
+func validateCartHeader(cart *models.Cart) error {
+	cart.Label = strings.TrimSpace(cart.Label)
+	cart.Note = strings.TrimSpace(cart.Note)
+	if len(cart.Label) > 100 {
+		return fmt.Errorf("%w: label maksimal 100 karakter", ErrValidation)
+	}
+	return nil
+}
+
+func validateCartLine(line *models.CartLine) error {
+	if line.ProductID <= 0 {
+		return fmt.Errorf("%w: product_id wajib diisi", ErrValidation)
+	}
+	if line.Quantity <= 0 {
+		return fmt.Errorf("%w: jumlah produk ID %d harus lebih dari 0", ErrValidation, line.ProductID)
+	}
+	line.Unit = strings.TrimSpace(line.Unit)
+	line.Note = strings.TrimSpace(line.Note)
+	return validateModifierIDs(line.ModifierIDs)
+}
+
+// validateModifierIDs - satu modifier tidak boleh dipilih dua kali di baris yang sama
+func validateModifierIDs(ids []int) error {
+	seen := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			return fmt.Errorf("%w: modifier ID %d dipilih lebih dari sekali", ErrValidation, id)
+		}
+		seen[id] = true
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"kasir-api/internal/models"
+	"kasir-api/internal/spreadsheet"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// catalogColumns - kolom file impor/ekspor katalog, urutan sama dengan hasil ekspor
+var catalogColumns = []string{"sku", "name", "price", "cost", "stock", "base_unit", "quantity_precision", "plu", "category_id"}
+
+// maxImportRows membatasi ukuran satu kali impor supaya transaksi DB tidak terlalu panjang
+const maxImportRows = 10000
+
+// ImportCatalog memvalidasi file lalu meng-upsert produk berdasarkan SKU.
+// Dry run menjalankan semuanya di transaksi DB yang kemudian dibatalkan,
+// jadi pelanggaran constraint (SKU/PLU dobel, kategori tidak ada) ikut terlaporkan.
+func (s *ProductService) ImportCatalog(actor string, format spreadsheet.Format, data []byte, mode string, dryRun bool) (*models.ImportResult, error) {
+	if mode == "" {
+		mode = models.ImportAllOrNothing
+	}
+	if mode != models.ImportAllOrNothing && mode != models.ImportSkipInvalid {
+		return nil, fmt.Errorf("%w: mode harus %s atau %s", ErrValidation, models.ImportAllOrNothing, models.ImportSkipInvalid)
+	}
+
+	// +1 untuk baris header
+	table, err := spreadsheet.Read(format, data, maxImportRows+1)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+	rows, rowErrors, err := parseCatalog(table)
+	if err != nil {
+		return nil, err
+	}
+
+	skipInvalid := mode == models.ImportSkipInvalid
+	commit := !dryRun && (skipInvalid || len(rowErrors) == 0)
+	result, err := s.repo.ImportProducts(actor, rows, skipInvalid, commit)
+	if err != nil {
+		return nil, err
+	}
+
+	result.DryRun = dryRun
+	result.Mode = mode
+	result.TotalRows = len(rows) + len(rowErrors)
+	result.Errors = append(rowErrors, result.Errors...)
+	slices.SortStableFunc(result.Errors, func(a, b models.ImportError) int { return a.Line - b.Line })
+	result.Skipped = len(result.Errors)
+	return result, nil
+}
+
+// ExportCatalog menulis produk (tanpa varian) dengan kolom yang sama seperti file impor
+func (s *ProductService) ExportCatalog(w io.Writer, format spreadsheet.Format, filter models.ProductFilter) error {
+	products, err := s.repo.GetAll(filter)
+	if err != nil {
+		return err
+	}
+
+	header := make([]interface{}, len(catalogColumns))
+	for i, c := range catalogColumns {
+		header[i] = c
+	}
+	table := [][]interface{}{header}
+	for _, p := range products {
+		var sku, plu, categoryID interface{}
+		if p.SKU != nil {
+			sku = *p.SKU
+		}
+		if p.PLU != nil {
+			plu = *p.PLU
+		}
+		if p.CategoryID != nil {
+			categoryID = *p.CategoryID
+		}
+		table = append(table, []interface{}{sku, p.Name, p.Price, p.Cost, p.Stock.Float64(), p.BaseUnit, p.QuantityPrecision, plu, categoryID})
+	}
+	return spreadsheet.Write(w, format, table)
+}
+
+// parseCatalog membaca header dan setiap baris. Error per baris dikumpulkan,
+// error pada header (kolom tidak dikenal, tanpa sku) menggagalkan seluruh file.
+func parseCatalog(table [][]string) ([]models.ProductImportRow, []models.ImportError, error) {
+	if len(table) == 0 {
+		return nil, nil, fmt.Errorf("%w: file kosong", ErrValidation)
+	}
+	if len(table)-1 > maxImportRows {
+		return nil, nil, fmt.Errorf("%w: maksimal %d baris per impor", ErrValidation, maxImportRows)
+	}
+
+	header := make([]string, len(table[0]))
+	columns := make(map[string]bool)
+	for i, h := range table[0] {
+		h = strings.ToLower(strings.TrimSpace(h))
+		if h == "" {
+			continue
+		}
+		if !slices.Contains(catalogColumns, h) {
+			return nil, nil, fmt.Errorf("%w: kolom '%s' tidak dikenal, kolom yang didukung: %s", ErrValidation, h, strings.Join(catalogColumns, ", "))
+		}
+		if columns[h] {
+			return nil, nil, fmt.Errorf("%w: kolom '%s' muncul lebih dari sekali", ErrValidation, h)
+		}
+		header[i] = h
+		columns[h] = true
+	}
+	if !columns["sku"] {
+		return nil, nil, fmt.Errorf("%w: kolom sku wajib ada", ErrValidation)
+	}
+
+	rows := make([]models.ProductImportRow, 0, len(table)-1)
+	rowErrors := make([]models.ImportError, 0)
+	seen := make(map[string]int)
+	for i, record := range table[1:] {
+		line := i + 2
+		// Hanya sel yang terisi yang masuk values, sel kosong tidak mengubah produk yang sudah ada
+		values := make(map[string]string, len(header))
+		filled := make(map[string]bool, len(header))
+		for j, cell := range record {
+			if j < len(header) && header[j] != "" {
+				if cell = strings.TrimSpace(cell); cell != "" {
+					values[header[j]] = cell
+					filled[header[j]] = true
+				}
+			}
+		}
+		if len(filled) == 0 {
+			continue
+		}
+
+		product, err := parseCatalogRow(values)
+		if err == nil {
+			if first, ok := seen[*product.SKU]; ok {
+				err = fmt.Errorf("SKU sama dengan baris %d", first)
+			} else {
+				seen[*product.SKU] = line
+			}
+		}
+		if err != nil {
+			rowErrors = append(rowErrors, models.ImportError{Line: line, SKU: values["sku"], Message: err.Error()})
+			continue
+		}
+		rows = append(rows, models.ProductImportRow{Line: line, Product: *product, Filled: filled})
+	}
+	return rows, rowErrors, nil
+}
+
+func parseCatalogRow(values map[string]string) (*models.Product, error) {
+	product := &models.Product{BaseUnit: values["base_unit"]}
+	if product.BaseUnit == "" {
+		product.BaseUnit = defaultBaseUnit
+	}
+
+	sku := values["sku"]
+	if sku == "" {
+		return nil, fmt.Errorf("sku wajib diisi")
+	}
+	product.SKU = &sku
+
+	product.Name = values["name"]
+
+	var err error
+	if product.Price, err = parseRupiah(values["price"], "price"); err != nil {
+		return nil, err
+	}
+	if product.Cost, err = parseRupiah(values["cost"], "cost"); err != nil {
+		return nil, err
+	}
+	if raw := values["stock"]; raw != "" {
+		product.Stock, err = models.ParseQuantity(raw)
+		if err != nil {
+			return nil, fmt.Errorf("stock '%s' bukan angka dengan maksimal %d desimal", raw, models.MaxQuantityPrecision)
+		}
+		if product.Stock < 0 {
+			return nil, fmt.Errorf("stock tidak boleh negatif")
+		}
+	}
+	if raw := values["quantity_precision"]; raw != "" {
+		product.QuantityPrecision, err = strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("quantity_precision '%s' bukan bilangan bulat", raw)
+		}
+	}
+	// Kolom opsional dikosongkan dengan ImportClearMarker, sel kosong berarti tidak diubah
+	if raw := values["plu"]; raw != "" && raw != models.ImportClearMarker {
+		product.PLU = &raw
+	}
+	if raw := values["category_id"]; raw != "" && raw != models.ImportClearMarker {
+		id, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("category_id '%s' bukan bilangan bulat", raw)
+		}
+		product.CategoryID = &id
+	}
+
+	// Pesan validasi dipakai per baris, tanpa prefix ErrValidation
+	if err := validateProductFields(product); err != nil {
+		return nil, fmt.Errorf("%s", strings.TrimPrefix(err.Error(), ErrValidation.Error()+": "))
+	}
+	return product, nil
+}
+
+// parseRupiah - angka bulat >= 0, XLSX menyimpan angka sebagai float jadi "15000.0" tetap diterima
+func parseRupiah(raw, column string) (int, error) {
+	if raw == "" {
+		return 0, nil
+	}
+	f, err := strconv.ParseFloat(raw, 64)
+	if err != nil || f != math.Trunc(f) {
+		return 0, fmt.Errorf("%s '%s' harus bilangan bulat rupiah", column, raw)
+	}
+	if f < 0 {
+		return 0, fmt.Errorf("%s tidak boleh negatif", column)
+	}
+	return int(f), nil
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"errors"
+	"kasir-api/internal/models"
+	"strings"
+	"testing"
+)
+
+func TestParseRupiah(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    int
+		wantErr bool
+	}{
+		{raw: "", want: 0},
+		{raw: "15000", want: 15000},
+		// XLSX menyimpan angka sebagai float
+		{raw: "15000.0", want: 15000},
+		{raw: "0", want: 0},
+		{raw: "15000.5", wantErr: true},
+		{raw: "-100", wantErr: true},
+		{raw: "Rp15.000", wantErr: true},
+		{raw: "abc", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseRupiah(tt.raw, "price")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseRupiah(%q) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("parseRupiah(%q) = %d, want %d", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestParseCatalogHeader(t *testing.T) {
+	tests := []struct {
+		name   string
+		header []string
+		errMsg string
+	}{
+		{"unknown column", []string{"sku", "warna"}, "kolom 'warna' tidak dikenal"},
+		{"duplicate column", []string{"sku", "name", " Name "}, "kolom 'name' muncul lebih dari sekali"},
+		{"missing sku", []string{"name", "price"}, "kolom sku wajib ada"},
+	}
+	for _, tt := range tests {
+		_, _, err := parseCatalog([][]string{tt.header})
+		if !errors.Is(err, ErrValidation) || !strings.Contains(err.Error(), tt.errMsg) {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.errMsg)
+		}
+	}
+
+	if _, _, err := parseCatalog(nil); !errors.Is(err, ErrValidation) {
+		t.Errorf("file kosong: error = %v, want ErrValidation", err)
+	}
+}
+
+func TestParseCatalogRows(t *testing.T) {
+	table := [][]string{
+		{" SKU ", "name", "price", "stock", "", "plu", "category_id"},
+		{"A1", "Kopi", "15000", "2.5", "diabaikan", "12345", "3"},
+		// sel kosong tidak ikut Filled, jadi tidak mengubah produk yang sudah ada
+		{"A2", "", "", "", "", "", ""},
+		{"", "", "", "", "", "", ""},
+		{"A3", "Teh", "abc"},
+		{"A1", "Kopi lagi"},
+		{"A4", "", "", "", "", models.ImportClearMarker, models.ImportClearMarker, "kolom ekstra"},
+		{"A5", "", "", "-1"},
+		{"A6", "", "", "0.0005"},
+	}
+	rows, rowErrors, err := parseCatalog(table)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantLines := []int{2, 3, 7}
+	if len(rows) != len(wantLines) {
+		t.Fatalf("rows = %+v, want lines %v", rows, wantLines)
+	}
+	for i, line := range wantLines {
+		if rows[i].Line != line {
+			t.Errorf("rows[%d].Line = %d, want %d", i, rows[i].Line, line)
+		}
+	}
+
+	first := rows[0]
+	if *first.Product.SKU != "A1" || first.Product.Name != "Kopi" || first.Product.Price != 15000 ||
+		first.Product.Stock != 2500 || *first.Product.PLU != "12345" || *first.Product.CategoryID != 3 {
+		t.Errorf("rows[0].Product = %+v", first.Product)
+	}
+	if first.Product.BaseUnit != defaultBaseUnit {
+		t.Errorf("rows[0].Product.BaseUnit = %q, want %q", first.Product.BaseUnit, defaultBaseUnit)
+	}
+	for _, column := range []string{"sku", "name", "price", "stock", "plu", "category_id"} {
+		if !first.Filled[column] {
+			t.Errorf("rows[0].Filled[%q] = false", column)
+		}
+	}
+
+	if filled := rows[1].Filled; len(filled) != 1 || !filled["sku"] {
+		t.Errorf("rows[1].Filled = %v, want hanya sku", filled)
+	}
+
+	cleared := rows[2]
+	if cleared.Product.PLU != nil || cleared.Product.CategoryID != nil || !cleared.Filled["plu"] || !cleared.Filled["category_id"] {
+		t.Errorf("rows[2] = %+v, want plu dan category_id dikosongkan", cleared)
+	}
+
+	wantErrors := []struct {
+		line   int
+		sku    string
+		errMsg string
+	}{
+		{5, "A3", "price 'abc'"},
+		{6, "A1", "SKU sama dengan baris 2"},
+		{8, "A5", "stock tidak boleh negatif"},
+		{9, "A6", "stock '0.0005'"},
+	}
+	if len(rowErrors) != len(wantErrors) {
+		t.Fatalf("rowErrors = %+v, want %d error", rowErrors, len(wantErrors))
+	}
+	for i, want := range wantErrors {
+		got := rowErrors[i]
+		if got.Line != want.line || got.SKU != want.sku || !strings.Contains(got.Message, want.errMsg) {
+			t.Errorf("rowErrors[%d] = %+v, want line %d sku %s message %q", i, got, want.line, want.sku, want.errMsg)
+		}
+	}
+}
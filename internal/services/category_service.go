@@ -1,8 +1,11 @@
 package services
 
 import (
+	"fmt"
 	"kasir-api/internal/models"
 	"kasir-api/internal/repositories"
+	"slices"
+	"time"
 )
 
 type CategoryService struct {
@@ -15,22 +18,168 @@ func NewCategoryService(repo *repositories.CategoryRepository) *CategoryService
 
 // Add methods for CategoryService as needed
 
-func (s *CategoryService) Create(category *models.Category) error {
-	return s.repo.Create(category)
+func (s *CategoryService) Create(actor string, category *models.Category) error {
+	return s.repo.Create(actor, category)
 }
 
-func (s *CategoryService) GetAll() ([]models.Category, error) {
-	return s.repo.GetAll()
+// GetAll returns a flat list with breadcrumbs, or nested roots when filter.Tree is set
+func (s *CategoryService) GetAll(filter models.CategoryFilter) ([]models.Category, error) {
+	categories, err := s.repo.GetAll(filter.IncludeArchived)
+	if err != nil {
+		return nil, err
+	}
+	fillPaths(categories)
+
+	if filter.IncludeStats {
+		end := filter.SalesEnd
+		end = time.Date(end.Year(), end.Month(), end.Day(), 23, 59, 59, 999999999, end.Location())
+		if filter.SalesStart.After(end) {
+			return nil, fmt.Errorf("%w: start_date must not be after end_date", ErrValidation)
+		}
+		stats, err := s.repo.GetStats(filter.SalesStart, end)
+		if err != nil {
+			return nil, err
+		}
+		for i := range categories {
+			st := stats[categories[i].ID]
+			categories[i].Stats = &st
+		}
+	}
+
+	if filter.Tree {
+		return buildTree(categories), nil
+	}
+	return categories, nil
 }
 
 func (s *CategoryService) GetByID(id int) (*models.Category, error) {
-	return s.repo.GetByID(id)
+	category, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Breadcrumbs need the ancestors, the whole table is small enough to load
+	all, err := s.repo.GetAll(true)
+	if err != nil {
+		return nil, err
+	}
+	fillPaths(all)
+	for _, c := range all {
+		if c.ID == id {
+			category.Path = c.Path
+			category.Depth = c.Depth
+		}
+	}
+	return category, nil
+}
+
+// Move puts the category (with its subtree) under parentID, nil makes it a root
+func (s *CategoryService) Move(actor string, id, version int, parentID *int) (*models.Category, error) {
+	category, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	category.ParentID = parentID
+	category.Version = version
+	if err := s.repo.Update(actor, category); err != nil {
+		return nil, err
+	}
+	return s.GetByID(id)
+}
+
+func (s *CategoryService) GetSales(id int, startDate, endDate time.Time) (*models.CategorySales, error) {
+	endDate = time.Date(endDate.Year(), endDate.Month(), endDate.Day(), 23, 59, 59, 999999999, endDate.Location())
+	return s.repo.GetSales(id, startDate, endDate)
+}
+
+// fillPaths sets Path and Depth from the parent links. A category whose parent
+// is not in the list (e.g. archived and filtered out) is treated as a root.
+func fillPaths(categories []models.Category) {
+	index := make(map[int]int, len(categories))
+	for i, c := range categories {
+		index[c.ID] = i
+	}
+
+	for i := range categories {
+		var path []models.CategoryRef
+		for j, ok := i, true; ok && len(path) <= len(categories); {
+			c := categories[j]
+			path = append(path, models.CategoryRef{ID: c.ID, Name: c.Name})
+			if c.ParentID == nil {
+				break
+			}
+			j, ok = index[*c.ParentID]
+		}
+		slices.Reverse(path)
+		categories[i].Path = path
+		categories[i].Depth = len(path) - 1
+	}
 }
 
-func (s *CategoryService) Update(category *models.Category) error {
-	return s.repo.Update(category)
+// buildTree nests categories under their parents, keeping the original order among siblings
+func buildTree(categories []models.Category) []models.Category {
+	present := make(map[int]bool, len(categories))
+	for _, c := range categories {
+		present[c.ID] = true
+	}
+
+	children := make(map[int][]models.Category)
+	roots := make([]models.Category, 0)
+	for _, c := range categories {
+		if c.ParentID == nil || !present[*c.ParentID] {
+			roots = append(roots, c)
+			continue
+		}
+		children[*c.ParentID] = append(children[*c.ParentID], c)
+	}
+
+	var attach func(nodes []models.Category)
+	attach = func(nodes []models.Category) {
+		for i := range nodes {
+			nodes[i].Children = children[nodes[i].ID]
+			attach(nodes[i].Children)
+		}
+	}
+	attach(roots)
+	return roots
+}
+
+func (s *CategoryService) Update(actor string, category *models.Category) error {
+	return s.repo.Update(actor, category)
+}
+
+func (s *CategoryService) Delete(actor string, id, version int) error {
+	return s.repo.Delete(actor, id, version)
 }
 
-func (s *CategoryService) Delete(id int) error {
-	return s.repo.Delete(id)
+func (s *CategoryService) Restore(actor string, id, version int) (*models.Category, error) {
+	if err := s.repo.Restore(actor, id, version); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(id)
+}
+
+// Patch applies a JSON Merge Patch and returns the category as stored
+func (s *CategoryService) Patch(actor string, id, version int, patch []byte) (*models.Category, error) {
+	current, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if version != 0 && current.Version != version {
+		return nil, repositories.ErrVersionMismatch
+	}
+
+	category, err := applyMergePatch(current, patch)
+	if err != nil {
+		return nil, err
+	}
+
+	category.ID = id
+	category.Version = current.Version
+	if err := s.repo.Update(actor, category); err != nil {
+		return nil, err
+	}
+
+	return s.GetByID(id)
 }
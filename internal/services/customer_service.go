@@ -0,0 +1,190 @@
+package services
+
+import (
+	"fmt"
+	"kasir-api/internal/models"
+	"kasir-api/internal/repositories"
+	"math"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+const (
+	defaultHistoryLimit = 50
+	maxHistoryLimit     = 200
+)
+
+type CustomerService struct {
+	repo *repositories.CustomerRepository
+}
+
+func NewCustomerService(repo *repositories.CustomerRepository) *CustomerService {
+	return &CustomerService{repo: repo}
+}
+
+func (s *CustomerService) GetAll(filter models.CustomerFilter) ([]models.Customer, error) {
+	customers, err := s.repo.GetAll(filter)
+	if err != nil {
+		return nil, err
+	}
+	for i := range customers {
+		fillCustomerStats(customers[i].Stats)
+	}
+	return customers, nil
+}
+
+func (s *CustomerService) GetByID(id int) (*models.Customer, error) {
+	customer, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	fillCustomerStats(customer.Stats)
+	return customer, nil
+}
+
+// FindByPhone - pencarian pelanggan di kasir, format nomor bebas (spasi, strip, +62)
+func (s *CustomerService) FindByPhone(phone string) (*models.Customer, error) {
+	normalized, err := normalizePhone(phone)
+	if err != nil {
+		return nil, err
+	}
+	customer, err := s.repo.GetByPhone(normalized)
+	if err != nil {
+		return nil, err
+	}
+	fillCustomerStats(customer.Stats)
+	return customer, nil
+}
+
+func (s *CustomerService) Create(actor string, customer *models.Customer) error {
+	if err := validateCustomer(customer); err != nil {
+		return err
+	}
+	if err := s.repo.Create(actor, customer); err != nil {
+		return err
+	}
+	customer.Stats = &models.CustomerStats{}
+	return nil
+}
+
+func (s *CustomerService) Update(actor string, customer *models.Customer) (*models.Customer, error) {
+	if err := validateCustomer(customer); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(actor, customer); err != nil {
+		return nil, err
+	}
+	return s.GetByID(customer.ID)
+}
+
+func (s *CustomerService) Delete(actor string, id, version int) error {
+	return s.repo.Delete(actor, id, version)
+}
+
+func (s *CustomerService) Restore(actor string, id, version int) (*models.Customer, error) {
+	if err := s.repo.Restore(actor, id, version); err != nil {
+		return nil, err
+	}
+	return s.GetByID(id)
+}
+
+func (s *CustomerService) GetTransactions(customerID, limit, offset int) ([]models.Transaction, error) {
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+	if limit > maxHistoryLimit {
+		return nil, fmt.Errorf("%w: limit maksimal %d", ErrValidation, maxHistoryLimit)
+	}
+	if offset < 0 {
+		return nil, fmt.Errorf("%w: offset tidak boleh negatif", ErrValidation)
+	}
+	return s.repo.GetTransactions(customerID, limit, offset)
+}
+
+func (s *CustomerService) GetPoints(customerID, limit int) (*models.PointsStatement, error) {
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+	if limit > maxHistoryLimit {
+		return nil, fmt.Errorf("%w: limit maksimal %d", ErrValidation, maxHistoryLimit)
+	}
+	return s.repo.GetPoints(customerID, limit)
+}
+
+func validateCustomer(customer *models.Customer) error {
+	customer.Name = strings.TrimSpace(customer.Name)
+	if customer.Name == "" {
+		return fmt.Errorf("%w: nama pelanggan wajib diisi", ErrValidation)
+	}
+
+	if customer.Phone != nil && strings.TrimSpace(*customer.Phone) == "" {
+		customer.Phone = nil
+	}
+	if customer.Phone != nil {
+		phone, err := normalizePhone(*customer.Phone)
+		if err != nil {
+			return err
+		}
+		customer.Phone = &phone
+	}
+
+	if customer.Email != nil && strings.TrimSpace(*customer.Email) == "" {
+		customer.Email = nil
+	}
+	if customer.Email != nil {
+		addr, err := mail.ParseAddress(strings.TrimSpace(*customer.Email))
+		if err != nil || addr.Name != "" {
+			return fmt.Errorf("%w: email '%s' tidak valid", ErrValidation, *customer.Email)
+		}
+		email := strings.ToLower(addr.Address)
+		customer.Email = &email
+	}
+	return nil
+}
+
+// normalizePhone membuang spasi/tanda baca dan mengubah awalan +62/62 menjadi 0,
+// supaya "+62 812-3456-789" dan "0812 3456 789" dianggap nomor yang sama
+func normalizePhone(phone string) (string, error) {
+	var b strings.Builder
+	for i, r := range strings.TrimSpace(phone) {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '+' && i == 0:
+		case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')':
+		default:
+			return "", fmt.Errorf("%w: nomor telepon '%s' tidak valid", ErrValidation, phone)
+		}
+	}
+
+	digits := b.String()
+	if strings.HasPrefix(digits, "62") {
+		digits = "0" + digits[2:]
+	}
+	if len(digits) < 6 || len(digits) > 15 {
+		return "", fmt.Errorf("%w: nomor telepon '%s' tidak valid", ErrValidation, phone)
+	}
+	return digits, nil
+}
+
+// fillCustomerStats menghitung nilai turunan dari angka mentah repository
+func fillCustomerStats(st *models.CustomerStats) {
+	if st == nil || st.TransactionCount == 0 {
+		return
+	}
+	st.AverageOrderValue = st.LifetimeValue / st.TransactionCount
+	if st.FirstVisit == nil || st.LastVisit == nil {
+		return
+	}
+
+	span := st.LastVisit.Sub(*st.FirstVisit).Hours() / 24
+	if st.Visits > 1 {
+		days := math.Round(span/float64(st.Visits-1)*10) / 10
+		st.AverageDaysBetweenVisits = &days
+	}
+
+	// Dihitung sampai hari ini supaya pelanggan yang sudah lama tidak datang frekuensinya turun
+	months := max(time.Since(*st.FirstVisit).Hours()/24/30, 1)
+	st.VisitsPerMonth = math.Round(float64(st.Visits)/months*100) / 100
+}
@@ -0,0 +1,7 @@
+package services
+
+import "kasir-api/internal/repositories"
+
+// ErrValidation dibungkus oleh error input yang tidak valid, handler memetakannya ke 400.
+// Sama dengan repositories.ErrValidation supaya input yang baru bisa dicek di dalam transaksi DB ikut 400.
+var ErrValidation = repositories.ErrValidation
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"image"
+	"image/color"
+	_ "image/gif" // decoder GIF untuk image.Decode
+	"image/jpeg"
+	"image/png"
+)
+
+const (
+	// maxImageSize - batas ukuran file gambar produk (5 MB)
+	maxImageSize = 5 << 20
+	// maxImagePixels - batas resolusi supaya decode tidak menghabiskan memori
+	maxImagePixels = 40_000_000
+	// thumbnailSize - sisi terpanjang thumbnail dalam piksel
+	thumbnailSize = 256
+)
+
+// imageFormats - content type hasil sniffing yang diterima beserta ekstensi filenya
+var imageFormats = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// thumbnail mengecilkan gambar dengan rata-rata kotak (box filter) supaya sisi terpanjangnya
+// maksimal size piksel. Gambar yang sudah kecil tidak diperbesar.
+func thumbnail(src image.Image, size int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= size && h <= size {
+		return src
+	}
+
+	tw, th := size, h*size/w
+	if h > w {
+		tw, th = w*size/h, size
+	}
+	tw, th = max(tw, 1), max(th, 1)
+
+	dst := image.NewRGBA(image.Rect(0, 0, tw, th))
+	for y := 0; y < th; y++ {
+		y0, y1 := b.Min.Y+y*h/th, b.Min.Y+(y+1)*h/th
+		for x := 0; x < tw; x++ {
+			x0, x1 := b.Min.X+x*w/tw, b.Min.X+(x+1)*w/tw
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < max(y1, y0+1); sy++ {
+				for sx := x0; sx < max(x1, x0+1); sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			dst.SetRGBA64(x, y, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(bl / n), A: uint16(a / n)})
+		}
+	}
+	return dst
+}
+
+// encodeThumbnail - sumber JPEG tetap JPEG, selainnya PNG supaya transparansi tidak hilang
+func encodeThumbnail(img image.Image, contentType string) ([]byte, string, error) {
+	var buf bytes.Buffer
+	if contentType == "image/jpeg" {
+		err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85})
+		return buf.Bytes(), contentType, err
+	}
+	err := png.Encode(&buf, img)
+	return buf.Bytes(), "image/png", err
+}
+
+// randomHex - nama file acak supaya URL gambar tidak bisa ditebak
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,22 @@
+package services
+
+import (
+	"fmt"
+	"kasir-api/internal/models"
+	"kasir-api/internal/repositories"
+)
+
+type InventoryService struct {
+	repo *repositories.InventoryRepository
+}
+
+func NewInventoryService(repo *repositories.InventoryRepository) *InventoryService {
+	return &InventoryService{repo: repo}
+}
+
+func (s *InventoryService) GetExpiring(days int, outletID *int) ([]models.ExpiringLot, error) {
+	if days < 0 {
+		return nil, fmt.Errorf("%w: days tidak boleh negatif", ErrValidation)
+	}
+	return s.repo.GetExpiring(days, outletID)
+}
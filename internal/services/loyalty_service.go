@@ -0,0 +1,53 @@
+package services
+
+import (
+	"fmt"
+	"kasir-api/internal/models"
+	"kasir-api/internal/repositories"
+)
+
+type LoyaltyService struct {
+	repo *repositories.LoyaltyRepository
+}
+
+func NewLoyaltyService(repo *repositories.LoyaltyRepository) *LoyaltyService {
+	return &LoyaltyService{repo: repo}
+}
+
+func (s *LoyaltyService) GetSettings() (*models.LoyaltySettings, error) {
+	return s.repo.GetSettings()
+}
+
+func (s *LoyaltyService) UpdateSettings(actor string, settings *models.LoyaltySettings) error {
+	switch {
+	case settings.RupiahPerPoint < 0:
+		return fmt.Errorf("%w: rupiah_per_point tidak boleh negatif", ErrValidation)
+	case settings.PointValue <= 0:
+		return fmt.Errorf("%w: point_value harus lebih dari 0", ErrValidation)
+	case settings.MinRedeemPoints < 0:
+		return fmt.Errorf("%w: min_redeem_points tidak boleh negatif", ErrValidation)
+	case settings.ExpiryDays < 0:
+		return fmt.Errorf("%w: expiry_days tidak boleh negatif", ErrValidation)
+	}
+	return s.repo.UpdateSettings(actor, settings)
+}
+
+func (s *LoyaltyService) GetCategoryRates() ([]models.LoyaltyCategoryRate, error) {
+	return s.repo.GetCategoryRates()
+}
+
+func (s *LoyaltyService) SetCategoryRate(actor string, rate *models.LoyaltyCategoryRate) error {
+	if rate.RupiahPerPoint < 0 {
+		return fmt.Errorf("%w: rupiah_per_point tidak boleh negatif (0 = kategori tanpa poin)", ErrValidation)
+	}
+	return s.repo.SetCategoryRate(actor, rate)
+}
+
+func (s *LoyaltyService) DeleteCategoryRate(actor string, categoryID int) error {
+	return s.repo.DeleteCategoryRate(actor, categoryID)
+}
+
+// ExpirePoints - dipanggil job berkala, mengembalikan jumlah poin yang hangus
+func (s *LoyaltyService) ExpirePoints() (int, error) {
+	return s.repo.ExpirePoints()
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var ErrInvalidPatch = errors.New("invalid merge patch")
+
+// applyMergePatch menerapkan JSON Merge Patch (RFC 7396) ke salinan current.
+// Field yang tidak ada di patch tetap, field bernilai null dihapus (jadi zero value).
+func applyMergePatch[T any](current *T, patch []byte) (*T, error) {
+	var patchDoc interface{}
+	dec := json.NewDecoder(bytes.NewReader(patch))
+	dec.UseNumber()
+	if err := dec.Decode(&patchDoc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	if _, ok := patchDoc.(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("%w: patch harus berupa JSON object", ErrInvalidPatch)
+	}
+
+	raw, err := json.Marshal(current)
+	if err != nil {
+		return nil, err
+	}
+	var targetDoc interface{}
+	dec = json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&targetDoc); err != nil {
+		return nil, err
+	}
+
+	merged, err := json.Marshal(mergePatch(targetDoc, patchDoc))
+	if err != nil {
+		return nil, err
+	}
+
+	var result T
+	if err := json.Unmarshal(merged, &result); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return &result, nil
+}
+
+func mergePatch(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = map[string]interface{}{}
+	}
+
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = mergePatch(targetObj[key], value)
+	}
+	return targetObj
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type patchTarget struct {
+	Name    string      `json:"name"`
+	Price   int         `json:"price"`
+	SKU     *string     `json:"sku"`
+	Tags    []string    `json:"tags"`
+	Address patchNested `json:"address"`
+}
+
+type patchNested struct {
+	City   string `json:"city"`
+	Street string `json:"street"`
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	sku := "SKU-1"
+	newSKU := "SKU-2"
+	current := patchTarget{
+		Name:    "Kopi",
+		Price:   15000,
+		SKU:     &sku,
+		Tags:    []string{"minuman", "panas"},
+		Address: patchNested{City: "Bandung", Street: "Dago"},
+	}
+
+	tests := []struct {
+		name    string
+		patch   string
+		want    patchTarget
+		wantErr bool
+	}{
+		{name: "empty patch keeps everything", patch: `{}`, want: current},
+		{
+			name:  "omitted fields unchanged",
+			patch: `{"price": 18000}`,
+			want:  patchTarget{Name: "Kopi", Price: 18000, SKU: &sku, Tags: current.Tags, Address: current.Address},
+		},
+		{
+			name:  "null clears field",
+			patch: `{"sku": null, "tags": null}`,
+			want:  patchTarget{Name: "Kopi", Price: 15000, Address: current.Address},
+		},
+		{
+			name:  "nested object merged",
+			patch: `{"address": {"street": "Braga"}}`,
+			want:  patchTarget{Name: "Kopi", Price: 15000, SKU: &sku, Tags: current.Tags, Address: patchNested{City: "Bandung", Street: "Braga"}},
+		},
+		{
+			name:  "array replaced",
+			patch: `{"tags": ["dingin"], "sku": "SKU-2"}`,
+			want:  patchTarget{Name: "Kopi", Price: 15000, SKU: &newSKU, Tags: []string{"dingin"}, Address: current.Address},
+		},
+		{name: "unknown field ignored", patch: `{"color": "merah"}`, want: current},
+		{name: "not an object", patch: `["name"]`, wantErr: true},
+		{name: "invalid json", patch: `{"name":`, wantErr: true},
+		{name: "wrong type", patch: `{"price": "mahal"}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := applyMergePatch(&current, []byte(tt.patch))
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidPatch) {
+				t.Errorf("%s: error = %v, want ErrInvalidPatch", tt.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: error = %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(*got, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, *got, tt.want)
+		}
+	}
+	if current.Price != 15000 || *current.SKU != "SKU-1" || current.Address.Street != "Dago" {
+		t.Errorf("applyMergePatch mengubah current: %+v", current)
+	}
+}
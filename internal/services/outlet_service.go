@@ -0,0 +1,91 @@
+package services
+
+import (
+	"fmt"
+	"kasir-api/internal/models"
+	"kasir-api/internal/repositories"
+	"strings"
+)
+
+type OutletService struct {
+	repo *repositories.OutletRepository
+}
+
+func NewOutletService(repo *repositories.OutletRepository) *OutletService {
+	return &OutletService{repo: repo}
+}
+
+func (s *OutletService) GetAll(includeArchived bool) ([]models.Outlet, error) {
+	return s.repo.GetAll(includeArchived)
+}
+
+func (s *OutletService) GetByID(id int) (*models.Outlet, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *OutletService) Create(actor string, outlet *models.Outlet) error {
+	if err := validateOutlet(outlet); err != nil {
+		return err
+	}
+	return s.repo.Create(actor, outlet)
+}
+
+func (s *OutletService) Update(actor string, outlet *models.Outlet) (*models.Outlet, error) {
+	if err := validateOutlet(outlet); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(actor, outlet); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(outlet.ID)
+}
+
+func (s *OutletService) Delete(actor string, id, version int) error {
+	return s.repo.Delete(actor, id, version)
+}
+
+func (s *OutletService) GetStock(outletID int, productID *int) ([]models.OutletStock, error) {
+	return s.repo.GetStock(outletID, productID)
+}
+
+// AdjustStock mengganti stok outlet dengan hasil hitung fisik lalu mengembalikan stok terbaru produk tersebut
+func (s *OutletService) AdjustStock(actor string, outletID int, adj models.StockAdjustment) ([]models.OutletStock, error) {
+	if adj.ProductID <= 0 {
+		return nil, fmt.Errorf("%w: product_id wajib diisi", ErrValidation)
+	}
+	if adj.Quantity < 0 {
+		return nil, fmt.Errorf("%w: jumlah stok tidak boleh negatif", ErrValidation)
+	}
+	if err := s.repo.AdjustStock(actor, outletID, adj); err != nil {
+		return nil, err
+	}
+	return s.repo.GetStock(outletID, &adj.ProductID)
+}
+
+func validateOutlet(outlet *models.Outlet) error {
+	outlet.Code = strings.ToUpper(strings.TrimSpace(outlet.Code))
+	outlet.Name = strings.TrimSpace(outlet.Name)
+	outlet.Address = strings.TrimSpace(outlet.Address)
+	if outlet.Kind == "" {
+		outlet.Kind = models.OutletStore
+	}
+	if outlet.Kind != models.OutletStore && outlet.Kind != models.OutletWarehouse {
+		return fmt.Errorf("%w: jenis outlet '%s' tidak dikenal (store, warehouse)", ErrValidation, outlet.Kind)
+	}
+	if outlet.Code == "" {
+		return fmt.Errorf("%w: kode outlet wajib diisi", ErrValidation)
+	}
+	if len(outlet.Code) > 20 {
+		return fmt.Errorf("%w: kode outlet maksimal 20 karakter", ErrValidation)
+	}
+	if outlet.Name == "" {
+		return fmt.Errorf("%w: nama outlet wajib diisi", ErrValidation)
+	}
+	if len(outlet.Name) > 100 {
+		return fmt.Errorf("%w: nama outlet maksimal 100 karakter", ErrValidation)
+	}
+	if len(outlet.Address) > 255 {
+		return fmt.Errorf("%w: alamat outlet maksimal 255 karakter", ErrValidation)
+	}
+	return nil
+}
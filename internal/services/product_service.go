@@ -1,35 +1,636 @@
 package services
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
 	"kasir-api/internal/models"
 	model "kasir-api/internal/models"
 	"kasir-api/internal/repositories"
+	"kasir-api/internal/storage"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 )
 
+const defaultBaseUnit = "pcs"
+
 type ProductService struct {
-	repo *repositories.ProductRepository
+	repo   *repositories.ProductRepository
+	images storage.Storage
 }
 
-func NewProductService(repo *repositories.ProductRepository) *ProductService {
-	return &ProductService{repo: repo}
+func NewProductService(repo *repositories.ProductRepository, images storage.Storage) *ProductService {
+	return &ProductService{repo: repo, images: images}
 }
 
-func (s *ProductService) GetAll(name string) ([]models.Product, error) {
-	return s.repo.GetAll()
+func (s *ProductService) GetAll(filter models.ProductFilter) ([]models.Product, error) {
+	return s.repo.GetAll(filter)
 }
 
-func (s *ProductService) Create(data *model.Product) error {
-	return s.repo.Create(data)
+func (s *ProductService) Create(actor string, data *model.Product) error {
+	if data.BaseUnit == "" {
+		data.BaseUnit = defaultBaseUnit
+	}
+	if err := validateProduct(data); err != nil {
+		return err
+	}
+	return s.repo.Create(actor, data)
 }
 
 func (s *ProductService) GetByID(id int) (*model.Product, error) {
 	return s.repo.GetByID(id)
 }
 
-func (s *ProductService) Update(product *model.Product) error {
-	return s.repo.Update(product)
+// Update - PUT: body didekode di atas produk yang tersimpan, jadi field yang tidak dikirim
+// (klien lama yang belum mengenal cost, plu, category_id, dst.) tetap memakai nilai lama.
+// null mengosongkan field opsional. Hasil diambil ulang dari database.
+func (s *ProductService) Update(actor string, id, version int, body []byte) (*model.Product, error) {
+	current, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	product := *current
+	if err := json.Unmarshal(body, &product); err != nil {
+		return nil, fmt.Errorf("%w: body tidak valid: %v", ErrValidation, err)
+	}
+	// Satuan dasar tidak boleh kosong
+	if product.BaseUnit == "" {
+		product.BaseUnit = current.BaseUnit
+	}
+	product.ID = id
+	product.Version = version
+	if err := validateProduct(&product); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(actor, &product); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetByID(id)
+}
+
+func validateProduct(product *model.Product) error {
+	if strings.TrimSpace(product.Name) == "" {
+		return fmt.Errorf("%w: name wajib diisi", ErrValidation)
+	}
+	return validateProductFields(product)
+}
+
+// validateProductFields - validasi semua kolom kecuali name, impor katalog boleh mengosongkan name
+// untuk SKU yang sudah ada
+func validateProductFields(product *model.Product) error {
+	if product.Price < 0 || product.Cost < 0 {
+		return fmt.Errorf("%w: price dan cost tidak boleh negatif", ErrValidation)
+	}
+	if product.SKU != nil {
+		sku := strings.TrimSpace(*product.SKU)
+		if sku == "" {
+			product.SKU = nil
+		} else {
+			product.SKU = &sku
+		}
+	}
+	if product.QuantityPrecision < 0 || product.QuantityPrecision > model.MaxQuantityPrecision {
+		return fmt.Errorf("%w: quantity_precision harus antara 0 dan %d", ErrValidation, model.MaxQuantityPrecision)
+	}
+	if product.PLU != nil {
+		if len(*product.PLU) != 5 || strings.Trim(*product.PLU, "0123456789") != "" {
+			return fmt.Errorf("%w: PLU harus 5 digit angka", ErrValidation)
+		}
+	}
+	product.KitchenStation = strings.ToLower(strings.TrimSpace(product.KitchenStation))
+	if len(product.KitchenStation) > 50 {
+		return fmt.Errorf("%w: kitchen_station maksimal 50 karakter", ErrValidation)
+	}
+	return nil
+}
+
+func (s *ProductService) Delete(actor string, id, version int) error {
+	return s.repo.Delete(actor, id, version)
+}
+
+func (s *ProductService) Restore(actor string, id, version int) (*model.Product, error) {
+	if err := s.repo.Restore(actor, id, version); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(id)
+}
+
+// Patch - partial update (JSON Merge Patch), hasil diambil ulang dari database
+func (s *ProductService) Patch(actor string, id, version int, patch []byte) (*model.Product, error) {
+	current, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if version != 0 && current.Version != version {
+		return nil, repositories.ErrVersionMismatch
+	}
+
+	product, err := patchProduct(current, patch)
+	if err != nil {
+		return nil, err
+	}
+
+	product.ID = id
+	product.Version = current.Version
+	if err := s.repo.Update(actor, product); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetByID(id)
+}
+
+// patchProduct menerapkan merge patch ke current lalu memvalidasi hasilnya seperti PUT
+func patchProduct(current *model.Product, patch []byte) (*model.Product, error) {
+	product, err := applyMergePatch(current, patch)
+	if err != nil {
+		return nil, err
+	}
+	// Satuan dasar tidak boleh kosong, null berarti kembali ke nilai tersimpan
+	if product.BaseUnit == "" {
+		product.BaseUnit = current.BaseUnit
+	}
+	if err := validateProduct(product); err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+func (s *ProductService) GetPriceHistory(productID int) ([]model.PriceHistory, error) {
+	if _, err := s.repo.GetByID(productID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetPriceHistory(productID)
+}
+
+func (s *ProductService) GetPriceSchedules(productID int) ([]model.PriceSchedule, error) {
+	if _, err := s.repo.GetByID(productID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetPriceSchedules(productID)
+}
+
+// SchedulePrice - jadwalkan harga baru, untuk perubahan langsung pakai PUT/PATCH
+func (s *ProductService) SchedulePrice(actor string, schedule *model.PriceSchedule) error {
+	if schedule.Price < 0 {
+		return fmt.Errorf("%w: harga tidak boleh negatif", ErrValidation)
+	}
+	if !schedule.EffectiveFrom.After(time.Now()) {
+		return fmt.Errorf("%w: effective_from harus di masa depan", ErrValidation)
+	}
+	return s.repo.CreatePriceSchedule(actor, schedule)
+}
+
+func (s *ProductService) CancelPriceSchedule(actor string, productID, scheduleID int) error {
+	return s.repo.CancelPriceSchedule(actor, productID, scheduleID)
+}
+
+// ApplyDuePrices dijalankan berkala supaya harga terjadwal juga terlihat di GET produk
+func (s *ProductService) ApplyDuePrices() (int, error) {
+	return s.repo.ApplyDuePrices()
+}
+
+// SetOptions - ganti sumbu opsi varian, mis. Ukuran: S/M/L
+func (s *ProductService) SetOptions(actor string, productID, version int, options []model.ProductOption) (*model.Product, error) {
+	seen := map[string]bool{}
+	for i, opt := range options {
+		opt.Name = strings.TrimSpace(opt.Name)
+		if opt.Name == "" {
+			return nil, fmt.Errorf("%w: nama opsi wajib diisi", ErrValidation)
+		}
+		if seen[opt.Name] {
+			return nil, fmt.Errorf("%w: opsi '%s' duplikat", ErrValidation, opt.Name)
+		}
+		seen[opt.Name] = true
+
+		if len(opt.Values) == 0 {
+			return nil, fmt.Errorf("%w: opsi '%s' belum punya nilai", ErrValidation, opt.Name)
+		}
+		values := map[string]bool{}
+		for _, v := range opt.Values {
+			if v == "" || values[v] {
+				return nil, fmt.Errorf("%w: nilai opsi '%s' kosong atau duplikat", ErrValidation, opt.Name)
+			}
+			values[v] = true
+		}
+		options[i] = opt
+	}
+
+	if _, err := s.repo.SetOptions(actor, productID, version, options); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(productID)
+}
+
+func (s *ProductService) GetVariants(productID int, includeArchived bool) ([]model.ProductVariant, error) {
+	if _, err := s.repo.GetByID(productID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetVariants(productID, includeArchived)
+}
+
+func (s *ProductService) GetVariant(productID, variantID int) (*model.ProductVariant, error) {
+	return s.repo.GetVariant(productID, variantID)
+}
+
+func (s *ProductService) CreateVariant(actor string, variant *model.ProductVariant) error {
+	if err := s.prepareVariant(variant); err != nil {
+		return err
+	}
+	return s.repo.CreateVariant(actor, variant)
+}
+
+func (s *ProductService) UpdateVariant(actor string, variant *model.ProductVariant) error {
+	if err := s.prepareVariant(variant); err != nil {
+		return err
+	}
+	return s.repo.UpdateVariant(actor, variant)
+}
+
+func (s *ProductService) DeleteVariant(actor string, productID, variantID, version int) error {
+	return s.repo.DeleteVariant(actor, productID, variantID, version)
+}
+
+// prepareVariant memvalidasi opsi varian terhadap sumbu opsi produk induk dan mengisi nama varian
+func (s *ProductService) prepareVariant(variant *model.ProductVariant) error {
+	product, err := s.repo.GetByID(variant.ProductID)
+	if err != nil {
+		return err
+	}
+	if len(product.Options) == 0 {
+		return fmt.Errorf("%w: atur opsi produk '%s' dulu sebelum menambah varian", ErrValidation, product.Name)
+	}
+	if variant.Price < 0 || variant.Stock < 0 {
+		return fmt.Errorf("%w: harga dan stok varian tidak boleh negatif", ErrValidation)
+	}
+	if len(variant.Options) != len(product.Options) {
+		return fmt.Errorf("%w: varian harus mengisi semua opsi produk", ErrValidation)
+	}
+
+	names := make([]string, 0, len(product.Options))
+	for _, opt := range product.Options {
+		value, ok := variant.Options[opt.Name]
+		if !ok || !slices.Contains(opt.Values, value) {
+			return fmt.Errorf("%w: nilai opsi '%s' tidak valid", ErrValidation, opt.Name)
+		}
+		names = append(names, value)
+	}
+	variant.Name = strings.Join(names, " / ")
+
+	existing, err := s.repo.GetVariants(variant.ProductID, false)
+	if err != nil {
+		return err
+	}
+	for _, v := range existing {
+		if v.ID != variant.ID && v.Name == variant.Name {
+			return fmt.Errorf("%w: varian '%s' sudah ada", ErrValidation, variant.Name)
+		}
+	}
+
+	if variant.SKU != nil && strings.TrimSpace(*variant.SKU) == "" {
+		variant.SKU = nil
+	}
+	return nil
+}
+
+func (s *ProductService) GetUnits(productID int) ([]model.ProductUnit, error) {
+	if _, err := s.repo.GetByID(productID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetUnits(productID)
+}
+
+func (s *ProductService) CreateUnit(actor string, unit *model.ProductUnit) error {
+	if err := s.validateUnit(unit); err != nil {
+		return err
+	}
+	return s.repo.CreateUnit(actor, unit)
+}
+
+func (s *ProductService) UpdateUnit(actor string, unit *model.ProductUnit) error {
+	if err := s.validateUnit(unit); err != nil {
+		return err
+	}
+	return s.repo.UpdateUnit(actor, unit)
+}
+
+func (s *ProductService) DeleteUnit(actor string, productID, unitID int) error {
+	return s.repo.DeleteUnit(actor, productID, unitID)
+}
+
+func (s *ProductService) GetModifiers(productID int) ([]model.ProductModifier, error) {
+	if _, err := s.repo.GetByID(productID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetModifiers(productID)
+}
+
+func (s *ProductService) CreateModifier(actor string, modifier *model.ProductModifier) error {
+	if err := validateModifier(modifier); err != nil {
+		return err
+	}
+	return s.repo.CreateModifier(actor, modifier)
+}
+
+func (s *ProductService) UpdateModifier(actor string, modifier *model.ProductModifier) error {
+	if err := validateModifier(modifier); err != nil {
+		return err
+	}
+	return s.repo.UpdateModifier(actor, modifier)
+}
+
+func (s *ProductService) DeleteModifier(actor string, productID, modifierID int) error {
+	return s.repo.DeleteModifier(actor, productID, modifierID)
+}
+
+// validateModifier - price_delta boleh negatif (mis. "tanpa susu"), harga akhir dicek saat checkout
+func validateModifier(modifier *model.ProductModifier) error {
+	modifier.Name = strings.TrimSpace(modifier.Name)
+	if modifier.Name == "" {
+		return fmt.Errorf("%w: nama modifier wajib diisi", ErrValidation)
+	}
+	if len(modifier.Name) > 100 {
+		return fmt.Errorf("%w: nama modifier maksimal 100 karakter", ErrValidation)
+	}
+	return nil
+}
+
+func (s *ProductService) validateUnit(unit *model.ProductUnit) error {
+	product, err := s.repo.GetByID(unit.ProductID)
+	if err != nil {
+		return err
+	}
+
+	unit.Name = strings.TrimSpace(unit.Name)
+	if unit.Name == "" {
+		return fmt.Errorf("%w: nama satuan wajib diisi", ErrValidation)
+	}
+	if unit.Name == product.BaseUnit {
+		return fmt.Errorf("%w: '%s' adalah satuan dasar produk", ErrValidation, unit.Name)
+	}
+	if unit.Factor <= 0 {
+		return fmt.Errorf("%w: faktor konversi harus lebih dari 0", ErrValidation)
+	}
+	if unit.Price != nil && *unit.Price < 0 {
+		return fmt.Errorf("%w: harga satuan tidak boleh negatif", ErrValidation)
+	}
+	return nil
+}
+
+// SetBundleItems - atur komponen paket, komponen tidak boleh paket lain
+func (s *ProductService) SetBundleItems(actor string, bundleID, version int, items []model.BundleItem) (*model.Product, error) {
+	bundle, err := s.repo.GetByID(bundleID)
+	if err != nil {
+		return nil, err
+	}
+	if !bundle.IsBundle {
+		return nil, fmt.Errorf("%w: produk '%s' bukan paket, set is_bundle dulu", ErrValidation, bundle.Name)
+	}
+
+	seen := map[string]bool{}
+	for _, item := range items {
+		if item.ComponentID == bundleID {
+			return nil, fmt.Errorf("%w: paket tidak boleh berisi dirinya sendiri", ErrValidation)
+		}
+		if item.Quantity <= 0 {
+			return nil, fmt.Errorf("%w: jumlah komponen harus lebih dari 0", ErrValidation)
+		}
+
+		component, err := s.repo.GetByID(item.ComponentID)
+		if errors.Is(err, repositories.ErrProductNotFound) {
+			return nil, fmt.Errorf("%w: komponen dengan ID %d tidak ditemukan", ErrValidation, item.ComponentID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if component.IsBundle {
+			return nil, fmt.Errorf("%w: komponen '%s' adalah paket, paket bertingkat tidak didukung", ErrValidation, component.Name)
+		}
+
+		key := strconv.Itoa(item.ComponentID)
+		if item.ComponentVariantID != nil {
+			key += "/" + strconv.Itoa(*item.ComponentVariantID)
+			if _, err := s.repo.GetVariant(item.ComponentID, *item.ComponentVariantID); err != nil {
+				return nil, fmt.Errorf("%w: varian komponen '%s' tidak ditemukan", ErrValidation, component.Name)
+			}
+		} else if len(component.Variants) > 0 {
+			return nil, fmt.Errorf("%w: komponen '%s' punya varian, component_variant_id wajib diisi", ErrValidation, component.Name)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("%w: komponen '%s' duplikat", ErrValidation, component.Name)
+		}
+		seen[key] = true
+	}
+
+	if err := s.repo.SetBundleItems(actor, bundleID, version, items); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(bundleID)
+}
+
+// maxBatchOperations - batas operasi per batch, juga menjaga jumlah parameter statement multi-row
+const maxBatchOperations = 1000
+
+// Batch menjalankan create/update/delete produk dalam satu transaksi DB (semua atau tidak sama sekali)
+func (s *ProductService) Batch(actor string, ops []models.ProductBatchOperation) (*models.ProductBatchResponse, error) {
+	if len(ops) == 0 {
+		return nil, fmt.Errorf("%w: operations tidak boleh kosong", ErrValidation)
+	}
+	if len(ops) > maxBatchOperations {
+		return nil, fmt.Errorf("%w: maksimal %d operasi per batch", ErrValidation, maxBatchOperations)
+	}
+
+	// Produk yang di-update dibaca sekaligus supaya body operasinya bisa didekode di atasnya seperti PUT.
+	// Repository mengecek versinya lagi setelah baris dikunci, jadi data yang dibaca di sini tidak usang.
+	var updateIDs []int64
+	for _, op := range ops {
+		if op.Op == models.BatchUpdate && op.ID > 0 {
+			updateIDs = append(updateIDs, int64(op.ID))
+		}
+	}
+	stored := make(map[int]model.Product, len(updateIDs))
+	if len(updateIDs) > 0 {
+		products, err := s.repo.GetAll(models.ProductFilter{IDs: updateIDs, IncludeArchived: true})
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range products {
+			stored[p.ID] = p
+		}
+	}
+
+	results := make([]models.ProductBatchResult, len(ops))
+	seen := make(map[int]int)
+	for i := range ops {
+		op := &ops[i]
+		results[i] = models.ProductBatchResult{Index: i, Op: op.Op, ID: op.ID}
+		if err := prepareBatchOperation(op, stored); err != nil {
+			results[i].Err = err
+			continue
+		}
+		if op.Op == models.BatchCreate {
+			continue
+		}
+		if first, ok := seen[op.ID]; ok {
+			results[i].Err = fmt.Errorf("%w: produk %d sudah ada di operasi #%d", ErrValidation, op.ID, first)
+			continue
+		}
+		seen[op.ID] = i
+	}
+
+	committed, err := s.repo.Batch(actor, ops, results)
+	if err != nil {
+		return nil, err
+	}
+	return &models.ProductBatchResponse{Committed: committed, Results: results}, nil
+}
+
+// prepareBatchOperation memvalidasi operasi dan mengisi op.Product dari op.Data.
+// Update didekode di atas produk tersimpan (stored), sama seperti PUT.
+func prepareBatchOperation(op *models.ProductBatchOperation, stored map[int]model.Product) error {
+	switch op.Op {
+	case models.BatchCreate:
+		product := model.Product{}
+		if err := decodeBatchProduct(op.Data, &product); err != nil {
+			return err
+		}
+		if product.BaseUnit == "" {
+			product.BaseUnit = defaultBaseUnit
+		}
+		op.Product = &product
+		return validateProduct(op.Product)
+	case models.BatchUpdate, models.BatchDelete:
+		if op.ID <= 0 {
+			return fmt.Errorf("%w: id wajib diisi", ErrValidation)
+		}
+		// Sama seperti If-Match yang wajib di PUT/DELETE
+		if op.Version <= 0 {
+			return fmt.Errorf("%w: version wajib diisi, ambil dari GET terakhir", ErrValidation)
+		}
+		if op.Op == models.BatchDelete {
+			return nil
+		}
+		current, ok := stored[op.ID]
+		if !ok {
+			return repositories.ErrProductNotFound
+		}
+		if current.Version != op.Version {
+			return repositories.ErrVersionMismatch
+		}
+		product := current
+		if err := decodeBatchProduct(op.Data, &product); err != nil {
+			return err
+		}
+		// Satuan dasar tidak boleh kosong
+		if product.BaseUnit == "" {
+			product.BaseUnit = current.BaseUnit
+		}
+		product.ID = op.ID
+		product.Version = op.Version
+		op.Product = &product
+		return validateProduct(op.Product)
+	}
+	return fmt.Errorf("%w: op harus create, update atau delete", ErrValidation)
+}
+
+func decodeBatchProduct(data json.RawMessage, product *model.Product) error {
+	if len(data) == 0 || string(data) == "null" {
+		return fmt.Errorf("%w: product wajib diisi", ErrValidation)
+	}
+	if err := json.Unmarshal(data, product); err != nil {
+		return fmt.Errorf("%w: product tidak valid: %v", ErrValidation, err)
+	}
+	return nil
+}
+
+func (s *ProductService) GetImages(productID int) ([]model.ProductImage, error) {
+	if _, err := s.repo.GetByID(productID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetImages(productID)
+}
+
+// UploadImage menyimpan gambar produk beserta thumbnail-nya. Jenis file ditentukan dari isi
+// (bukan dari nama file atau header client), dan file di storage dihapus lagi kalau insert gagal.
+func (s *ProductService) UploadImage(actor string, productID int, data []byte) (*model.ProductImage, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: file gambar kosong", ErrValidation)
+	}
+	if len(data) > maxImageSize {
+		return nil, fmt.Errorf("%w: ukuran gambar maksimal %d MB", ErrValidation, maxImageSize>>20)
+	}
+	contentType := http.DetectContentType(data)
+	ext, ok := imageFormats[contentType]
+	if !ok {
+		return nil, fmt.Errorf("%w: format gambar '%s' tidak didukung (jpeg, png, gif)", ErrValidation, contentType)
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: gambar tidak bisa dibaca: %v", ErrValidation, err)
+	}
+	if cfg.Width*cfg.Height > maxImagePixels {
+		return nil, fmt.Errorf("%w: resolusi gambar terlalu besar (%dx%d)", ErrValidation, cfg.Width, cfg.Height)
+	}
+	if _, err := s.repo.GetByID(productID); err != nil {
+		return nil, err
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: gambar tidak bisa dibaca: %v", ErrValidation, err)
+	}
+	thumb, thumbType, err := encodeThumbnail(thumbnail(src, thumbnailSize), contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+	img := model.ProductImage{
+		ProductID:    productID,
+		Key:          fmt.Sprintf("products/%d/%s%s", productID, name, ext),
+		ThumbnailKey: fmt.Sprintf("products/%d/%s_thumb%s", productID, name, imageFormats[thumbType]),
+		ContentType:  contentType,
+		Size:         len(data),
+		Width:        cfg.Width,
+		Height:       cfg.Height,
+	}
+
+	if err := s.images.Put(img.Key, bytes.NewReader(data), contentType); err != nil {
+		return nil, err
+	}
+	if err := s.images.Put(img.ThumbnailKey, bytes.NewReader(thumb), thumbType); err != nil {
+		s.images.Delete(img.Key)
+		return nil, err
+	}
+	if err := s.repo.CreateImage(actor, &img); err != nil {
+		s.images.Delete(img.Key)
+		s.images.Delete(img.ThumbnailKey)
+		return nil, err
+	}
+	return &img, nil
 }
 
-func (s *ProductService) Delete(id int) error {
-	return s.repo.Delete(id)
+// DeleteImage menghapus baris gambar lalu file-nya; file yang gagal dihapus hanya dicatat ke log
+func (s *ProductService) DeleteImage(actor string, productID, imageID int) error {
+	img, err := s.repo.DeleteImage(actor, productID, imageID)
+	if err != nil {
+		return err
+	}
+	for _, key := range []string{img.Key, img.ThumbnailKey} {
+		if err := s.images.Delete(key); err != nil {
+			log.Printf("Gagal menghapus file gambar %s: %v", key, err)
+		}
+	}
+	return nil
 }
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"errors"
+	"kasir-api/internal/models"
+	"strings"
+	"testing"
+)
+
+func TestPatchProduct(t *testing.T) {
+	sku := "KOPI-1"
+	current := &models.Product{ID: 1, SKU: &sku, Name: "Kopi", Price: 15000, Cost: 9000, BaseUnit: "cup", Version: 3}
+
+	tests := []struct {
+		name   string
+		patch  string
+		errMsg string
+		check  func(p *models.Product) bool
+	}{
+		{name: "price only", patch: `{"price": 18000}`, check: func(p *models.Product) bool {
+			return p.Price == 18000 && p.Name == "Kopi" && p.Cost == 9000 && *p.SKU == "KOPI-1"
+		}},
+		{name: "null base unit keeps stored", patch: `{"base_unit": null}`, check: func(p *models.Product) bool {
+			return p.BaseUnit == "cup"
+		}},
+		{name: "kitchen station normalized", patch: `{"kitchen_station": " Bar "}`, check: func(p *models.Product) bool {
+			return p.KitchenStation == "bar"
+		}},
+		{name: "empty name", patch: `{"name": ""}`, errMsg: "name wajib diisi"},
+		{name: "null name", patch: `{"name": null}`, errMsg: "name wajib diisi"},
+		{name: "negative price", patch: `{"price": -1}`, errMsg: "tidak boleh negatif"},
+		{name: "negative cost", patch: `{"cost": -500}`, errMsg: "tidak boleh negatif"},
+		{name: "invalid plu", patch: `{"plu": "12a45"}`, errMsg: "PLU harus 5 digit"},
+		{name: "precision too high", patch: `{"quantity_precision": 4}`, errMsg: "quantity_precision"},
+		{name: "kitchen station too long", patch: `{"kitchen_station": "` + strings.Repeat("x", 51) + `"}`, errMsg: "kitchen_station"},
+	}
+	for _, tt := range tests {
+		got, err := patchProduct(current, []byte(tt.patch))
+		if tt.errMsg != "" {
+			if !errors.Is(err, ErrValidation) || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("%s: error = %v, want %q", tt.name, err, tt.errMsg)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: error = %v", tt.name, err)
+			continue
+		}
+		if !tt.check(got) {
+			t.Errorf("%s: got %+v", tt.name, *got)
+		}
+	}
+	if current.Name != "Kopi" || current.Price != 15000 {
+		t.Errorf("patchProduct mengubah current: %+v", *current)
+	}
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"fmt"
+	"kasir-api/internal/models"
+	"kasir-api/internal/repositories"
+	"strings"
+	"time"
+)
+
+type PurchaseService struct {
+	repo *repositories.PurchaseRepository
+}
+
+func NewPurchaseService(repo *repositories.PurchaseRepository) *PurchaseService {
+	return &PurchaseService{repo: repo}
+}
+
+func (s *PurchaseService) CreateReceipt(actor string, receipt *models.PurchaseReceipt) error {
+	if len(receipt.Lines) == 0 {
+		return fmt.Errorf("%w: penerimaan barang minimal satu baris", ErrValidation)
+	}
+	for i := range receipt.Lines {
+		line := &receipt.Lines[i]
+		if line.Quantity <= 0 {
+			return fmt.Errorf("%w: jumlah barang harus lebih dari 0", ErrValidation)
+		}
+		if line.UnitCost < 0 {
+			return fmt.Errorf("%w: harga beli tidak boleh negatif", ErrValidation)
+		}
+		line.LotNumber = strings.TrimSpace(line.LotNumber)
+		if line.ExpiresAt != nil {
+			if _, err := time.Parse("2006-01-02", *line.ExpiresAt); err != nil {
+				return fmt.Errorf("%w: expires_at harus berformat YYYY-MM-DD", ErrValidation)
+			}
+		}
+	}
+	return s.repo.CreateReceipt(actor, receipt)
+}
+
+func (s *PurchaseService) GetAll() ([]models.PurchaseReceipt, error) {
+	return s.repo.GetAll()
+}
+
+func (s *PurchaseService) GetByID(id int) (*models.PurchaseReceipt, error) {
+	return s.repo.GetByID(id)
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"fmt"
+	"kasir-api/internal/models"
+	"kasir-api/internal/repositories"
+	"strings"
+	"time"
+)
+
+const (
+	defaultReservationTTL = 15 * time.Minute
+	maxReservationTTL     = 24 * time.Hour
+)
+
+type ReservationService struct {
+	repo *repositories.ReservationRepository
+}
+
+func NewReservationService(repo *repositories.ReservationRepository) *ReservationService {
+	return &ReservationService{repo: repo}
+}
+
+var reservationStatuses = map[string]bool{
+	models.ReservationActive:   true,
+	models.ReservationConsumed: true,
+	models.ReservationExpired:  true,
+	models.ReservationReleased: true,
+}
+
+func (s *ReservationService) GetAll(filter models.ReservationFilter) ([]models.Reservation, error) {
+	if filter.Status != "" && !reservationStatuses[filter.Status] {
+		return nil, fmt.Errorf("%w: status '%s' tidak dikenal (active, consumed, expired, released)", ErrValidation, filter.Status)
+	}
+	filter.Reference = strings.TrimSpace(filter.Reference)
+	return s.repo.GetAll(filter)
+}
+
+func (s *ReservationService) GetByID(id int) (*models.Reservation, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *ReservationService) Create(actor string, req models.ReservationRequest) (*models.Reservation, error) {
+	req.Reference = strings.TrimSpace(req.Reference)
+	if len(req.Reference) > 100 {
+		return nil, fmt.Errorf("%w: reference maksimal 100 karakter", ErrValidation)
+	}
+	ttl, err := reservationTTL(req.TTLMinutes)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.Items) == 0 {
+		return nil, fmt.Errorf("%w: items wajib diisi", ErrValidation)
+	}
+	for _, item := range req.Items {
+		if item.Barcode != "" {
+			return nil, fmt.Errorf("%w: barcode timbangan tidak bisa direservasi, kirim product_id dan quantity", ErrValidation)
+		}
+		if item.ProductID <= 0 {
+			return nil, fmt.Errorf("%w: product_id wajib diisi", ErrValidation)
+		}
+		if item.Quantity <= 0 {
+			return nil, fmt.Errorf("%w: jumlah produk ID %d harus lebih dari 0", ErrValidation, item.ProductID)
+		}
+	}
+
+	id, err := s.repo.Create(actor, req, ttl)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(id)
+}
+
+// Extend - kiosk/web shop memperpanjang reservasi selama pelanggan masih di halaman pembayaran
+func (s *ReservationService) Extend(actor string, id, ttlMinutes int) (*models.Reservation, error) {
+	ttl, err := reservationTTL(ttlMinutes)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Extend(actor, id, ttl); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(id)
+}
+
+func (s *ReservationService) Release(actor string, id int) (*models.Reservation, error) {
+	if err := s.repo.Release(actor, id); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(id)
+}
+
+// ExpireDue dipanggil sweeper latar belakang
+func (s *ReservationService) ExpireDue() (int, error) {
+	return s.repo.ExpireDue()
+}
+
+func reservationTTL(minutes int) (time.Duration, error) {
+	if minutes == 0 {
+		return defaultReservationTTL, nil
+	}
+	ttl := time.Duration(minutes) * time.Minute
+	if minutes < 0 || ttl > maxReservationTTL {
+		return 0, fmt.Errorf("%w: ttl_minutes harus antara 1 dan %d", ErrValidation, int(maxReservationTTL.Minutes()))
+	}
+	return ttl, nil
+}
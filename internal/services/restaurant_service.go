@@ -0,0 +1,91 @@
+package services
+
+import (
+	"fmt"
+	"kasir-api/internal/models"
+	"kasir-api/internal/repositories"
+	"strings"
+)
+
+type TableService struct {
+	repo *repositories.TableRepository
+}
+
+func NewTableService(repo *repositories.TableRepository) *TableService {
+	return &TableService{repo: repo}
+}
+
+func (s *TableService) GetAll(includeArchived bool) ([]models.DiningTable, error) {
+	return s.repo.GetAll(includeArchived)
+}
+
+func (s *TableService) GetByID(id int) (*models.DiningTable, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *TableService) Create(actor string, table *models.DiningTable) error {
+	if err := validateTable(table); err != nil {
+		return err
+	}
+	return s.repo.Create(actor, table)
+}
+
+func (s *TableService) Update(actor string, table *models.DiningTable) (*models.DiningTable, error) {
+	if err := validateTable(table); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(actor, table); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(table.ID)
+}
+
+func (s *TableService) Delete(actor string, id, version int) error {
+	return s.repo.Delete(actor, id, version)
+}
+
+func validateTable(table *models.DiningTable) error {
+	table.Name = strings.TrimSpace(table.Name)
+	if table.Name == "" {
+		return fmt.Errorf("%w: nama meja wajib diisi", ErrValidation)
+	}
+	if len(table.Name) > 50 {
+		return fmt.Errorf("%w: nama meja maksimal 50 karakter", ErrValidation)
+	}
+	if table.Seats < 0 {
+		return fmt.Errorf("%w: jumlah kursi tidak boleh negatif", ErrValidation)
+	}
+	return nil
+}
+
+type KitchenService struct {
+	repo *repositories.KitchenRepository
+}
+
+func NewKitchenService(repo *repositories.KitchenRepository) *KitchenService {
+	return &KitchenService{repo: repo}
+}
+
+func (s *KitchenService) GetTickets(filter models.KitchenFilter) ([]models.KitchenTicket, error) {
+	for _, status := range filter.Statuses {
+		if _, ok := models.KitchenStatusOrder[status]; !ok {
+			return nil, kitchenStatusError(status)
+		}
+	}
+	filter.Station = strings.ToLower(strings.TrimSpace(filter.Station))
+	return s.repo.GetTickets(filter)
+}
+
+func (s *KitchenService) SetStatus(lineID int, status string) (*models.KitchenTicket, error) {
+	if _, ok := models.KitchenStatusOrder[status]; !ok {
+		return nil, kitchenStatusError(status)
+	}
+	if err := s.repo.SetStatus(lineID, status); err != nil {
+		return nil, err
+	}
+	return s.repo.GetTicket(lineID)
+}
+
+func kitchenStatusError(status string) error {
+	return fmt.Errorf("%w: status '%s' tidak dikenal (queued, cooking, ready, served)", ErrValidation, status)
+}
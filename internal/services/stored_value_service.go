@@ -0,0 +1,88 @@
+package services
+
+import (
+	"fmt"
+	"kasir-api/internal/models"
+	"kasir-api/internal/repositories"
+	"strings"
+)
+
+type StoredValueService struct {
+	repo *repositories.StoredValueRepository
+}
+
+func NewStoredValueService(repo *repositories.StoredValueRepository) *StoredValueService {
+	return &StoredValueService{repo: repo}
+}
+
+func (s *StoredValueService) GetAll(customerID *int) ([]models.StoredValueAccount, error) {
+	return s.repo.GetAll(customerID)
+}
+
+func (s *StoredValueService) GetByCode(code string) (*models.StoredValueAccount, error) {
+	return s.repo.GetByCode(normalizeCode(code))
+}
+
+func (s *StoredValueService) Issue(actor string, req models.IssueStoredValueRequest) (*models.StoredValueAccount, error) {
+	if req.Type != models.GiftCard && req.Type != models.StoreCredit {
+		return nil, fmt.Errorf("%w: type harus gift_card atau store_credit", ErrValidation)
+	}
+	if req.Amount <= 0 {
+		return nil, fmt.Errorf("%w: saldo awal harus lebih dari 0", ErrValidation)
+	}
+	req.Code = normalizeCode(req.Code)
+	if len(req.Code) > 64 {
+		return nil, fmt.Errorf("%w: kode maksimal 64 karakter", ErrValidation)
+	}
+	req.Note = strings.TrimSpace(req.Note)
+	return s.repo.Issue(actor, req)
+}
+
+func (s *StoredValueService) TopUp(actor string, code string, op models.StoredValueOperation) (*models.StoredValueAccount, error) {
+	if op.Amount <= 0 {
+		return nil, fmt.Errorf("%w: jumlah top up harus lebih dari 0", ErrValidation)
+	}
+	entry := models.StoredValueEntry{Amount: op.Amount, Type: models.StoredValueTopUp, Note: strings.TrimSpace(op.Note)}
+	return s.repo.Post(actor, normalizeCode(code), entry)
+}
+
+// Redeem - penukaran di luar checkout (mis. koreksi atau penukaran di sistem lain)
+func (s *StoredValueService) Redeem(actor string, code string, op models.StoredValueOperation) (*models.StoredValueAccount, error) {
+	if op.Amount <= 0 {
+		return nil, fmt.Errorf("%w: jumlah penukaran harus lebih dari 0", ErrValidation)
+	}
+	entry := models.StoredValueEntry{Amount: -op.Amount, Type: models.StoredValueRedeem, Note: strings.TrimSpace(op.Note)}
+	return s.repo.Post(actor, normalizeCode(code), entry)
+}
+
+// normalizeCode - kode tidak peka huruf besar/kecil dan spasi di pinggir
+func normalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// validatePayments dipanggil dari checkout, jumlah terhadap total dicek di repository
+func validatePayments(payments []models.CheckoutPayment) error {
+	seen := make(map[string]bool)
+	for i := range payments {
+		p := &payments[i]
+		if p.Amount <= 0 {
+			return fmt.Errorf("%w: jumlah pembayaran harus lebih dari 0", ErrValidation)
+		}
+		switch p.Method {
+		case models.PaymentCash, models.PaymentCard:
+			p.Code = ""
+		case models.PaymentStoredValue:
+			p.Code = normalizeCode(p.Code)
+			if p.Code == "" {
+				return fmt.Errorf("%w: kode kartu wajib untuk pembayaran stored_value", ErrValidation)
+			}
+			if seen[p.Code] {
+				return fmt.Errorf("%w: kartu %s dipakai lebih dari sekali", ErrValidation, p.Code)
+			}
+			seen[p.Code] = true
+		default:
+			return fmt.Errorf("%w: metode pembayaran '%s' tidak dikenal (cash, card, stored_value)", ErrValidation, p.Method)
+		}
+	}
+	return nil
+}
@@ -1,8 +1,10 @@
 package services
 
 import (
+	"fmt"
 	"kasir-api/internal/models"
 	"kasir-api/internal/repositories"
+	"strings"
 	"time"
 )
 
@@ -14,21 +16,113 @@ func NewTransactionService(repo *repositories.TransactionRepository) *Transactio
 	return &TransactionService{repo: repo}
 }
 
-func (s *TransactionService) Checkout(items []models.CheckoutItem) (*models.Transaction, error) {
-	return s.repo.CreateTransaction(items)
+func (s *TransactionService) Checkout(actor string, req models.CheckoutRequest) (*models.Transaction, error) {
+	items := req.Items
+	if len(items) == 0 {
+		return nil, fmt.Errorf("%w: keranjang kosong", ErrValidation)
+	}
+	if req.RedeemPoints < 0 {
+		return nil, fmt.Errorf("%w: redeem_points tidak boleh negatif", ErrValidation)
+	}
+	if err := validatePayments(req.Payments); err != nil {
+		return nil, err
+	}
+
+	for i := range items {
+		if items[i].Barcode != "" {
+			if err := s.resolveBarcode(&items[i]); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if items[i].Quantity <= 0 {
+			return nil, fmt.Errorf("%w: jumlah produk ID %d harus lebih dari 0", ErrValidation, items[i].ProductID)
+		}
+	}
+	for _, item := range items {
+		if err := validateModifierIDs(item.ModifierIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.repo.CreateTransaction(actor, req)
+}
+
+// resolveBarcode mengisi produk dan jumlah/harga label dari barcode timbangan
+func (s *TransactionService) resolveBarcode(item *models.CheckoutItem) error {
+	barcode, err := parseScaleBarcode(item.Barcode)
+	if err != nil {
+		return err
+	}
+
+	productID, err := s.repo.GetProductIDByPLU(barcode.PLU)
+	if err != nil {
+		return err
+	}
+	item.ProductID = productID
+	item.Unit = ""
+
+	switch {
+	case barcode.Price > 0:
+		item.LabelPrice = barcode.Price
+	case barcode.Weight > 0:
+		item.Quantity = barcode.Weight
+	default:
+		return fmt.Errorf("%w: berat/harga di barcode %s kosong", ErrValidation, item.Barcode)
+	}
+	return nil
+}
+
+func (s *TransactionService) GetByID(id int) (*models.Transaction, error) {
+	return s.repo.GetByID(id)
+}
+
+// Refund - items kosong berarti retur seluruh sisa transaksi
+func (s *TransactionService) Refund(actor string, transactionID int, req models.RefundRequest) (*models.Refund, error) {
+	seen := make(map[int]bool, len(req.Items))
+	for _, item := range req.Items {
+		if item.Quantity <= 0 {
+			return nil, fmt.Errorf("%w: jumlah retur detail %d harus lebih dari 0", ErrValidation, item.DetailID)
+		}
+		if seen[item.DetailID] {
+			return nil, fmt.Errorf("%w: detail %d muncul lebih dari sekali", ErrValidation, item.DetailID)
+		}
+		seen[item.DetailID] = true
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	return s.repo.Refund(actor, transactionID, req)
+}
+
+func (s *TransactionService) GetRefunds(transactionID int) ([]models.Refund, error) {
+	if _, err := s.repo.GetByID(transactionID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetRefunds(transactionID)
 }
 
-func (s *TransactionService) GetDailyReport(date time.Time) (*models.SalesReport, error) {
+// GetDailyReport - outletID nil berarti gabungan semua outlet
+func (s *TransactionService) GetDailyReport(date time.Time, outletID *int) (*models.SalesReport, error) {
 	startDate := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
 	endDate := time.Date(date.Year(), date.Month(), date.Day(), 23, 59, 59, 999999999, date.Location())
 
-	return s.repo.GetReportByDateRange(startDate, endDate)
+	return s.repo.GetReportByDateRange(startDate, endDate, outletID)
 }
 
-func (s *TransactionService) GetReportByDateRange(startDate, endDate time.Time) (*models.SalesReport, error) {
+func (s *TransactionService) GetReportByDateRange(startDate, endDate time.Time, outletID *int) (*models.SalesReport, error) {
 	// Adjust endDate to include the full day if needed, or assume caller handles it.
 	// For "YYYY-MM-DD" parsing, usually we get 00:00:00.
 	// We should probably set endDate to 23:59:59 of that day.
 	endDate = time.Date(endDate.Year(), endDate.Month(), endDate.Day(), 23, 59, 59, 999999999, endDate.Location())
-	return s.repo.GetReportByDateRange(startDate, endDate)
+	return s.repo.GetReportByDateRange(startDate, endDate, outletID)
+}
+
+func (s *TransactionService) GetMovementReport(startDate, endDate time.Time, outletID *int) (*models.MovementReport, error) {
+	endDate = time.Date(endDate.Year(), endDate.Month(), endDate.Day(), 23, 59, 59, 999999999, endDate.Location())
+	return s.repo.GetMovementReport(startDate, endDate, outletID)
+}
+
+// GetConsolidatedReport - ringkasan semua outlet dalam satu laporan
+func (s *TransactionService) GetConsolidatedReport(startDate, endDate time.Time) (*models.ConsolidatedReport, error) {
+	endDate = time.Date(endDate.Year(), endDate.Month(), endDate.Day(), 23, 59, 59, 999999999, endDate.Location())
+	return s.repo.GetConsolidatedReport(startDate, endDate)
 }
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"fmt"
+	"kasir-api/internal/models"
+	"kasir-api/internal/repositories"
+	"strings"
+)
+
+type TransferService struct {
+	repo *repositories.TransferRepository
+}
+
+func NewTransferService(repo *repositories.TransferRepository) *TransferService {
+	return &TransferService{repo: repo}
+}
+
+func (s *TransferService) GetAll(filter models.TransferFilter) ([]models.StockTransfer, error) {
+	switch filter.Status {
+	case "", models.TransferDraft, models.TransferInTransit, models.TransferReceived, models.TransferCancelled:
+	default:
+		return nil, fmt.Errorf("%w: status transfer '%s' tidak dikenal", ErrValidation, filter.Status)
+	}
+	return s.repo.GetAll(filter)
+}
+
+func (s *TransferService) GetByID(id int) (*models.StockTransfer, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *TransferService) Create(actor string, transfer *models.StockTransfer) (*models.StockTransfer, error) {
+	if err := validateTransfer(transfer); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(actor, transfer); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(transfer.ID)
+}
+
+// Update hanya untuk transfer draft, baris lama diganti seluruhnya
+func (s *TransferService) Update(actor string, transfer *models.StockTransfer) (*models.StockTransfer, error) {
+	if err := validateTransfer(transfer); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(actor, transfer); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(transfer.ID)
+}
+
+func (s *TransferService) Cancel(actor string, id, version int) (*models.StockTransfer, error) {
+	if err := s.repo.Cancel(actor, id, version); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(id)
+}
+
+func (s *TransferService) Dispatch(actor string, id, version int) (*models.StockTransfer, error) {
+	if err := s.repo.Dispatch(actor, id, version); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(id)
+}
+
+func (s *TransferService) Receive(actor string, id, version int, receipt models.TransferReceipt) (*models.StockTransfer, error) {
+	seen := make(map[int]bool, len(receipt.Lines))
+	for i := range receipt.Lines {
+		l := &receipt.Lines[i]
+		l.Note = strings.TrimSpace(l.Note)
+		if l.LineID <= 0 {
+			return nil, fmt.Errorf("%w: line_id wajib diisi", ErrValidation)
+		}
+		if seen[l.LineID] {
+			return nil, fmt.Errorf("%w: baris %d disebut lebih dari sekali", ErrValidation, l.LineID)
+		}
+		seen[l.LineID] = true
+		if l.ReceivedQuantity < 0 {
+			return nil, fmt.Errorf("%w: jumlah diterima tidak boleh negatif", ErrValidation)
+		}
+		if len(l.Note) > 255 {
+			return nil, fmt.Errorf("%w: catatan baris maksimal 255 karakter", ErrValidation)
+		}
+	}
+	if err := s.repo.Receive(actor, id, version, receipt); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(id)
+}
+
+func validateTransfer(transfer *models.StockTransfer) error {
+	transfer.Note = strings.TrimSpace(transfer.Note)
+	if transfer.SourceOutletID <= 0 || transfer.DestinationOutletID <= 0 {
+		return fmt.Errorf("%w: source_outlet_id dan destination_outlet_id wajib diisi", ErrValidation)
+	}
+	if transfer.SourceOutletID == transfer.DestinationOutletID {
+		return fmt.Errorf("%w: outlet asal dan tujuan tidak boleh sama", ErrValidation)
+	}
+	if len(transfer.Note) > 255 {
+		return fmt.Errorf("%w: catatan transfer maksimal 255 karakter", ErrValidation)
+	}
+	if len(transfer.Lines) == 0 {
+		return fmt.Errorf("%w: transfer minimal berisi satu baris", ErrValidation)
+	}
+
+	type lineKey struct{ productID, variantID int }
+	seen := make(map[lineKey]bool, len(transfer.Lines))
+	for i := range transfer.Lines {
+		l := &transfer.Lines[i]
+		l.Note = strings.TrimSpace(l.Note)
+		if l.ProductID <= 0 {
+			return fmt.Errorf("%w: product_id wajib diisi", ErrValidation)
+		}
+		key := lineKey{productID: l.ProductID}
+		if l.VariantID != nil {
+			key.variantID = *l.VariantID
+		}
+		if seen[key] {
+			return fmt.Errorf("%w: produk %d muncul lebih dari sekali, gabungkan jumlahnya", ErrValidation, l.ProductID)
+		}
+		seen[key] = true
+		if l.Quantity <= 0 {
+			return fmt.Errorf("%w: jumlah transfer harus lebih dari 0", ErrValidation)
+		}
+		if len(l.Note) > 255 {
+			return fmt.Errorf("%w: catatan baris maksimal 255 karakter", ErrValidation)
+		}
+	}
+	return nil
+}
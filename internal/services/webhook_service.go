@@ -0,0 +1,362 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"kasir-api/internal/models"
+	"kasir-api/internal/repositories"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Pengiriman webhook: percobaan ke-n yang gagal dijadwalkan ulang setelah
+// webhookRetryBase * 2^(n-1), paling lama webhookRetryMax. Setelah maxWebhookAttempts
+// percobaan, pengiriman ditandai failed dan hanya bisa dikirim ulang manual.
+// Event dan log pengiriman yang sudah selesai disimpan selama webhookRetention.
+const (
+	maxWebhookAttempts = 10
+	webhookRetryBase   = 30 * time.Second
+	webhookRetryMax    = 6 * time.Hour
+	webhookTimeout     = 10 * time.Second
+	webhookBatchSize   = 20
+	webhookFanOutSize  = 100
+	webhookRetention   = 30 * 24 * time.Hour
+)
+
+type WebhookService struct {
+	repo   *repositories.WebhookRepository
+	client *http.Client
+}
+
+func NewWebhookService(repo *repositories.WebhookRepository) *WebhookService {
+	return &WebhookService{repo: repo, client: newWebhookClient()}
+}
+
+var errWebhookAddress = errors.New("alamat tujuan webhook tidak boleh jaringan lokal/privat")
+
+// newWebhookClient - API tanpa autentikasi, jadi URL webhook tidak boleh dipakai untuk menjangkau
+// jaringan internal (SSRF). Alamat dicek di saat dial, setelah DNS di-resolve, supaya DNS rebinding
+// tidak bisa melewatinya. Proxy dari environment dan redirect ditolak karena keduanya melewati cek ini.
+func newWebhookClient() *http.Client {
+	dialer := &net.Dialer{Timeout: webhookTimeout, Control: denyInternalAddress}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   webhookTimeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// denyInternalAddress - hook net.Dialer.Control, address sudah berupa IP:port hasil resolve
+func denyInternalAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || internalIP(ip) {
+		return fmt.Errorf("%w: %s", errWebhookAddress, host)
+	}
+	return nil
+}
+
+// sharedAddressSpace - 100.64.0.0/10 (CGNAT), tidak tercakup IsPrivate
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// internalIP - loopback, privat, link-local (termasuk metadata cloud 169.254.169.254), unspecified dan multicast
+func internalIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() || sharedAddressSpace.Contains(ip)
+}
+
+func (s *WebhookService) GetAll(includeArchived bool) ([]models.WebhookSubscription, error) {
+	return s.repo.GetAll(includeArchived)
+}
+
+func (s *WebhookService) GetByID(id int) (*models.WebhookSubscription, error) {
+	return s.repo.GetByID(id)
+}
+
+// Create - secret kosong dibuat acak. Secret dikembalikan sekali ini saja, simpan di sisi penerima.
+func (s *WebhookService) Create(actor string, req models.WebhookSubscriptionRequest) (*models.WebhookSubscription, error) {
+	if err := validateWebhook(&req); err != nil {
+		return nil, err
+	}
+	if req.Secret == "" {
+		secret, err := newWebhookSecret()
+		if err != nil {
+			return nil, err
+		}
+		req.Secret = secret
+	}
+
+	subscription := &models.WebhookSubscription{
+		URL:         req.URL,
+		Events:      req.Events,
+		Secret:      req.Secret,
+		Description: req.Description,
+		Active:      req.Active == nil || *req.Active,
+	}
+	if err := s.repo.Create(actor, subscription); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// Update - version 0 berarti tanpa cek versi. Secret baru (kalau diisi) ikut dikembalikan di respons.
+func (s *WebhookService) Update(actor string, id, version int, req models.WebhookSubscriptionRequest) (*models.WebhookSubscription, error) {
+	if err := validateWebhook(&req); err != nil {
+		return nil, err
+	}
+	current, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if current.DeletedAt != nil {
+		return nil, repositories.ErrWebhookNotFound
+	}
+
+	subscription := &models.WebhookSubscription{
+		ID:          id,
+		URL:         req.URL,
+		Events:      req.Events,
+		Secret:      req.Secret,
+		Description: req.Description,
+		Active:      current.Active,
+		Version:     version,
+	}
+	if req.Active != nil {
+		subscription.Active = *req.Active
+	}
+	if err := s.repo.Update(actor, subscription); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+func (s *WebhookService) Delete(actor string, id, version int) error {
+	return s.repo.Delete(actor, id, version)
+}
+
+func (s *WebhookService) GetDeliveries(filter models.DeliveryFilter) ([]models.WebhookDelivery, error) {
+	switch filter.Status {
+	case "", models.DeliveryPending, models.DeliverySucceeded, models.DeliveryFailed:
+	default:
+		return nil, fmt.Errorf("%w: status pengiriman '%s' tidak dikenal (pending, succeeded, failed)", ErrValidation, filter.Status)
+	}
+	if filter.EventType != "" && !knownWebhookEvent(filter.EventType) {
+		return nil, fmt.Errorf("%w: event '%s' tidak dikenal (%s)", ErrValidation, filter.EventType, strings.Join(models.WebhookEventTypes, ", "))
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultHistoryLimit
+	}
+	if filter.Limit > maxHistoryLimit {
+		return nil, fmt.Errorf("%w: limit maksimal %d", ErrValidation, maxHistoryLimit)
+	}
+	if filter.Offset < 0 {
+		return nil, fmt.Errorf("%w: offset tidak boleh negatif", ErrValidation)
+	}
+	if _, err := s.repo.GetByID(filter.SubscriptionID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetDeliveries(filter)
+}
+
+func (s *WebhookService) GetDelivery(subscriptionID int, deliveryID int64) (*models.WebhookDelivery, error) {
+	return s.repo.GetDelivery(subscriptionID, deliveryID)
+}
+
+// RetryDelivery mengantrekan ulang pengiriman yang sudah selesai (gagal atau berhasil).
+// Langganan yang sudah dihapus tidak dikirimi lagi.
+func (s *WebhookService) RetryDelivery(subscriptionID int, deliveryID int64) (*models.WebhookDelivery, error) {
+	subscription, err := s.repo.GetByID(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if subscription.DeletedAt != nil {
+		return nil, repositories.ErrWebhookNotFound
+	}
+	if err := s.repo.RetryDelivery(subscriptionID, deliveryID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetDelivery(subscriptionID, deliveryID)
+}
+
+// Dispatch - job latar belakang: sebar event outbox ke langganan, lalu kirim pengiriman yang jatuh tempo
+func (s *WebhookService) Dispatch() error {
+	if _, err := s.repo.FanOutEvents(webhookFanOutSize); err != nil {
+		return err
+	}
+
+	// Lease cukup untuk satu batch yang semuanya kena timeout
+	deliveries, err := s.repo.ClaimDueDeliveries(webhookBatchSize, webhookBatchSize*webhookTimeout+time.Minute)
+	if err != nil {
+		return err
+	}
+	for _, d := range deliveries {
+		attempt := s.send(d)
+
+		status := models.DeliveryPending
+		switch {
+		case attempt.Error == "":
+			status = models.DeliverySucceeded
+		case d.Attempt >= maxWebhookAttempts:
+			status = models.DeliveryFailed
+		}
+		if err := s.repo.RecordAttempt(d.ID, attempt, status, time.Now().Add(webhookRetryDelay(d.Attempt))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PurgeHistory - job latar belakang: hapus event outbox dan log pengiriman yang lebih lama dari webhookRetention
+func (s *WebhookService) PurgeHistory() error {
+	_, err := s.repo.PurgeHistory(time.Now().Add(-webhookRetention))
+	return err
+}
+
+// send mengirim satu event. Hanya respons 2xx yang dianggap berhasil, selain itu Error diisi.
+func (s *WebhookService) send(d models.PendingDelivery) (attempt models.WebhookDeliveryAttempt) {
+	attempt.Attempt = d.Attempt
+	started := time.Now()
+	defer func() {
+		attempt.DurationMS = int(time.Since(started).Milliseconds())
+	}()
+
+	body, err := json.Marshal(d.Event)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	timestamp := strconv.FormatInt(started.Unix(), 10)
+
+	req, err := http.NewRequest(http.MethodPost, d.URL, bytes.NewReader(body))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "kasir-api-webhook/1.0")
+	req.Header.Set("X-Kasir-Event", d.Event.Type)
+	req.Header.Set("X-Kasir-Delivery", strconv.FormatInt(d.ID, 10))
+	req.Header.Set("X-Kasir-Timestamp", timestamp)
+	req.Header.Set("X-Kasir-Signature", "sha256="+webhookSignature(d.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer resp.Body.Close()
+
+	// Isi respons tidak disimpan (bisa berisi data dari luar yang tidak perlu dilihat lewat API),
+	// cukup dibuang sebagian supaya koneksi bisa dipakai ulang
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	attempt.StatusCode = &resp.StatusCode
+	if resp.StatusCode >= 300 && resp.StatusCode <= 399 {
+		attempt.Error = fmt.Sprintf("penerima membalas redirect HTTP %d, redirect tidak diikuti", resp.StatusCode)
+	} else if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		attempt.Error = fmt.Sprintf("penerima membalas HTTP %d", resp.StatusCode)
+	}
+	return attempt
+}
+
+// webhookSignature - hex HMAC-SHA256 dari "<timestamp>.<body>". Penerima menghitung ulang dengan
+// secret yang sama dan menolak timestamp yang terlalu lama untuk mencegah replay.
+func webhookSignature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookRetryDelay - jeda sebelum percobaan berikutnya setelah percobaan ke-attempt gagal
+func webhookRetryDelay(attempt int) time.Duration {
+	delay := webhookRetryBase
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= webhookRetryMax {
+			return webhookRetryMax
+		}
+	}
+	return delay
+}
+
+// newWebhookSecret membuat secret acak 192 bit
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+func knownWebhookEvent(event string) bool {
+	for _, e := range models.WebhookEventTypes {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+func validateWebhook(req *models.WebhookSubscriptionRequest) error {
+	req.URL = strings.TrimSpace(req.URL)
+	req.Description = strings.TrimSpace(req.Description)
+	if req.URL == "" {
+		return fmt.Errorf("%w: url wajib diisi", ErrValidation)
+	}
+	if len(req.URL) > 2048 {
+		return fmt.Errorf("%w: url maksimal 2048 karakter", ErrValidation)
+	}
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" || u.User != nil {
+		return fmt.Errorf("%w: url harus berupa alamat http(s) lengkap tanpa user:password", ErrValidation)
+	}
+	// Cek awal untuk pesan yang jelas, pengaman sebenarnya ada di denyInternalAddress saat dial
+	host := strings.ToLower(u.Hostname())
+	if ip := net.ParseIP(host); (ip != nil && internalIP(ip)) || host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("%w: url tidak boleh mengarah ke jaringan lokal/privat", ErrValidation)
+	}
+	if len(req.Description) > 255 {
+		return fmt.Errorf("%w: deskripsi maksimal 255 karakter", ErrValidation)
+	}
+	if req.Secret != "" && (len(req.Secret) < 16 || len(req.Secret) > 255) {
+		return fmt.Errorf("%w: secret antara 16 dan 255 karakter", ErrValidation)
+	}
+
+	if len(req.Events) == 0 {
+		return fmt.Errorf("%w: pilih minimal satu event (%s)", ErrValidation, strings.Join(models.WebhookEventTypes, ", "))
+	}
+	seen := make(map[string]bool, len(req.Events))
+	events := make([]string, 0, len(req.Events))
+	for _, e := range req.Events {
+		e = strings.TrimSpace(e)
+		if !knownWebhookEvent(e) {
+			return fmt.Errorf("%w: event '%s' tidak dikenal (%s)", ErrValidation, e, strings.Join(models.WebhookEventTypes, ", "))
+		}
+		if !seen[e] {
+			seen[e] = true
+			events = append(events, e)
+		}
+	}
+	req.Events = events
+	return nil
+}
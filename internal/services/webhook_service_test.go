@@ -0,0 +1,40 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWebhookSignature(t *testing.T) {
+	got := webhookSignature("rahasia", "1700000000", []byte(`{"id":1}`))
+	want := "a220d78578249610121fdb2f4c0901969b88d6a12c2e8a469484ef561168dac1"
+	if got != want {
+		t.Errorf("webhookSignature = %s, want %s", got, want)
+	}
+	if webhookSignature("rahasia", "1700000001", []byte(`{"id":1}`)) == want {
+		t.Error("timestamp lain seharusnya menghasilkan signature lain")
+	}
+	if webhookSignature("lain", "1700000000", []byte(`{"id":1}`)) == want {
+		t.Error("secret lain seharusnya menghasilkan signature lain")
+	}
+}
+
+func TestWebhookRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{0, 30 * time.Second},
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{10, 256 * time.Minute},
+		{11, webhookRetryMax},
+		{100, webhookRetryMax},
+	}
+	for _, tt := range tests {
+		if got := webhookRetryDelay(tt.attempt); got != tt.want {
+			t.Errorf("webhookRetryDelay(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+// Package spreadsheet membaca dan menulis tabel sederhana dalam format CSV dan XLSX.
+// Hanya sheet pertama XLSX yang dibaca, tanpa style maupun rumus.
+package spreadsheet
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type Format string
+
+const (
+	CSV  Format = "csv"
+	XLSX Format = "xlsx"
+)
+
+const (
+	ContentTypeCSV  = "text/csv; charset=utf-8"
+	ContentTypeXLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+var ErrUnsupportedFormat = errors.New("format file harus csv atau xlsx")
+
+// MaxColumns - batas kolom XLSX (kolom XFD), sel di luar itu pasti bukan file yang wajar
+const MaxColumns = 16384
+
+// ParseFormat menerima "csv"/"xlsx" atau nama file dengan ekstensi tersebut
+func ParseFormat(s string) (Format, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if ext := filepath.Ext(s); ext != "" {
+		s = ext[1:]
+	}
+	switch Format(s) {
+	case CSV, XLSX:
+		return Format(s), nil
+	}
+	return "", ErrUnsupportedFormat
+}
+
+func (f Format) ContentType() string {
+	if f == XLSX {
+		return ContentTypeXLSX
+	}
+	return ContentTypeCSV
+}
+
+// Read mengembalikan semua baris, baris kosong di akhir dibuang. File dengan nomor baris
+// di atas maxRows ditolak saat dibaca, sebelum tabelnya dibangun di memori.
+func Read(format Format, data []byte, maxRows int) ([][]string, error) {
+	var rows [][]string
+	var err error
+	switch format {
+	case CSV:
+		rows, err = readCSV(data, maxRows)
+	case XLSX:
+		rows, err = readXLSX(data, maxRows)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for len(rows) > 0 && isBlank(rows[len(rows)-1]) {
+		rows = rows[:len(rows)-1]
+	}
+	return rows, nil
+}
+
+// Write menulis rows, sel bertipe int/float64 ditulis sebagai angka di XLSX
+func Write(w io.Writer, format Format, rows [][]interface{}) error {
+	switch format {
+	case CSV:
+		return writeCSV(w, rows)
+	case XLSX:
+		return writeXLSX(w, rows)
+	}
+	return ErrUnsupportedFormat
+}
+
+func readCSV(data []byte, maxRows int) ([][]string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	r := csv.NewReader(bytes.NewReader(data))
+	// Excel dengan locale Indonesia menyimpan CSV dengan pemisah titik koma
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		r.Comma = ';'
+	}
+	r.FieldsPerRecord = -1
+
+	var rows [][]string
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("CSV tidak valid: %w", err)
+		}
+		if len(rows) >= maxRows {
+			return nil, tooManyRows(maxRows)
+		}
+		if len(record) > MaxColumns {
+			return nil, fmt.Errorf("CSV tidak valid: maksimal %d kolom", MaxColumns)
+		}
+		rows = append(rows, record)
+	}
+	return rows, nil
+}
+
+func writeCSV(w io.Writer, rows [][]interface{}) error {
+	cw := csv.NewWriter(w)
+	for _, row := range rows {
+		record := make([]string, len(row))
+		for i, cell := range row {
+			record[i] = formatCell(cell)
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func formatCell(cell interface{}) string {
+	switch v := cell.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(cell)
+}
+
+func isBlank(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func tooManyRows(maxRows int) error {
+	return fmt.Errorf("file melebihi %d baris (termasuk header)", maxRows)
+}
@@ -0,0 +1,123 @@
+package spreadsheet
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Format
+		err  error
+	}{
+		{"csv", CSV, nil},
+		{" XLSX ", XLSX, nil},
+		{"katalog.xlsx", XLSX, nil},
+		{"Produk.CSV", CSV, nil},
+		{"xls", "", ErrUnsupportedFormat},
+		{"katalog.ods", "", ErrUnsupportedFormat},
+	}
+	for _, tt := range tests {
+		got, err := ParseFormat(tt.in)
+		if got != tt.want || !errors.Is(err, tt.err) {
+			t.Errorf("ParseFormat(%q) = %q, %v, want %q, %v", tt.in, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+// roundTripRows - sel yang ditulis Write dan hasil bacanya lewat Read
+var roundTripRows = [][]interface{}{
+	{"sku", "name", "price", "stock", "plu"},
+	{"A1", "Kopi, susu", 15000, 2.5, nil},
+	{"A2", `Teh "tarik"`, 0, 0.125, "00042"},
+	{"A3", "<b>&amp;</b>\nbaris dua", -5, float64(3), "-"},
+}
+
+var roundTripWant = [][]string{
+	{"sku", "name", "price", "stock", "plu"},
+	{"A1", "Kopi, susu", "15000", "2.5", ""},
+	{"A2", `Teh "tarik"`, "0", "0.125", "00042"},
+	{"A3", "<b>&amp;</b>\nbaris dua", "-5", "3", "-"},
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, format := range []Format{CSV, XLSX} {
+		var buf bytes.Buffer
+		if err := Write(&buf, format, roundTripRows); err != nil {
+			t.Fatalf("%s: Write error = %v", format, err)
+		}
+		got, err := Read(format, buf.Bytes(), 10)
+		if err != nil {
+			t.Fatalf("%s: Read error = %v", format, err)
+		}
+		want := roundTripWant
+		if format == XLSX {
+			// Sel nil tidak ditulis di XLSX, jadi baris berakhir di sel terakhir yang terisi
+			want = append([][]string{}, roundTripWant...)
+			want[1] = want[1][:4]
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: round trip = %q, want %q", format, got, want)
+		}
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want [][]string
+	}{
+		{"comma", "sku,name\nA1,Kopi\n", [][]string{{"sku", "name"}, {"A1", "Kopi"}}},
+		{"semicolon from excel", "sku;name;price\nA1;Kopi, susu;15000\n", [][]string{{"sku", "name", "price"}, {"A1", "Kopi, susu", "15000"}}},
+		{"utf-8 bom", "\xef\xbb\xbfsku,name\nA1,Kopi", [][]string{{"sku", "name"}, {"A1", "Kopi"}}},
+		{"trailing blank rows trimmed", "sku\nA1\n,\n \n", [][]string{{"sku"}, {"A1"}}},
+		{"ragged rows", "sku,name,price\nA1\n", [][]string{{"sku", "name", "price"}, {"A1"}}},
+		{"empty", "", nil},
+	}
+	for _, tt := range tests {
+		got, err := Read(CSV, []byte(tt.data), 10)
+		if err != nil {
+			t.Errorf("%s: error = %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReadCSVLimits(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		errMsg string
+	}{
+		{"too many rows", strings.Repeat("A1,Kopi\n", 4), "melebihi 3 baris"},
+		{"too many columns", strings.Repeat("x,", MaxColumns) + "x\n", "maksimal 16384 kolom"},
+		{"invalid quoting", "sku,name\nA1,\"Kopi\n", "CSV tidak valid"},
+	}
+	for _, tt := range tests {
+		_, err := Read(CSV, []byte(tt.data), 3)
+		if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.errMsg)
+		}
+	}
+
+	if _, err := Read(CSV, []byte(strings.Repeat("A1,Kopi\n", 3)), 3); err != nil {
+		t.Errorf("tepat maxRows baris: error = %v", err)
+	}
+}
+
+func TestReadUnsupportedFormat(t *testing.T) {
+	if _, err := Read("ods", []byte("x"), 10); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("Read error = %v, want ErrUnsupportedFormat", err)
+	}
+	if err := Write(&bytes.Buffer{}, "ods", nil); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("Write error = %v, want ErrUnsupportedFormat", err)
+	}
+}
@@ -0,0 +1,172 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// buildXLSX membuat zip dari isi file apa adanya. Tanpa workbook.xml, sheet dibaca dari
+// xl/worksheets/sheet1.xml.
+func buildXLSX(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, body := range files {
+		fw, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(fw, body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func sheetXML(rows string) string {
+	return `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` + rows + `</sheetData></worksheet>`
+}
+
+func TestReadXLSX(t *testing.T) {
+	data := buildXLSX(t, map[string]string{
+		"xl/sharedStrings.xml": `<sst><si><t>sku</t></si><si><r><t>Kopi </t></r><r><t>susu</t></r></si></sst>`,
+		"xl/worksheets/sheet1.xml": sheetXML(
+			`<row r="1"><c r="A1" t="s"><v>0</v></c><c r="C1" t="inlineStr"><is><t>aktif</t></is></c></row>` +
+				// baris 2 kosong tidak ditulis
+				`<row r="3"><c r="A3" t="s"><v>1</v></c><c r="B3"><v>15000</v></c><c r="C3" t="b"><v>1</v></c></row>`),
+	})
+	got, err := Read(XLSX, data, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"sku", "", "aktif"},
+		nil,
+		{"Kopi susu", "15000", "true"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Read = %q, want %q", got, want)
+	}
+}
+
+func TestReadXLSXLimits(t *testing.T) {
+	// Setiap baris punya sel di kolom terakhir, jadi tiap baris mengalokasikan MaxColumns sel
+	var wide strings.Builder
+	for i := 1; i <= maxCells/MaxColumns+1; i++ {
+		fmt.Fprintf(&wide, `<row r="%d"><c r="XFD%d"><v>1</v></c></row>`, i, i)
+	}
+
+	tests := []struct {
+		name    string
+		files   map[string]string
+		maxRows int
+		errMsg  string
+	}{
+		{
+			name:    "row number above limit",
+			files:   map[string]string{"xl/worksheets/sheet1.xml": sheetXML(`<row r="1000000"><c r="A1000000"><v>1</v></c></row>`)},
+			maxRows: 10,
+			errMsg:  "melebihi 10 baris",
+		},
+		{
+			name:    "negative row number",
+			files:   map[string]string{"xl/worksheets/sheet1.xml": sheetXML(`<row r="-1"><c><v>1</v></c></row>`)},
+			maxRows: 10,
+			errMsg:  "nomor baris -1",
+		},
+		{
+			name:    "column beyond XFD",
+			files:   map[string]string{"xl/worksheets/sheet1.xml": sheetXML(`<row r="1"><c r="ZZZZZZ1"><v>1</v></c></row>`)},
+			maxRows: 10,
+			errMsg:  "di luar kolom XFD",
+		},
+		{
+			name:    "too many cells",
+			files:   map[string]string{"xl/worksheets/sheet1.xml": sheetXML(wide.String())},
+			maxRows: maxCells,
+			errMsg:  "lebih dari 4194304 sel",
+		},
+		{
+			name:    "shared string out of range",
+			files:   map[string]string{"xl/worksheets/sheet1.xml": sheetXML(`<row r="1"><c r="A1" t="s"><v>3</v></c></row>`)},
+			maxRows: 10,
+			errMsg:  "shared string",
+		},
+		{
+			name:    "missing sheet",
+			files:   map[string]string{"xl/styles.xml": `<styleSheet/>`},
+			maxRows: 10,
+			errMsg:  "sheet1.xml tidak ditemukan",
+		},
+	}
+	for _, tt := range tests {
+		_, err := Read(XLSX, buildXLSX(t, tt.files), tt.maxRows)
+		if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.errMsg)
+		}
+	}
+
+	if _, err := Read(XLSX, []byte("bukan zip"), 10); err == nil || !strings.Contains(err.Error(), "XLSX tidak valid") {
+		t.Errorf("bukan zip: error = %v", err)
+	}
+}
+
+func TestReadXLSXDecompressedSize(t *testing.T) {
+	// Sebelum dekompresi hanya beberapa puluh KB, setelahnya melebihi maxXMLSize
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	fw, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.WriteString(fw, `<worksheet><sheetData>`)
+	padding := bytes.Repeat([]byte(" "), 1<<20)
+	for written := 0; written <= maxXMLSize; written += len(padding) {
+		if _, err := fw.Write(padding); err != nil {
+			t.Fatal(err)
+		}
+	}
+	io.WriteString(fw, `</sheetData></worksheet>`)
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = Read(XLSX, buf.Bytes(), 10)
+	if err == nil || !strings.Contains(err.Error(), "XLSX terlalu besar") {
+		t.Errorf("error = %v, want XLSX terlalu besar", err)
+	}
+}
+
+func TestColumnIndexAndName(t *testing.T) {
+	tests := []struct {
+		ref   string
+		index int
+		name  string
+	}{
+		{"A1", 0, "A"},
+		{"Z9", 25, "Z"},
+		{"AA10", 26, "AA"},
+		{"AZ2", 51, "AZ"},
+		{"XFD1048576", MaxColumns - 1, "XFD"},
+	}
+	for _, tt := range tests {
+		if got := columnIndex(tt.ref); got != tt.index {
+			t.Errorf("columnIndex(%q) = %d, want %d", tt.ref, got, tt.index)
+		}
+		if got := columnName(tt.index); got != tt.name {
+			t.Errorf("columnName(%d) = %q, want %q", tt.index, got, tt.name)
+		}
+	}
+	// Referensi raksasa berhenti di MaxColumns, tidak overflow
+	if got := columnIndex("ZZZZZZZZZZZZZZZ1"); got < MaxColumns-1 {
+		t.Errorf("columnIndex(ZZZ...) = %d, want >= %d", got, MaxColumns-1)
+	}
+}
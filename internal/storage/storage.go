@@ -0,0 +1,19 @@
+// Package storage menyimpan file media (gambar produk) di balik interface Storage,
+// supaya backend lokal bisa diganti object storage tanpa mengubah service.
+package storage
+
+import (
+	"errors"
+	"io"
+)
+
+var ErrInvalidKey = errors.New("key file tidak valid")
+
+type Storage interface {
+	// Put menyimpan isi r dengan key relatif, mis. "products/5/ab12.jpg"
+	Put(key string, r io.Reader, contentType string) error
+	// Delete menghapus file, key yang tidak ada tidak dianggap error
+	Delete(key string) error
+	// URL mengembalikan alamat publik untuk key
+	URL(key string) string
+}